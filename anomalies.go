@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/nokia/ntt/internal/results"
+	"github.com/spf13/cobra"
+)
+
+var (
+	AnomaliesCommand = &cobra.Command{
+		Use:   "anomalies",
+		Short: "Find tests with suspicious durations",
+		Long: `Find tests with suspicious durations.
+
+The anomalies command loads all test results files (` + results.Filename + `)
+found in the directories given as arguments and compares the latest run of
+every test with its previous runs. Tests whose latest duration deviates more
+than --sigma standard deviations from the historical mean are listed, largest
+deviation first.
+
+Such a test is either a possible performance regression or a test which
+silently short-circuited.
+
+Mean and standard deviation of only a few samples are not meaningful. Tests with
+less than --min-history previous runs are ignored. At least five previous runs
+are recommended.
+`,
+		RunE: anomalies,
+	}
+
+	anomalySigma      float64
+	anomalyMinHistory int
+)
+
+func init() {
+	AnomaliesCommand.Flags().Float64Var(&anomalySigma, "sigma", 3, "report tests deviating more than N standard deviations")
+	AnomaliesCommand.Flags().IntVar(&anomalyMinHistory, "min-history", 5, "ignore tests with less than N previous runs")
+	ReportCommand.AddCommand(AnomaliesCommand)
+}
+
+func anomalies(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+
+	dbs, err := results.LoadHistory(args...)
+	if err != nil {
+		return err
+	}
+	var runs []results.Run
+	for _, db := range dbs {
+		runs = append(runs, db.Runs()...)
+	}
+
+	list := results.Anomalies(runs, anomalySigma, anomalyMinHistory)
+	if useJSON {
+		b, err := json.MarshalIndent(list, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+		return nil
+	}
+
+	for _, a := range list {
+		fmt.Printf("%s\t%s\t%s\t%+.1f\n", a.Name, a.Latest, a.Mean, a.Sigma)
+	}
+	return nil
+}
@@ -2,10 +2,12 @@ package main
 
 import (
 	"fmt"
+	"os"
 	"regexp"
 	"strings"
 
 	"github.com/nokia/ntt/internal/env"
+	"github.com/nokia/ntt/internal/yaml"
 	"github.com/nokia/ntt/project"
 	"github.com/spf13/pflag"
 )
@@ -49,12 +51,28 @@ import (
 // 	example.foo
 // 	example.bar
 //
+// Compound conditions on tags are expressed by boolean tag expressions with
+// the operators and, or, not and parentheses. A ~pattern atom matches the
+// qualified name against a regular expression:
+//
+// 	$ ntt list --basket='@two and not @one'
+// 	example.bar
+//
+// 	$ ntt list --basket='~foo$ or @one'
+// 	example.foo
+//
+// All filters of a basket are ANDed: an object must match every --regex,
+// --tags-regex and --basket filter and must not match any of the exclusion
+// filters. Hence exclusion filters take precedence.
+//
 func BasketFlags() *pflag.FlagSet {
 	fs := pflag.NewFlagSet("basket", pflag.ContinueOnError)
 	fs.StringSliceP("regex", "r", nil, "list objects matching regular * expression.")
 	fs.StringSliceP("exclude", "x", nil, "exclude objects matching regular * expresion.")
 	fs.StringSliceP("tags-regex", "R", nil, "list objects with tags matching regular * expression")
 	fs.StringSliceP("tags-exclude", "X", nil, "exclude objects with tags matching * regular expression")
+	fs.StringArray("basket", nil, "list objects with tags satisfying boolean expression, like '@stable and not @slow'")
+	fs.String("basket-file", "", "read basket definitions from YAML file, mapping basket names to filters or tag expressions")
 	return fs
 }
 
@@ -94,6 +112,24 @@ func BasketFlags() *pflag.FlagSet {
 // 	# This does the same:
 // 	$ ntt list --tags-regex="@wip|@flaky"
 //
+// Baskets may also be boolean tag expressions (see parseTagExpr), either
+// listed in NTT_LIST_BASKETS directly or as definition not starting with a
+// flag:
+//
+// 	$ export NTT_LIST_BASKETS_stable="not (@wip or @flaky)"
+// 	$ NTT_LIST_BASKETS="stable:@ipv6 and @slow" ntt list
+//
+// Large sets of baskets are better defined in a YAML file passed with
+// --basket-file:
+//
+// 	$ cat baskets.yml
+// 	stable: not (@wip or @flaky)
+// 	ipv6: --tags-regex @ipv6
+// 	$ NTT_LIST_BASKETS=stable:ipv6 ntt list --basket-file baskets.yml
+//
+// Definitions of the basket file take precedence over definitions of the
+// environment or configuration. Explicit filters like --basket always apply.
+//
 type Basket struct {
 	// Name is the name of the basket. The basket is used to filter objects
 	// by tag, if no explicit filters are given.
@@ -111,6 +147,13 @@ type Basket struct {
 	// Regular expressions the object tags must not match.
 	TagsExclude []string
 
+	// Boolean tag expressions the object tags must satisfy.
+	TagsExpr []string
+	exprs    []tagExpr
+
+	// Definitions of named baskets read from a basket file.
+	Definitions map[string]string
+
 	// Baskets are sub-baskets to be ORed.
 	Baskets []Basket
 }
@@ -148,9 +191,63 @@ func NewBasketWithFlags(name string, fs *pflag.FlagSet) (Basket, error) {
 	if err != nil {
 		return b, err
 	}
+	b.TagsExpr, err = fs.GetStringArray("basket")
+	if err != nil {
+		return b, err
+	}
+	for _, s := range b.TagsExpr {
+		x, err := parseTagExpr(s)
+		if err != nil {
+			return b, err
+		}
+		b.exprs = append(b.exprs, x)
+	}
+	file, err := fs.GetString("basket-file")
+	if err != nil {
+		return b, err
+	}
+	if file != "" {
+		if b.Definitions, err = readBasketFile(file); err != nil {
+			return b, err
+		}
+	}
 	return b, nil
 }
 
+// readBasketFile reads basket definitions from a YAML file mapping basket
+// names to definitions. Invalid definitions are reported as error.
+func readBasketFile(file string) (map[string]string, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var defs map[string]string
+	if err := yaml.Unmarshal(b, &defs); err != nil {
+		return nil, fmt.Errorf("basket file %s: %w", file, err)
+	}
+	for name, def := range defs {
+		if _, err := NewBasket(name, basketArgs(name, def)...); err != nil {
+			return nil, fmt.Errorf("basket file %s: basket %s: %w", file, name, err)
+		}
+	}
+	return defs, nil
+}
+
+// basketArgs returns the filter flags of the basket name with the given
+// definition. Definitions not starting with a flag are tag expressions.
+func basketArgs(name string, def string) []string {
+	switch def = strings.TrimSpace(def); {
+	case isTagExpr(name):
+		return []string{"--basket=" + name}
+	case def == "":
+		return []string{"-R", "@" + name}
+	case !strings.HasPrefix(def, "-"):
+		return []string{"--basket=" + def}
+	default:
+		return strings.Fields(def)
+	}
+}
+
 // Load baskets from given environment variable from environment
 // or from configuration.
 func (b *Basket) LoadFromEnvOrConfig(c *project.Config, key string) error {
@@ -179,12 +276,11 @@ func (b *Basket) LoadFromEnvOrConfig(c *project.Config, key string) error {
 		if name == "" {
 			continue
 		}
-		args := strings.Fields(get(fmt.Sprintf("%s_%s", key, name)))
-		if len(args) == 0 {
-			args = []string{"-R", "@" + name}
+		def, ok := b.Definitions[name]
+		if !ok {
+			def = get(fmt.Sprintf("%s_%s", key, name))
 		}
-
-		sb, err := NewBasket(name, args...)
+		sb, err := NewBasket(name, basketArgs(name, def)...)
 		if err != nil {
 			return err
 		}
@@ -230,6 +326,12 @@ func (b *Basket) match(name string, tags [][]string) bool {
 		return false
 	}
 
+	for _, x := range b.exprs {
+		if !matchExpr(x, name, tags) {
+			return false
+		}
+	}
+
 	return true
 }
 
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/nokia/ntt/ttcn3/doc"
+	"github.com/spf13/cobra"
+)
+
+var (
+	BasketCommand = &cobra.Command{
+		Use:   "basket",
+		Short: "Develop and debug baskets",
+	}
+
+	BasketTestCommand = &cobra.Command{
+		Use:   "test",
+		Short: "Match a basket against a list of test IDs",
+		Long: `Match a basket against a list of test IDs.
+
+The test command reads test IDs and their documentation tags from the file given
+by --ids and prints all IDs matching the basket expression given by --basket.
+No sources are parsed, which makes iterating on basket expressions fast.
+
+The IDs file is either the output of 'ntt list --with-tags' (one ID per line,
+followed by tab separated tags) or the output of 'ntt list --json'. Example:
+
+	$ ntt list --with-tags > ids.txt
+	$ ntt basket test --basket "-R @ipv6 -X @wip|@flaky" --ids ids.txt
+
+`,
+		RunE: basketTest,
+	}
+
+	basketExpr string
+	basketIDs  string
+)
+
+func init() {
+	BasketTestCommand.Flags().StringVar(&basketExpr, "basket", "", "basket expression, for example \"-R @stable -x foo\"")
+	BasketTestCommand.Flags().StringVar(&basketIDs, "ids", "", "read test IDs and tags from `file`")
+	BasketTestCommand.MarkFlagRequired("ids")
+	BasketCommand.AddCommand(BasketTestCommand)
+}
+
+func basketTest(cmd *cobra.Command, args []string) error {
+	b, err := NewBasket("test", strings.Fields(basketExpr)...)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(basketIDs)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	ids, err := readBasketIDs(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", basketIDs, err)
+	}
+
+	var matches []string
+	for _, id := range ids {
+		if b.Match(id.ID, id.Tags) {
+			matches = append(matches, id.ID)
+		}
+	}
+
+	if outputJSON {
+		b, err := json.MarshalIndent(matches, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+		return nil
+	}
+	for _, id := range matches {
+		fmt.Println(id)
+	}
+	return nil
+}
+
+// basketID is a test ID with its parsed documentation tags.
+type basketID struct {
+	ID   string
+	Tags [][]string
+}
+
+// readBasketIDs reads test IDs and tags as written by ntt list --with-tags or
+// ntt list --json. Tags of repeated IDs are merged. The order of first
+// occurrence is preserved.
+func readBasketIDs(r io.Reader) ([]basketID, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		ids   []basketID
+		index = make(map[string]int)
+	)
+	add := func(id string, tags ...string) {
+		i, ok := index[id]
+		if !ok {
+			i = len(ids)
+			index[id] = i
+			ids = append(ids, basketID{ID: id})
+		}
+		for _, t := range tags {
+			if tag := doc.FindTag(t); tag != nil {
+				ids[i].Tags = append(ids[i].Tags, tag)
+			}
+		}
+	}
+
+	if b = bytes.TrimSpace(b); len(b) > 0 && b[0] == '[' {
+		var entries []struct {
+			ID   string   `json:"id"`
+			Tags []string `json:"tags"`
+		}
+		if err := json.Unmarshal(b, &entries); err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			add(e.ID, e.Tags...)
+		}
+		return ids, nil
+	}
+
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		f := strings.Split(line, "\t")
+		add(strings.TrimSpace(f[0]), f[1:]...)
+	}
+	return ids, s.Err()
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// A tagExpr is a boolean expression over documentation tags and object
+// names, like "@stable and not (@slow or ~^TC_ipv6_)". It reports whether the
+// object with the given qualified name and tag names satisfies the expression.
+type tagExpr func(name string, tags map[string]bool) bool
+
+// tagToken is a token of a tag expression. Pos is the byte offset of the
+// token in the expression.
+type tagToken struct {
+	Text string
+	Pos  int
+}
+
+// parseTagExpr parses a boolean expression over tags. Tags are ANDed, ORed
+// and negated with the keywords and, or and not; parentheses group. A list of
+// tags without operators is ANDed. A ~pattern atom is a regular expression
+// matched against the qualified object name, it ends at the next white space
+// or unbalanced parenthesis:
+//
+//	expr    = term { "or" term }
+//	term    = factor { [ "and" ] factor }
+//	factor  = "not" factor | "(" expr ")" | tag | pattern
+//	tag     = "@" name
+//	pattern = "~" regexp
+//
+// Errors point at the offending token.
+func parseTagExpr(s string) (tagExpr, error) {
+	p := tagParser{src: s, toks: scanTagExpr(s)}
+	if len(p.toks) == 0 {
+		return nil, fmt.Errorf("invalid tag expression %q: empty expression", s)
+	}
+	x, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.toks) {
+		return nil, p.errorf("unexpected %q", p.toks[p.pos].Text)
+	}
+	return x, nil
+}
+
+// scanTagExpr splits s into parentheses and words separated by white space.
+// Balanced parentheses are part of ~pattern words.
+func scanTagExpr(s string) []tagToken {
+	var toks []tagToken
+	for i := 0; i < len(s); {
+		switch c := s[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(' || c == ')':
+			toks = append(toks, tagToken{Text: s[i : i+1], Pos: i})
+			i++
+		case c == '~':
+			j, depth := i, 0
+		loop:
+			for ; j < len(s); j++ {
+				switch s[j] {
+				case ' ', '\t', '\n', '\r':
+					break loop
+				case '(':
+					depth++
+				case ')':
+					if depth == 0 {
+						break loop
+					}
+					depth--
+				}
+			}
+			toks = append(toks, tagToken{Text: s[i:j], Pos: i})
+			i = j
+		default:
+			j := i
+			for j < len(s) && !strings.ContainsRune(" \t\n\r()", rune(s[j])) {
+				j++
+			}
+			toks = append(toks, tagToken{Text: s[i:j], Pos: i})
+			i = j
+		}
+	}
+	return toks
+}
+
+type tagParser struct {
+	src  string
+	toks []tagToken
+	pos  int
+}
+
+// peek returns the text of the current token or "" at the end of input.
+func (p *tagParser) peek() string {
+	if p.pos < len(p.toks) {
+		return p.toks[p.pos].Text
+	}
+	return ""
+}
+
+// errorf returns an error located at the current token.
+func (p *tagParser) errorf(format string, args ...interface{}) error {
+	col := len(p.src) + 1
+	if p.pos < len(p.toks) {
+		col = p.toks[p.pos].Pos + 1
+	}
+	return fmt.Errorf("invalid tag expression %q: column %d: %s", p.src, col, fmt.Sprintf(format, args...))
+}
+
+func (p *tagParser) parseOr() (tagExpr, error) {
+	x, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "or" {
+		p.pos++
+		y, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		a, b := x, y
+		x = func(name string, tags map[string]bool) bool { return a(name, tags) || b(name, tags) }
+	}
+	return x, nil
+}
+
+func (p *tagParser) parseAnd() (tagExpr, error) {
+	x, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch p.peek() {
+		case "", "or", ")":
+			return x, nil
+		case "and":
+			p.pos++
+		}
+		y, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		a, b := x, y
+		x = func(name string, tags map[string]bool) bool { return a(name, tags) && b(name, tags) }
+	}
+}
+
+func (p *tagParser) parseNot() (tagExpr, error) {
+	switch tok := p.peek(); {
+	case tok == "":
+		return nil, p.errorf("unexpected end of expression, expected tag or ~pattern")
+	case tok == "not":
+		p.pos++
+		x, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return func(name string, tags map[string]bool) bool { return !x(name, tags) }, nil
+	case tok == "(":
+		p.pos++
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			if p.peek() == "" {
+				return nil, p.errorf("unexpected end of expression, expected \")\"")
+			}
+			return nil, p.errorf("unexpected %q, expected \")\"", p.peek())
+		}
+		p.pos++
+		return x, nil
+	case strings.HasPrefix(tok, "@") && len(tok) > 1:
+		p.pos++
+		return func(name string, tags map[string]bool) bool { return tags[tok] }, nil
+	case strings.HasPrefix(tok, "~"):
+		re, err := regexp.Compile(tok[1:])
+		if err != nil {
+			return nil, p.errorf("%s", err.Error())
+		}
+		p.pos++
+		return func(name string, tags map[string]bool) bool { return re.MatchString(name) }, nil
+	default:
+		return nil, p.errorf("unexpected %q, expected tag or ~pattern", tok)
+	}
+}
+
+// matchExpr returns true if the object with the given name and tags
+// satisfies the tag expression.
+func matchExpr(x tagExpr, name string, tags [][]string) bool {
+	names := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		names[tag[0]] = true
+	}
+	return x(name, names)
+}
+
+// isTagExpr returns true if s is a tag expression rather than a basket name.
+func isTagExpr(s string) bool {
+	return strings.ContainsAny(s, "@~() \t")
+}
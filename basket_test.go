@@ -2,9 +2,12 @@ package main
 
 import (
 	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
+	"github.com/nokia/ntt/internal/yaml"
 	"github.com/nokia/ntt/ttcn3/doc"
 )
 
@@ -69,6 +72,69 @@ func TestBasketMatch(t *testing.T) {
 	}
 }
 
+func TestBasketTagExpr(t *testing.T) {
+	tests := []struct {
+		expr string
+		name string
+		tags []string
+		want bool
+	}{
+		{expr: "@stable", tags: []string{"@stable"}, want: true},
+		{expr: "@stable", tags: []string{"@stable_ish"}, want: false},
+		{expr: "@stable and not @slow", tags: []string{"@stable"}, want: true},
+		{expr: "@stable and not @slow", tags: []string{"@stable", "@slow"}, want: false},
+		{expr: "@stable or @slow", tags: []string{"@slow"}, want: true},
+		{expr: "@a or @b and @c", tags: []string{"@a"}, want: true},
+		{expr: "(@a or @b) and @c", tags: []string{"@a"}, want: false},
+		{expr: "not not @a", tags: []string{"@a"}, want: true},
+		{expr: "not (@a or @b)", want: true},
+		{expr: "@prio", tags: []string{"@prio: high"}, want: true},
+
+		{expr: "~^m\\.tc", name: "m.tc1", want: true},
+		{expr: "~^m\\.tc", name: "x.m.tc1", want: false},
+		{expr: "~tc(1|2)", name: "m.tc2", want: true},
+		{expr: "(~tc(1|2))", name: "m.tc2", want: true},
+		{expr: "~tc1 and not @wip", name: "m.tc1", tags: []string{"@wip"}, want: false},
+		{expr: "~tc1 or @wip", name: "m.tc2", tags: []string{"@wip"}, want: true},
+
+		// A list of tags without operators must match all tags.
+		{expr: "@a @b", tags: []string{"@a", "@b"}, want: true},
+		{expr: "@a @b", tags: []string{"@a"}, want: false},
+	}
+
+	for _, tt := range tests {
+		b, err := NewBasket("testBasket", "--basket="+tt.expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual := b.Match(tt.name, doc.FindAllTags(strings.Join(tt.tags, "\n")))
+		if actual != tt.want {
+			t.Errorf("Basket(%q).Match(%q, %q) = %v, want %v", tt.expr, tt.name, tt.tags, actual, tt.want)
+		}
+	}
+}
+
+func TestParseTagExprError(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{expr: "", want: `invalid tag expression "": empty expression`},
+		{expr: "@a and", want: `invalid tag expression "@a and": column 7: unexpected end of expression, expected tag or ~pattern`},
+		{expr: "@a or or @b", want: `invalid tag expression "@a or or @b": column 7: unexpected "or", expected tag or ~pattern`},
+		{expr: "(@a", want: `invalid tag expression "(@a": column 4: unexpected end of expression, expected ")"`},
+		{expr: "@a)", want: `invalid tag expression "@a)": column 3: unexpected ")"`},
+		{expr: "~foo(", want: `invalid tag expression "~foo(": column 1: error parsing regexp: missing closing ): ` + "`foo(`"},
+		{expr: "@a and wip", want: `invalid tag expression "@a and wip": column 8: unexpected "wip", expected tag or ~pattern`},
+	}
+	for _, tt := range tests {
+		_, err := parseTagExpr(tt.expr)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("parseTagExpr(%q) = %v, want %s", tt.expr, err, tt.want)
+		}
+	}
+}
+
 func TestSubBaskets(t *testing.T) {
 	tests := []struct {
 		basket string
@@ -142,3 +208,149 @@ func TestLoadFromEnv(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadTagExprFromEnv(t *testing.T) {
+	os.Setenv("TEST_EXPR_BASKET", "fast:@ipv6 and @smoke")
+	os.Setenv("TEST_EXPR_BASKET_fast", "@stable and not @slow")
+	defer func() {
+		os.Unsetenv("TEST_EXPR_BASKET")
+		os.Unsetenv("TEST_EXPR_BASKET_fast")
+	}()
+
+	b, err := NewBasket("testBasket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.LoadFromEnvOrConfig(nil, "TEST_EXPR_BASKET"); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		tags []string
+		want bool
+	}{
+		{tags: []string{"@stable"}, want: true},
+		{tags: []string{"@stable", "@slow"}, want: false},
+		{tags: []string{"@ipv6", "@smoke", "@slow"}, want: true},
+		{tags: []string{"@ipv6"}, want: false},
+	}
+	for _, tt := range tests {
+		actual := b.Match("foo", doc.FindAllTags(strings.Join(tt.tags, "\n")))
+		if actual != tt.want {
+			t.Errorf("Basket.Match(%q) = %v, want %v", tt.tags, actual, tt.want)
+		}
+	}
+
+	os.Setenv("TEST_EXPR_BASKET", "@a and")
+	b, _ = NewBasket("testBasket")
+	if err := b.LoadFromEnvOrConfig(nil, "TEST_EXPR_BASKET"); err == nil {
+		t.Errorf("LoadFromEnvOrConfig accepted an invalid tag expression")
+	}
+}
+
+func TestBasketFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "baskets.yml")
+	content := "fast: \"@stable and not @slow\"\nipv6: --tags-regex @ipv6\n"
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	defs, err := readBasketFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"fast": "@stable and not @slow", "ipv6": "--tags-regex @ipv6"}
+	if !reflect.DeepEqual(defs, want) {
+		t.Errorf("readBasketFile() = %v, want %v", defs, want)
+	}
+
+	// Definitions written back are read unchanged.
+	b, err := yaml.Marshal(defs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if defs, err = readBasketFile(file); err != nil || !reflect.DeepEqual(defs, want) {
+		t.Errorf("readBasketFile() = %v, %v, want %v", defs, err, want)
+	}
+
+	if err := os.WriteFile(file, []byte("bad: \"@a and\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readBasketFile(file); err == nil {
+		t.Errorf("readBasketFile accepted an invalid tag expression")
+	}
+}
+
+func TestBasketFileMerge(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "baskets.yml")
+	if err := os.WriteFile(file, []byte("fast: \"@stable and not @slow\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("TEST_FILE_BASKET", "fast:ipv6")
+	os.Setenv("TEST_FILE_BASKET_fast", "-R @wip")
+	os.Setenv("TEST_FILE_BASKET_ipv6", "@ipv6")
+	defer func() {
+		os.Unsetenv("TEST_FILE_BASKET")
+		os.Unsetenv("TEST_FILE_BASKET_fast")
+		os.Unsetenv("TEST_FILE_BASKET_ipv6")
+	}()
+
+	b, err := NewBasket("testBasket", "--basket-file", file, "--basket", "not @flaky")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.LoadFromEnvOrConfig(nil, "TEST_FILE_BASKET"); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		tags []string
+		want bool
+	}{
+		{tags: []string{"@stable"}, want: true},
+		{tags: []string{"@wip"}, want: false},
+		{tags: []string{"@ipv6"}, want: true},
+		{tags: []string{"@stable", "@flaky"}, want: false},
+	}
+	for _, tt := range tests {
+		actual := b.Match("foo", doc.FindAllTags(strings.Join(tt.tags, "\n")))
+		if actual != tt.want {
+			t.Errorf("Basket.Match(%q) = %v, want %v", tt.tags, actual, tt.want)
+		}
+	}
+}
+
+func TestReadBasketIDs(t *testing.T) {
+	tests := []struct {
+		input  string
+		basket string
+		want   []string
+	}{
+		{input: "a.foo\na.bar\n", basket: "", want: []string{"a.foo", "a.bar"}},
+		{input: "a.foo\t@wip\na.bar\t@stable\n", basket: "-X @wip", want: []string{"a.bar"}},
+		{input: "a.foo\t@wip\na.foo\t@ipv6\na.bar\t@ipv6\n", basket: "-R @ipv6 -X @wip", want: []string{"a.bar"}},
+		{input: "a.foo\t@prio:high\na.bar\t@prio:low\n", basket: "-R @prio:high", want: []string{"a.foo"}},
+		{input: `[{"id":"a.foo","tags":["@wip"]},{"id":"a.bar"}]`, basket: "-X @wip", want: []string{"a.bar"}},
+	}
+
+	for _, tt := range tests {
+		b, err := NewBasket("testBasket", strings.Fields(tt.basket)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids, err := readBasketIDs(strings.NewReader(tt.input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var actual []string
+		for _, id := range ids {
+			if b.Match(id.ID, id.Tags) {
+				actual = append(actual, id.ID)
+			}
+		}
+		if !reflect.DeepEqual(actual, tt.want) {
+			t.Errorf("Basket(%q) matches %v, want %v", tt.basket, actual, tt.want)
+		}
+	}
+}
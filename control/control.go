@@ -5,6 +5,8 @@ import (
 	"errors"
 	"sync"
 	"time"
+
+	"github.com/nokia/ntt/internal/log"
 )
 
 var ErrNoFactory = errors.New("factory is not set")
@@ -21,6 +23,8 @@ type RunnerFactory func() (Runner, error)
 type Controller struct {
 	sync.Mutex
 	maxWorkers int
+	rampUp     time.Duration
+	logDir     string
 	running    map[*Job]time.Time
 	factory    RunnerFactory
 }
@@ -49,15 +53,35 @@ func (c *Controller) Run(ctx context.Context) <-chan Event {
 		wg.Add(1)
 		go func(i int) {
 			defer wg.Done()
+			ctx := ctx
+			if c.logDir != "" {
+				f, err := openWorkerLog(c.logDir, i)
+				if err != nil {
+					results <- NewErrorEvent(err)
+					return
+				}
+				defer f.Close()
+				ctx = context.WithValue(ctx, workerLogKey{}, &log.ConsoleLogger{Out: f})
+			}
+
+			WorkerLogf(ctx, "worker %d waiting", i)
+			if !c.wait(ctx, i) {
+				WorkerLogf(ctx, "worker %d canceled", i)
+				return
+			}
 			worker, err := c.factory()
 			if err != nil {
+				WorkerLogf(ctx, "error: %s", err.Error())
 				results <- NewErrorEvent(err)
 				return
 			}
 
+			WorkerLogf(ctx, "worker %d started", i)
 			for event := range worker.Run(ctx) {
+				logEvent(ctx, event)
 				results <- event
 			}
+			WorkerLogf(ctx, "worker %d finished", i)
 		}(i)
 	}
 
@@ -78,6 +102,7 @@ func (c *Controller) Run(ctx context.Context) <-chan Event {
 					c.running[ev.Job] = ev.Time()
 				case StopEvent:
 					ev.Begin = c.running[ev.Job]
+					res = ev
 				}
 				out <- res
 			case <-ticker.C:
@@ -97,6 +122,23 @@ func (c *Controller) Run(ctx context.Context) <-chan Event {
 	return out
 }
 
+// wait delays the start of worker i according to the ramp-up duration. It
+// returns false if ctx is done before.
+func (c *Controller) wait(ctx context.Context, i int) bool {
+	d := c.rampUp / time.Duration(c.maxWorkers) * time.Duration(i)
+	if d <= 0 {
+		return true
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
 type Option func(*Controller) error
 
 func MaxWorkers(n int) Option {
@@ -112,3 +154,25 @@ func WithFactory(f RunnerFactory) Option {
 		return nil
 	}
 }
+
+// RampUp spreads the start of the workers evenly over duration d. The first
+// worker starts immediately, then another one every d/n, with n being the
+// maximum number of workers. Once all workers are started, the parallelism is
+// not limited any further.
+func RampUp(d time.Duration) Option {
+	return func(c *Controller) error {
+		c.rampUp = d
+		return nil
+	}
+}
+
+// WorkerLogDir makes each worker write a diagnostic log to dir/worker-<n>.log.
+// The log records the lifecycle of the worker, the jobs it runs and the
+// commands spawned for them. Existing logs are appended to (see
+// RotateWorkerLogs).
+func WorkerLogDir(dir string) Option {
+	return func(c *Controller) error {
+		c.logDir = dir
+		return nil
+	}
+}
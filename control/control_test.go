@@ -0,0 +1,205 @@
+package control_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nokia/ntt/control"
+	"github.com/stretchr/testify/assert"
+)
+
+type nopRunner struct{}
+
+func (nopRunner) Run(context.Context) <-chan control.Event {
+	ch := make(chan control.Event)
+	close(ch)
+	return ch
+}
+
+func TestRampUp(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		starts []time.Duration
+	)
+	begin := time.Now()
+	factory := func() (control.Runner, error) {
+		mu.Lock()
+		starts = append(starts, time.Since(begin))
+		mu.Unlock()
+		return nopRunner{}, nil
+	}
+
+	c, err := control.New(
+		control.MaxWorkers(4),
+		control.RampUp(200*time.Millisecond),
+		control.WithFactory(factory),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range c.Run(context.Background()) {
+	}
+
+	sort.Slice(starts, func(i, j int) bool { return starts[i] < starts[j] })
+	assert.Len(t, starts, 4)
+	for i, d := range starts {
+		assert.GreaterOrEqual(t, d, time.Duration(i)*50*time.Millisecond, "worker %d", i)
+	}
+	assert.Less(t, starts[0], 50*time.Millisecond)
+}
+
+func TestRampUpCancel(t *testing.T) {
+	var (
+		mu sync.Mutex
+		n  int
+	)
+	factory := func() (control.Runner, error) {
+		mu.Lock()
+		n++
+		mu.Unlock()
+		return nopRunner{}, nil
+	}
+	c, err := control.New(
+		control.MaxWorkers(4),
+		control.RampUp(time.Hour),
+		control.WithFactory(factory),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	for range c.Run(ctx) {
+	}
+	assert.Equal(t, 1, n)
+}
+
+type eventRunner []control.Event
+
+func (r eventRunner) Run(ctx context.Context) <-chan control.Event {
+	ch := make(chan control.Event)
+	go func() {
+		defer close(ch)
+		control.WorkerLogf(ctx, "+ k3r test.t3xf")
+		for _, e := range r {
+			ch <- e
+		}
+	}()
+	return ch
+}
+
+func TestWorkerLogDir(t *testing.T) {
+	dir := t.TempDir()
+	job := &control.Job{ID: "test.A-1", Name: "test.A"}
+	factory := func() (control.Runner, error) {
+		return eventRunner{
+			control.NewStartEvent(job, job.Name),
+			control.NewStopEvent(job, job.Name, "pass"),
+		}, nil
+	}
+
+	run := func() {
+		c, err := control.New(
+			control.MaxWorkers(1),
+			control.WorkerLogDir(dir),
+			control.WithFactory(factory),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for range c.Run(context.Background()) {
+		}
+	}
+
+	run()
+	b, err := os.ReadFile(filepath.Join(dir, "worker-0.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := string(b)
+	assert.Contains(t, s, "worker 0 started")
+	assert.Contains(t, s, "+ k3r test.t3xf")
+	assert.Contains(t, s, "started test.A (job test.A-1)")
+	assert.Contains(t, s, "finished test.A (job test.A-1): pass")
+	assert.Contains(t, s, "worker 0 finished")
+
+	// A second controller of the same run appends to the log.
+	run()
+	b, err = os.ReadFile(filepath.Join(dir, "worker-0.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, strings.Count(string(b), "worker 0 started"))
+	assert.True(t, strings.HasPrefix(string(b), s))
+
+	// Rotation keeps the logs of the previous run.
+	assert.Nil(t, control.RotateWorkerLogs(dir))
+	run()
+	old, err := os.ReadFile(filepath.Join(dir, "worker-0.log.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, string(b), string(old))
+	b, err = os.ReadFile(filepath.Join(dir, "worker-0.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, strings.Count(string(b), "worker 0 started"))
+
+	// Missing directories have nothing to rotate.
+	assert.Nil(t, control.RotateWorkerLogs(filepath.Join(dir, "missing")))
+}
+
+func TestRedactEvent(t *testing.T) {
+	redact := func(s string) string { return strings.ReplaceAll(s, "hunter2", "***") }
+	job := &control.Job{ID: "test.A-1"}
+
+	e := control.RedactEvent(control.NewLogEvent(job, "password: hunter2"), redact)
+	assert.Equal(t, "password: ***", e.(control.LogEvent).Text)
+
+	e = control.RedactEvent(control.NewErrorEvent(&control.JobError{Job: job, Err: errors.New("login hunter2 failed")}), redact)
+	assert.Equal(t, "login *** failed", e.(control.ErrorEvent).Err.Error())
+	assert.Equal(t, job, control.UnwrapJob(e))
+
+	stop := control.NewStopEvent(job, "test.A", "fail")
+	assert.Equal(t, stop, control.RedactEvent(stop, redact))
+}
+
+func TestEvalSkipIf(t *testing.T) {
+	vars := map[string]string{"OS": "linux", "FEATURE_X": "off"}
+	lookup := func(k string) (string, bool) {
+		v, ok := vars[k]
+		return v, ok
+	}
+	tests := []struct {
+		expr string
+		want bool
+		err  bool
+	}{
+		{expr: "OS==linux", want: true},
+		{expr: " OS == windows ", want: false},
+		{expr: "OS!=windows", want: true},
+		{expr: "FEATURE_X != off", want: false},
+		{expr: "UNDEFINED==", want: true},
+		{expr: "UNDEFINED!=", want: false},
+		{expr: "OS", err: true},
+		{expr: "==linux", err: true},
+	}
+	for _, tt := range tests {
+		got, err := control.EvalSkipIf(tt.expr, lookup)
+		if tt.err {
+			assert.NotNil(t, err, tt.expr)
+			continue
+		}
+		assert.Nil(t, err, tt.expr)
+		assert.Equal(t, tt.want, got, tt.expr)
+	}
+}
@@ -39,6 +39,10 @@ func NewLogEvent(job *Job, text string) LogEvent {
 // StartEvent is an event that is emitted when the test is started.
 type StartEvent struct {
 	Name string
+
+	// Attempt is the attempt of the job, which started. Zero, if unknown.
+	Attempt int
+
 	event
 	*Job
 }
@@ -53,6 +57,13 @@ type StopEvent struct {
 	Name    string
 	Verdict string
 	Begin   time.Time
+
+	// WorkDir is the working directory of the test, if known.
+	WorkDir string
+
+	// Attempt is the attempt of the job, which stopped. Zero, if unknown.
+	Attempt int
+
 	event
 	*Job
 }
@@ -62,6 +73,22 @@ func NewStopEvent(job *Job, name string, verdict string) StopEvent {
 	return StopEvent{event: event{t: time.Now()}, Job: job, Name: name, Verdict: verdict}
 }
 
+// DoneEvent is emitted when a runner is done with a job, including clean-up
+// like hooks and the removal of temporary directories. DoneEvent follows all
+// other events of the job. Only then the job may be run again.
+type DoneEvent struct {
+	// Attempt is the attempt of the job, which is done. Zero, if unknown.
+	Attempt int
+
+	event
+	*Job
+}
+
+// NewDoneEvent creates a new DoneEvent.
+func NewDoneEvent(job *Job) DoneEvent {
+	return DoneEvent{event: event{t: time.Now()}, Job: job}
+}
+
 // TickerEvent is an event that is emitted periodically during the test execution.
 type TickerEvent struct {
 	event
@@ -89,6 +116,8 @@ func UnwrapJob(e Event) *Job {
 		return e.Job
 	case LogEvent:
 		return e.Job
+	case DoneEvent:
+		return e.Job
 	case ErrorEvent:
 		var err *JobError
 		if errors.As(e.Err, &err) {
@@ -97,3 +126,74 @@ func UnwrapJob(e Event) *Job {
 	}
 	return nil
 }
+
+// WithAttempt returns e tagged with attempt, the attempt of the job producing
+// it. Start, stop, error and done events of a job still arriving after the job
+// was retried can be told apart this way (see AttemptOf).
+func WithAttempt(e Event, attempt int) Event {
+	if attempt < 1 {
+		attempt = 1
+	}
+	switch ev := e.(type) {
+	case StartEvent:
+		ev.Attempt = attempt
+		return ev
+	case StopEvent:
+		ev.Attempt = attempt
+		return ev
+	case DoneEvent:
+		ev.Attempt = attempt
+		return ev
+	case ErrorEvent:
+		var err *JobError
+		if errors.As(ev.Err, &err) {
+			err.Attempt = attempt
+		}
+	}
+	return e
+}
+
+// AttemptOf returns the attempt of the job, which produced e, or zero if
+// unknown.
+func AttemptOf(e Event) int {
+	switch e := e.(type) {
+	case StartEvent:
+		return e.Attempt
+	case StopEvent:
+		return e.Attempt
+	case DoneEvent:
+		return e.Attempt
+	case ErrorEvent:
+		var err *JobError
+		if errors.As(e.Err, &err) {
+			return err.Attempt
+		}
+	}
+	return 0
+}
+
+// RedactEvent returns e with the texts of log and error events passed through
+// redact. Redacted errors still unwrap to the original error, hence the job of
+// an error event is preserved.
+func RedactEvent(e Event, redact func(string) string) Event {
+	switch ev := e.(type) {
+	case LogEvent:
+		ev.Text = redact(ev.Text)
+		return ev
+	case ErrorEvent:
+		if msg := redact(ev.Err.Error()); msg != ev.Err.Error() {
+			ev.Err = &redactedError{msg: msg, err: ev.Err}
+		}
+		return ev
+	}
+	return e
+}
+
+// redactedError replaces the message of an error.
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string { return e.msg }
+func (e *redactedError) Unwrap() error { return e.err }
@@ -0,0 +1,187 @@
+package control
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nokia/ntt/internal/env"
+	"github.com/nokia/ntt/internal/log"
+	"github.com/nokia/ntt/internal/proc"
+	"github.com/nokia/ntt/project"
+)
+
+// Hook events called once per test run.
+const (
+	BeforeAll = "before_all"
+	AfterAll  = "after_all"
+)
+
+// Hook events called around every job.
+const (
+	BeforeEach = "before_each"
+	AfterEach  = "after_each"
+)
+
+// RunHook calls the hooks file of the given configuration with event as first
+// argument. Additional environment variables may be passed by vars. RunHook
+// does nothing when no hooks file is configured.
+//
+// The hook inherits the environment of ntt, the variables of the project
+// configuration and following variables:
+//
+//	K3_NAME         Name of the test suite.
+//	K3_HOOKS_FILE   Path to the hooks file.
+//	K3_SOURCE_DIR   Path of the manifest file.
+//	K3_TIMEOUT      The default test timeout, if specified.
+//
+// Stdout and stderr of the hook are redirected to stderr.
+func RunHook(ctx context.Context, conf *project.Config, event string, vars ...string) error {
+	if conf == nil || conf.HooksFile == "" {
+		return nil
+	}
+
+	cmd := proc.CommandContext(ctx, conf.HooksFile, event)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(cmd.Env, os.Environ()...)
+	cmd.Env = append(cmd.Env, env.Env(conf.Variables).Slice()...)
+	cmd.Env = append(cmd.Env, "K3_NAME="+conf.Name, "K3_HOOKS_FILE="+conf.HooksFile)
+	if file := conf.ManifestFile; file != "" {
+		cmd.Env = append(cmd.Env, "K3_SOURCE_DIR="+file)
+	}
+	if t := conf.Timeout.Duration; t > 0 {
+		cmd.Env = append(cmd.Env, "K3_TIMEOUT="+t.String())
+	}
+	cmd.Env = append(cmd.Env, vars...)
+
+	log.Debugf("+ %s\n", cmd.String())
+	WorkerLogf(ctx, "+ %s", cmd.String())
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("hook %s: %w", event, err)
+	}
+	return nil
+}
+
+// WebhookTimeout limits the time a webhook of VerdictHooks may take.
+var WebhookTimeout = 10 * time.Second
+
+// VerdictHooks invoke actions when tests end with a verdict of interest, like
+// paging on-call when a critical test fails. The caller decides which verdicts
+// are of interest by calling Fire.
+//
+// An action is either a webhook or a command. Actions starting with http://
+// or https:// are webhooks, which receive a JSON object with the test name,
+// the verdict and the reason via POST:
+//
+//	{"name": "test.Critical", "verdict": "fail", "reason": "timeout"}
+//
+// Other actions are commands, split into fields like the reporter plugin
+// command. They inherit the environment of ntt and following variables:
+//
+//	K3_TEST_NAME     Full qualified test name.
+//	K3_TEST_VERDICT  The verdict of the test.
+//	K3_TEST_REASON   The reason of the verdict, if any.
+//
+// Actions run asynchronously. Their failures are logged as warnings, but do
+// not affect the test run.
+type VerdictHooks struct {
+	hooks []verdictHook
+	wg    sync.WaitGroup
+}
+
+type verdictHook struct {
+	pattern string
+	action  string
+}
+
+type verdictHookPayload struct {
+	Name    string `json:"name"`
+	Verdict string `json:"verdict"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// Register adds an action for tests matching pattern. The pattern syntax is
+// that of path.Match, for example test.* or *.TC_attach.
+func (h *VerdictHooks) Register(pattern string, action string) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("hook pattern %q: %w", pattern, err)
+	}
+	if strings.TrimSpace(action) == "" {
+		return fmt.Errorf("hook for %q: empty action", pattern)
+	}
+	h.hooks = append(h.hooks, verdictHook{pattern: pattern, action: action})
+	return nil
+}
+
+// Fire starts the actions registered for patterns matching name. Fire does
+// not wait for the actions to complete.
+func (h *VerdictHooks) Fire(ctx context.Context, name string, verdict string, reason string) {
+	if h == nil {
+		return
+	}
+	for _, hook := range h.hooks {
+		if ok, _ := path.Match(hook.pattern, name); !ok {
+			continue
+		}
+		h.wg.Add(1)
+		go func(action string) {
+			defer h.wg.Done()
+			if err := runVerdictHook(ctx, action, verdictHookPayload{Name: name, Verdict: verdict, Reason: reason}); err != nil {
+				log.Printf("warning: hook for %s: %s\n", name, err.Error())
+			}
+		}(hook.action)
+	}
+}
+
+// Wait waits for all actions started by Fire to complete.
+func (h *VerdictHooks) Wait() {
+	if h != nil {
+		h.wg.Wait()
+	}
+}
+
+func runVerdictHook(ctx context.Context, action string, p verdictHookPayload) error {
+	if strings.HasPrefix(action, "http://") || strings.HasPrefix(action, "https://") {
+		b, err := json.Marshal(p)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(ctx, WebhookTimeout)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, action, bytes.NewReader(b))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		log.Debugf("+ POST %s\n", action)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode/100 != 2 {
+			return fmt.Errorf("%s: %s", action, resp.Status)
+		}
+		return nil
+	}
+
+	args := strings.Fields(action)
+	cmd := proc.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(cmd.Env, os.Environ()...)
+	cmd.Env = append(cmd.Env, "K3_TEST_NAME="+p.Name, "K3_TEST_VERDICT="+p.Verdict, "K3_TEST_REASON="+p.Reason)
+	log.Debugf("+ %s\n", cmd.String())
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w", action, err)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package control_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nokia/ntt/control"
+	"github.com/nokia/ntt/project"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunHook(t *testing.T) {
+	t.Parallel()
+	t.Run("no hooks file", func(t *testing.T) {
+		assert.Nil(t, control.RunHook(context.Background(), &project.Config{}, control.BeforeAll))
+		assert.Nil(t, control.RunHook(context.Background(), nil, control.BeforeAll))
+	})
+
+	t.Run("event and environment", func(t *testing.T) {
+		dir := t.TempDir()
+		out := filepath.Join(dir, "out")
+		conf := &project.Config{}
+		conf.Name = "suite"
+		conf.HooksFile = writeHook(t, dir, `echo "$1 $K3_NAME $FOO" > `+out)
+		err := control.RunHook(context.Background(), conf, control.AfterAll, "FOO=bar")
+		assert.Nil(t, err)
+		b, _ := os.ReadFile(out)
+		assert.Equal(t, "after_all suite bar\n", string(b))
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		conf := &project.Config{}
+		conf.HooksFile = writeHook(t, t.TempDir(), `exit 1`)
+		assert.NotNil(t, control.RunHook(context.Background(), conf, control.BeforeAll))
+	})
+}
+
+func TestVerdictHooks(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out")
+	script := writeHook(t, dir, `echo "$K3_TEST_NAME $K3_TEST_VERDICT $K3_TEST_REASON" >> `+out)
+
+	var got []map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var m map[string]string
+		json.NewDecoder(r.Body).Decode(&m)
+		got = append(got, m)
+	}))
+	defer srv.Close()
+
+	var h control.VerdictHooks
+	assert.Nil(t, h.Register("test.Critical*", script))
+	assert.Nil(t, h.Register("*.TC_?", srv.URL))
+	assert.Nil(t, h.Register("test.Broken", filepath.Join(dir, "missing")))
+	assert.NotNil(t, h.Register("test.[", script))
+	assert.NotNil(t, h.Register("test.A", " "))
+
+	ctx := context.Background()
+	h.Fire(ctx, "test.Critical", "fail", "timeout")
+	h.Wait()
+	h.Fire(ctx, "test.TC_A", "error", "")
+	h.Wait()
+	h.Fire(ctx, "test.Other", "fail", "")
+	h.Fire(ctx, "test.Broken", "fail", "")
+	h.Wait()
+
+	b, _ := os.ReadFile(out)
+	assert.Equal(t, "test.Critical fail timeout\n", string(b))
+	assert.Equal(t, []map[string]string{{"name": "test.TC_A", "verdict": "error"}}, got)
+
+	var nilHooks *control.VerdictHooks
+	nilHooks.Fire(ctx, "test.A", "fail", "")
+	nilHooks.Wait()
+}
+
+func writeHook(t *testing.T, dir string, body string) string {
+	path := filepath.Join(dir, "test.hooks")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -2,6 +2,8 @@
 package control
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/nokia/ntt/project"
@@ -15,6 +17,11 @@ type Job struct {
 	// Name is the fully qualified name of the test or control function.
 	Name string
 
+	// Subtest is the identifier of the instance of a data-driven test, like
+	// test.A[PX_RATE=10], or of a repetition, like test.A#2. Subtest is
+	// empty for regular jobs.
+	Subtest string
+
 	// Args is the list of arguments to pass to the test.
 	Args []string
 
@@ -24,12 +31,58 @@ type Job struct {
 	// Module Parameters
 	ModulePars map[string]string
 
-	// Dir specifies the working directory for the job.
+	// Dir specifies the directory containing the working directory of the
+	// job (see WorkDir).
 	Dir string
 
+	// Layout generates the path of the working directory inside Dir. The
+	// job ID is used, if Layout is nil.
+	Layout *Layout
+
+	// Attempt is the number of the current attempt of the job, starting
+	// at 1. Zero counts as 1. OrderedQueue.Retry increments it.
+	Attempt int
+
+	// Iteration is the number of the repetition, when the same jobs are
+	// run repeatedly, starting at 1. Zero counts as 1.
+	Iteration int
+
 	// Env specifies the environment variables to pass to the job.
 	Env []string
 
+	// Weight is the relative resource demand of the job, used to limit
+	// the jobs running concurrently (see k3r.WithSemaphore). Zero counts
+	// as 1.
+	Weight int
+
+	// After lists the names of tests, which must have passed before the
+	// job is started (see OrderedQueue).
+	After []string
+
+	// Labels are arbitrary key-value pairs attached to the job, for
+	// example by @label tags or by tools generating tests files. Labels
+	// without value are stored with an empty value.
+	Labels map[string]string
+
+	// Tags are the names of the documentation tags of the test, like @wip,
+	// in order of appearance and without duplicates.
+	Tags []string
+
+	// TempDir is a private temporary directory for the job. When set,
+	// TMPDIR, TMP and TEMP point to it. Runners create TempDir before the
+	// job starts and remove it afterwards, unless KeepTempDir is true.
+	TempDir     string
+	KeepTempDir bool
+
+	// SkipReason is set for jobs, which shall not be run, for example
+	// because of a @skip-if tag. Such jobs are reported as skipped.
+	SkipReason string
+
+	// Fingerprint is a content hash of the test definition and the
+	// definitions it depends on (see ttcn3.Node.Fingerprint). It is empty
+	// if the definition is not known.
+	Fingerprint string
+
 	// Config provides the project configuration
 	*project.Config
 }
@@ -45,6 +98,9 @@ func NewJob(name string, conf *project.Config) *Job {
 type JobError struct {
 	*Job
 	Err error
+
+	// Attempt is the attempt of the job, which failed. Zero, if unknown.
+	Attempt int
 }
 
 func (e *JobError) Error() string {
@@ -54,3 +110,47 @@ func (e *JobError) Error() string {
 func (e *JobError) Unwrap() error {
 	return e.Err
 }
+
+// MatchLabels returns true if labels contain all labels of sel. A selector
+// with empty value matches any value.
+func MatchLabels(labels map[string]string, sel map[string]string) bool {
+	for k, v := range sel {
+		l, ok := labels[k]
+		if !ok || (v != "" && l != v) {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseLabels parses a list of labels of the form key=value or key,
+// separated by commas or white space.
+func ParseLabels(s string) (map[string]string, error) {
+	ret := make(map[string]string)
+	for _, f := range strings.Fields(strings.ReplaceAll(s, ",", " ")) {
+		k, v, _ := strings.Cut(f, "=")
+		if k == "" {
+			return nil, fmt.Errorf("invalid label %q: missing key", f)
+		}
+		ret[k] = v
+	}
+	return ret, nil
+}
+
+// EvalSkipIf evaluates the condition of a @skip-if tag. A condition has the
+// form VAR==value or VAR!=value. Variables are looked up with lookup, undefined
+// variables are empty.
+func EvalSkipIf(expr string, lookup func(string) (string, bool)) (bool, error) {
+	op := "=="
+	k, v, ok := strings.Cut(expr, op)
+	if !ok {
+		op = "!="
+		k, v, ok = strings.Cut(expr, op)
+	}
+	k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+	if !ok || k == "" {
+		return false, fmt.Errorf("invalid @skip-if condition %q: must be VAR==value or VAR!=value", expr)
+	}
+	val, _ := lookup(k)
+	return (val == v) == (op == "=="), nil
+}
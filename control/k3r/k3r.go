@@ -32,6 +32,11 @@ type Test struct {
 
 	// LogFile is the path to the log file.
 	LogFile string
+
+	// Dir is the working directory of the test. Unlike Job.Dir, which
+	// contains the working directories of all jobs, it is specific to
+	// this test instance.
+	Dir string
 }
 
 var (
@@ -72,7 +77,7 @@ func (t *Test) Run(ctx context.Context) <-chan control.Event {
 		defer close(events)
 
 		if !strings.Contains(t.Name, ".") {
-			events <- control.NewErrorEvent(ErrNotQualified)
+			events <- control.NewErrorEvent(&control.JobError{Job: t.Job, Err: ErrNotQualified})
 			return
 		}
 
@@ -101,14 +106,14 @@ func (t *Test) Run(ctx context.Context) <-chan control.Event {
 		cmd.Dir = t.Dir
 		env, err := buildEnv(t)
 		if err != nil {
-			events <- control.NewErrorEvent(err)
+			events <- control.NewErrorEvent(&control.JobError{Job: t.Job, Err: err})
 			return
 		}
 		cmd.Env = env
 
 		sid, err := session.Get()
 		if err != nil {
-			events <- control.NewErrorEvent(err)
+			events <- control.NewErrorEvent(&control.JobError{Job: t.Job, Err: err})
 			return
 		}
 		defer session.Release(sid)
@@ -116,7 +121,7 @@ func (t *Test) Run(ctx context.Context) <-chan control.Event {
 
 		abs, err := filepath.Abs(t.LogFile)
 		if err != nil {
-			events <- control.NewErrorEvent(err)
+			events <- control.NewErrorEvent(&control.JobError{Job: t.Job, Err: err})
 			return
 		}
 		cmd.Env = append(cmd.Env, fmt.Sprintf("K3_TEST_LOG=%s", abs))
@@ -124,12 +129,12 @@ func (t *Test) Run(ctx context.Context) <-chan control.Event {
 		cmd.Stdin = strings.NewReader(t.request())
 		stdout, err := cmd.StdoutPipe()
 		if err != nil {
-			events <- control.NewErrorEvent(&Error{Err: err})
+			events <- control.NewErrorEvent(&control.JobError{Job: t.Job, Err: &Error{Err: err}})
 			return
 		}
 		stderr, err := cmd.StderrPipe()
 		if err != nil {
-			events <- control.NewErrorEvent(&Error{Err: err})
+			events <- control.NewErrorEvent(&control.JobError{Job: t.Job, Err: &Error{Err: err}})
 			return
 		}
 		log.Debugf("env:\n")
@@ -137,11 +142,14 @@ func (t *Test) Run(ctx context.Context) <-chan control.Event {
 			log.Debugf("  %s\n", e)
 		}
 		log.Debugf("+ %s\n", cmd.String())
-		err = cmd.Start()
+		control.WorkerLogf(ctx, "+ %s", cmd.String())
+		// Processes spawned by k3r are stopped together with it.
+		release, err := proc.StartGroup(ctx, cmd)
 		if err != nil {
-			events <- control.NewErrorEvent(&Error{Err: err})
+			events <- control.NewErrorEvent(&control.JobError{Job: t.Job, Err: &Error{Err: err}})
 			return
 		}
+		defer release()
 
 		var wg sync.WaitGroup
 		wg.Add(1)
@@ -153,7 +161,7 @@ func (t *Test) Run(ctx context.Context) <-chan control.Event {
 				events <- control.NewLogEvent(t.Job, "k3r: "+scanner.Text())
 			}
 			if err := scanner.Err(); err != nil {
-				events <- control.NewErrorEvent(&Error{Err: err})
+				events <- control.NewErrorEvent(&control.JobError{Job: t.Job, Err: &Error{Err: err}})
 			}
 		}()
 
@@ -178,39 +186,39 @@ func (t *Test) Run(ctx context.Context) <-chan control.Event {
 			case "tciError":
 				switch v[1] {
 				case "E101:":
-					events <- control.NewErrorEvent(ErrNoSuchModule)
+					events <- control.NewErrorEvent(&control.JobError{Job: t.Job, Err: ErrNoSuchModule})
 				case "E102:":
-					events <- control.NewErrorEvent(ErrNoSuchTest)
+					events <- control.NewErrorEvent(&control.JobError{Job: t.Job, Err: ErrNoSuchTest})
 				case "E103:":
-					events <- control.NewErrorEvent(ErrNoSuchControl)
+					events <- control.NewErrorEvent(&control.JobError{Job: t.Job, Err: ErrNoSuchControl})
 				case "E200:":
-					events <- control.NewErrorEvent(ErrRuntimeNotReady)
+					events <- control.NewErrorEvent(&control.JobError{Job: t.Job, Err: ErrRuntimeNotReady})
 				case "E201:":
 					// ErrModuleNotReady happens, when tciRootModule was not called.
 					// This is a spurious error, so we'll ignore it.
 					//events <- control.NewErrorEvent(ErrModuleNotReady)
 				case "E202:":
-					events <- control.NewErrorEvent(ErrTestNotReady)
+					events <- control.NewErrorEvent(&control.JobError{Job: t.Job, Err: ErrTestNotReady})
 				case "E203:":
-					events <- control.NewErrorEvent(ErrControlNotReady)
+					events <- control.NewErrorEvent(&control.JobError{Job: t.Job, Err: ErrControlNotReady})
 				case "E999:":
-					events <- control.NewErrorEvent(fmt.Errorf("%w: %s", ErrNotImplemented, strings.Join(v[2:], " ")))
+					events <- control.NewErrorEvent(&control.JobError{Job: t.Job, Err: fmt.Errorf("%w: %s", ErrNotImplemented, strings.Join(v[2:], " "))})
 				default:
-					events <- control.NewErrorEvent(fmt.Errorf("%w: %s", ErrUnknown, strings.Join(v[1:], " ")))
+					events <- control.NewErrorEvent(&control.JobError{Job: t.Job, Err: fmt.Errorf("%w: %s", ErrUnknown, strings.Join(v[1:], " "))})
 				}
 			default:
-				events <- control.NewErrorEvent(fmt.Errorf("%w: %s", ErrInvalidMessage, line))
+				events <- control.NewErrorEvent(&control.JobError{Job: t.Job, Err: fmt.Errorf("%w: %s", ErrInvalidMessage, line)})
 			}
 		}
 		if err := scanner.Err(); err != nil {
-			events <- control.NewErrorEvent(&Error{Err: err})
+			events <- control.NewErrorEvent(&control.JobError{Job: t.Job, Err: &Error{Err: err}})
 		}
 		wg.Wait()
 		err = waitGracefully(t, cmd)
 		if ctx.Err() == context.DeadlineExceeded {
-			events <- control.NewErrorEvent(ErrTimeout)
+			events <- control.NewErrorEvent(&control.JobError{Job: t.Job, Err: ErrTimeout})
 		} else if err != nil {
-			events <- control.NewErrorEvent(&Error{Err: err})
+			events <- control.NewErrorEvent(&control.JobError{Job: t.Job, Err: &Error{Err: err}})
 		}
 	}()
 
@@ -279,6 +287,13 @@ func buildEnv(t *Test) ([]string, error) {
 	}
 
 	ret = append(ret, t.Env...)
+	if t.TempDir != "" {
+		dir, err := filepath.Abs(t.TempDir)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, "TMPDIR="+dir, "TMP="+dir, "TEMP="+dir)
+	}
 	ret = append(ret, buildEnvPaths("K3R_PATH", k3rPaths...))
 	ret = append(ret, buildEnvPaths("LD_LIBRARY_PATH", ldLibraryPaths...))
 	ret = append(ret, buildEnvPaths("PATH", binPaths...))
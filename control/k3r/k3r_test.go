@@ -255,6 +255,24 @@ func TestBuildEnv(t *testing.T) {
 		assert.Nil(t, err)
 		assert.Equal(t, want, got)
 	})
+	t.Run("temp dir", func(t *testing.T) {
+		want := []string{
+			"K3_NAME=",
+			"TMPDIR=/tmp/job",
+			"TMP=/tmp/job",
+			"TEMP=/tmp/job",
+			"K3R_PATH=.",
+			"LD_LIBRARY_PATH=.",
+			"PATH=.",
+			"K3_SERVER=pipe,/dev/fd/0,/dev/fd/1"}
+		reset := clearEnv()
+		defer reset()
+		test := newTest()
+		test.TempDir = "/tmp/job"
+		got, err := buildEnv(test)
+		assert.Nil(t, err)
+		assert.Equal(t, want, got)
+	})
 }
 
 func newTest() *Test {
@@ -285,3 +303,67 @@ func initStage(t *testing.T) (string, string) {
 
 	return old, dir
 }
+
+// Errors of a test, like timeouts, must be attributed to its job. Otherwise
+// jobs waiting for it would never be dispatched.
+func TestTimeoutDependency(t *testing.T) {
+	dir := t.TempDir()
+	k3r := filepath.Join(dir, "k3r")
+	if err := os.WriteFile(k3r, []byte("#!/bin/sh\nexec sleep 10\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	a := &tsts.Job{ID: "test.A-0", Name: "test.A", Dir: dir}
+	b := &tsts.Job{ID: "test.B-0", Name: "test.B", Dir: dir, After: []string{"test.A"}}
+	q, err := tsts.NewOrderedQueue([]*tsts.Job{a, b})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	jobs := q.Jobs(ctx)
+	job := <-jobs
+	assert.Equal(t, a, job)
+
+	tctx, tcancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer tcancel()
+	tst := &Test{Job: job, T3XF: "test.t3xf", Runtime: k3r, Dir: dir}
+	for e := range tst.Run(tctx) {
+		if e, ok := e.(tsts.ErrorEvent); ok {
+			assert.ErrorIs(t, e, ErrTimeout)
+			q.Done(tsts.UnwrapJob(e), false)
+		}
+	}
+
+	select {
+	case job, ok := <-jobs:
+		assert.False(t, ok, "unexpected job %v", job)
+	case <-time.After(5 * time.Second):
+		t.Fatal("jobs waiting for the timed out job were not skipped")
+	}
+	assert.Equal(t, []*tsts.Job{b}, q.Skipped())
+}
+
+func TestBeforeEachFailure(t *testing.T) {
+	dir := t.TempDir()
+	hooks := filepath.Join(dir, "hooks.sh")
+	if err := os.WriteFile(hooks, []byte("#!/bin/sh\n[ \"$1\" != before_each ]\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	conf := &project.Config{}
+	conf.HooksFile = hooks
+
+	jobs := make(chan *tsts.Job, 1)
+	jobs <- &tsts.Job{ID: "test.A-0", Name: "test.A", Config: conf}
+	close(jobs)
+
+	// The failure must count only once, hence there is no verdict.
+	var actual []string
+	for e := range NewRunner(jobs).Run(context.Background()) {
+		actual = append(actual, strings.TrimPrefix(fmt.Sprintf("%T", e), "control."))
+		if job := tsts.UnwrapJob(e); job == nil || job.ID != "test.A-0" {
+			t.Errorf("event %T without job", e)
+		}
+	}
+	assert.Equal(t, []string{"StartEvent", "ErrorEvent", "DoneEvent"}, actual)
+}
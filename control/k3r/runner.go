@@ -10,91 +10,265 @@ import (
 
 	"github.com/nokia/ntt/control"
 	"github.com/nokia/ntt/internal/env"
+	"golang.org/x/sync/semaphore"
 )
 
 // Runner executes tests using k3r.
 type Runner struct {
 	jobs <-chan *control.Job
+	sem  *semaphore.Weighted
 }
 
-func Factory(jobs <-chan *control.Job) control.RunnerFactory {
-	return func() (control.Runner, error) { return NewRunner(jobs), nil }
+// Option configures a Runner.
+type Option func(*Runner)
+
+// WithSemaphore lets the runner acquire sem with the weight of each job
+// before executing it. Runners sharing a semaphore limit the summed weight
+// of their concurrently running jobs. Jobs heavier than sem never start,
+// hence their weight has to be limited beforehand.
+func WithSemaphore(sem *semaphore.Weighted) Option {
+	return func(r *Runner) {
+		r.sem = sem
+	}
 }
 
-func NewRunner(jobs <-chan *control.Job) *Runner {
-	return &Runner{jobs: jobs}
+func Factory(jobs <-chan *control.Job, opts ...Option) control.RunnerFactory {
+	return func() (control.Runner, error) { return NewRunner(jobs, opts...), nil }
 }
 
-func (r *Runner) Run(ctx context.Context) <-chan control.Event {
-	var (
-		workingDir string
-		logFile    string
-	)
+func NewRunner(jobs <-chan *control.Job, opts ...Option) *Runner {
+	r := &Runner{jobs: jobs}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
 
+func (r *Runner) Run(ctx context.Context) <-chan control.Event {
 	results := make(chan control.Event)
 	go func() {
 		defer close(results)
 		for job := range r.jobs {
-			ctx := ctx
-			if job.Dir != "" {
-				workingDir = filepath.Join(job.Dir, job.ID)
-				if err := os.MkdirAll(workingDir, 0755); err != nil {
-					results <- control.NewErrorEvent(err)
-					continue
-				}
-			} else {
-				logFile = fmt.Sprintf("%s.log", strings.TrimSuffix(job.ID, "-0"))
+			// The attempt is fixed before the job runs, because
+			// the job may be retried while its last events are
+			// still on the way.
+			attempt := job.Attempt
+			weight := int64(job.Weight)
+			if weight < 1 {
+				weight = 1
 			}
-
-			t3xf := job.Config.K3.T3XF
-			if workingDir != "" {
-				absT3xf, err := filepath.Abs(t3xf)
-				if err != nil {
-					results <- control.NewErrorEvent(err)
-					continue
-				}
-				absDir, err := filepath.Abs(workingDir)
-				if err != nil {
-					results <- control.NewErrorEvent(err)
-					continue
-				}
-				t3xf, err = filepath.Rel(absDir, absT3xf)
-				if err != nil {
-					results <- control.NewErrorEvent(err)
+			if r.sem != nil {
+				if err := r.sem.Acquire(ctx, weight); err != nil {
+					results <- control.WithAttempt(control.NewErrorEvent(&control.JobError{Job: job, Err: err}), attempt)
+					results <- control.WithAttempt(control.NewDoneEvent(job), attempt)
 					continue
 				}
 			}
+			events := make(chan control.Event)
+			go func() {
+				defer close(events)
+				r.runJob(ctx, job, events)
+			}()
+			for e := range events {
+				results <- control.WithAttempt(e, attempt)
+			}
+			if r.sem != nil {
+				r.sem.Release(weight)
+			}
+			results <- control.WithAttempt(control.NewDoneEvent(job), attempt)
+		}
+	}()
+	return results
+}
 
-			t := NewTest(t3xf, job)
+// runJob executes a single job and sends its events to results.
+func (r *Runner) runJob(ctx context.Context, job *control.Job, results chan<- control.Event) {
+	var (
+		workingDir string
+		logFile    string
+	)
 
-			var (
-				timeout time.Duration
-				err     error
-			)
-			if err != nil {
-				results <- control.NewErrorEvent(err)
-				continue
-			}
+	// Hooks have their own per-test timeout, independent of the test.
+	hookCtx := ctx
+	start := control.NewLayoutData(job, time.Now())
+	if job.Dir != "" {
+		dir, err := job.WorkDir(start)
+		if err != nil {
+			results <- control.NewErrorEvent(&control.JobError{Job: job, Err: err})
+			return
+		}
+		workingDir = dir
+		if err := os.MkdirAll(workingDir, 0755); err != nil {
+			results <- control.NewErrorEvent(&control.JobError{Job: job, Err: err})
+			return
+		}
+	} else {
+		logFile = fmt.Sprintf("%s.log", strings.TrimSuffix(job.ID, "-0"))
+	}
 
-			var cancel context.CancelFunc
-			if timeout > 0 {
-				ctx, cancel = context.WithTimeout(ctx, timeout)
-			}
+	t3xf := job.Config.K3.T3XF
+	if workingDir != "" {
+		absT3xf, err := filepath.Abs(t3xf)
+		if err != nil {
+			results <- control.NewErrorEvent(&control.JobError{Job: job, Err: err})
+			return
+		}
+		absDir, err := filepath.Abs(workingDir)
+		if err != nil {
+			results <- control.NewErrorEvent(&control.JobError{Job: job, Err: err})
+			return
+		}
+		t3xf, err = filepath.Rel(absDir, absT3xf)
+		if err != nil {
+			results <- control.NewErrorEvent(&control.JobError{Job: job, Err: err})
+			return
+		}
+	}
 
-			// TODO(5nord) implement module parameters
-			t.Dir = workingDir
-			t.LogFile = logFile
-			t.Env = env.Environ()
-			if s := env.Getenv("NTT_CACHE"); s != "" {
-				t.Env = append(t.Env, strings.Split(s, string(os.PathListSeparator))...)
-			}
-			for e := range t.Run(ctx) {
-				results <- e
-			}
+	t := NewTest(t3xf, job)
+
+	var (
+		timeout time.Duration
+		err     error
+	)
+	if err != nil {
+		results <- control.NewErrorEvent(&control.JobError{Job: job, Err: err})
+		return
+	}
+
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+
+	// TODO(5nord) implement module parameters
+	t.Dir = workingDir
+	t.LogFile = logFile
+	t.Env = inheritedEnv()
+	if job.TempDir != "" {
+		if err := os.MkdirAll(job.TempDir, 0755); err != nil {
+			results <- control.NewErrorEvent(&control.JobError{Job: job, Err: err})
 			if cancel != nil {
 				cancel()
 			}
+			return
 		}
-	}()
-	return results
+	}
+
+	// A failing before_each hook fails the job like a crashing test: a
+	// single error and no verdict.
+	if err := r.runHook(hookCtx, job, workingDir, control.BeforeEach); err != nil {
+		results <- control.NewStartEvent(job, job.Name)
+		results <- control.NewErrorEvent(&control.JobError{Job: job, Err: err})
+		if cancel != nil {
+			cancel()
+		}
+		return
+	}
+
+	verdict := ""
+	var stop *control.StopEvent
+	for e := range t.Run(ctx) {
+		if e, ok := e.(control.StopEvent); ok {
+			verdict = e.Verdict
+			e.WorkDir = workingDir
+
+			// With a layout the working directory may depend on
+			// the verdict. The stop event is held back until the
+			// directory has its final name.
+			if job.Layout != nil && workingDir != "" {
+				stop = &e
+				continue
+			}
+		}
+		results <- e
+	}
+	if cancel != nil {
+		cancel()
+	}
+
+	if job.Layout != nil && workingDir != "" {
+		final := start
+		final.Verdict = verdict
+		if final.Verdict == "" {
+			final.Verdict = "error"
+		}
+		dir, err := job.WorkDir(final)
+		if err == nil && dir != workingDir {
+			err = renameDir(workingDir, dir)
+		}
+		if err != nil {
+			results <- control.NewLogEvent(job, fmt.Sprintf("warning: %s", err.Error()))
+		} else {
+			workingDir = dir
+		}
+		if stop != nil {
+			stop.WorkDir = workingDir
+			results <- *stop
+		}
+	}
+
+	if err := r.runHook(hookCtx, job, workingDir, control.AfterEach, "K3_TEST_VERDICT="+verdict); err != nil {
+		results <- control.NewLogEvent(job, fmt.Sprintf("warning: %s", err.Error()))
+	}
+
+	if job.TempDir != "" && !job.KeepTempDir {
+		if err := os.RemoveAll(job.TempDir); err != nil {
+			results <- control.NewLogEvent(job, fmt.Sprintf("warning: %s", err.Error()))
+		}
+	}
+}
+
+// Environ returns the environment the given job would be executed with.
+// Variables only known at execution time, like K3_SESSION_ID or K3_TEST_LOG,
+// are not included.
+func Environ(job *control.Job) ([]string, error) {
+	t := NewTest(job.Config.K3.T3XF, job)
+	t.Env = inheritedEnv()
+	return buildEnv(t)
+}
+
+// inheritedEnv returns the environment every test inherits from ntt.
+func inheritedEnv() []string {
+	ret := env.Environ()
+	if s := env.Getenv("NTT_CACHE"); s != "" {
+		ret = append(ret, strings.Split(s, string(os.PathListSeparator))...)
+	}
+	return ret
+}
+
+// renameDir moves directory old to new, creating the parent directories of new.
+// An existing directory new is replaced, like the working directory of a job
+// without layout is overwritten by later attempts.
+func renameDir(old, new string) error {
+	if err := os.MkdirAll(filepath.Dir(new), 0755); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(new); err != nil {
+		return err
+	}
+	return os.Rename(old, new)
+}
+
+// runHook calls the hooks file with the given per-test event. The hook
+// receives the job ID, test name and working directory dir via environment
+// and is bound to the per-test timeout.
+func (r *Runner) runHook(ctx context.Context, job *control.Job, dir string, event string, vars ...string) error {
+	if job.Config == nil || job.Config.HooksFile == "" {
+		return nil
+	}
+	if job.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, job.Timeout)
+		defer cancel()
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	vars = append([]string{
+		"K3_TEST_ID=" + job.ID,
+		"K3_TEST_NAME=" + job.Name,
+		"K3_TEST_DIR=" + dir,
+	}, vars...)
+	return control.RunHook(ctx, job.Config, event, vars...)
 }
@@ -32,6 +32,7 @@ Compiling test %s in %q`, job.Name, job.Config.Root)
 			if err := Build(r.w, job.Config); err != nil {
 				fmt.Fprintln(r.w, err.Error())
 				results <- control.NewErrorEvent(&control.JobError{Job: job, Err: err})
+				results <- control.NewDoneEvent(job)
 				continue
 			}
 
@@ -50,6 +51,7 @@ Content of log directory %q:
 					logDir, strings.Join(files, "\n"))
 			}
 			results <- control.NewStopEvent(job, job.Name, "")
+			results <- control.NewDoneEvent(job)
 
 		}
 	}()
@@ -0,0 +1,106 @@
+package control
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// TimestampLayout is the time format of LayoutData.Timestamp.
+const TimestampLayout = "20060102-150405"
+
+// A Layout is a text/template generating the path of the working directory
+// of a job relative to Job.Dir, like "{{.ID}}/{{.Attempt}}-{{.Verdict}}".
+type Layout struct {
+	text string
+	tmpl *template.Template
+}
+
+// LayoutData provides the fields available to a Layout.
+type LayoutData struct {
+	// ID is the unique identifier of the job.
+	ID string
+
+	// Name is the fully qualified name of the test.
+	Name string
+
+	// Attempt is the number of the attempt, starting at 1.
+	Attempt int
+
+	// Iteration is the number of the repetition, starting at 1.
+	Iteration int
+
+	// Verdict is "running" while the test runs, afterwards its verdict or
+	// "error", if the test ended without verdict.
+	Verdict string
+
+	// Timestamp is the start time of the attempt (see TimestampLayout).
+	Timestamp string
+}
+
+// NewLayoutData returns the layout data of a job, which started at begin and
+// is still running.
+func NewLayoutData(job *Job, begin time.Time) LayoutData {
+	attempt := job.Attempt
+	if attempt < 1 {
+		attempt = 1
+	}
+	iteration := job.Iteration
+	if iteration < 1 {
+		iteration = 1
+	}
+	return LayoutData{
+		ID:        job.ID,
+		Name:      job.Name,
+		Attempt:   attempt,
+		Iteration: iteration,
+		Verdict:   "running",
+		Timestamp: begin.Format(TimestampLayout),
+	}
+}
+
+// ParseLayout parses a layout template. Unknown fields are reported as error.
+func ParseLayout(s string) (*Layout, error) {
+	tmpl, err := template.New("layout").Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	l := &Layout{text: s, tmpl: tmpl}
+	if _, err := l.Path(LayoutData{ID: "test-0", Name: "test", Attempt: 1, Iteration: 1, Verdict: "running"}); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Path returns the relative path generated from the layout. Paths leaving the
+// directory they are relative to are an error.
+func (l *Layout) Path(data LayoutData) (string, error) {
+	var b strings.Builder
+	if err := l.tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	p := filepath.Clean(b.String())
+	if p == "." || filepath.IsAbs(p) || p == ".." || strings.HasPrefix(p, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("layout %q: %q is not a relative subdirectory", l.text, b.String())
+	}
+	return p, nil
+}
+
+// WorkDir returns the working directory of the job: Dir joined with the path
+// generated by Layout or, without Layout, with the job ID. WorkDir is empty if
+// Dir is empty.
+func (job *Job) WorkDir(data LayoutData) (string, error) {
+	if job.Dir == "" {
+		return "", nil
+	}
+	if job.Layout == nil {
+		return filepath.Join(job.Dir, job.ID), nil
+	}
+	p, err := job.Layout.Path(data)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(job.Dir, p), nil
+}
@@ -0,0 +1,40 @@
+package control_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nokia/ntt/control"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLayout(t *testing.T) {
+	job := &control.Job{ID: "m.tc-0", Name: "m.tc", Dir: "out"}
+	begin := time.Date(2022, 3, 4, 5, 6, 7, 0, time.UTC)
+	data := control.NewLayoutData(job, begin)
+	assert.Equal(t, control.LayoutData{ID: "m.tc-0", Name: "m.tc", Attempt: 1, Iteration: 1, Verdict: "running", Timestamp: "20220304-050607"}, data)
+
+	// Without layout the job ID is used.
+	dir, err := job.WorkDir(data)
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join("out", "m.tc-0"), dir)
+
+	job.Layout, err = control.ParseLayout("{{.ID}}/{{.Attempt}}-{{.Verdict}}-{{.Timestamp}}")
+	assert.Nil(t, err)
+	data.Attempt, data.Verdict = 2, "fail"
+	dir, err = job.WorkDir(data)
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join("out", "m.tc-0", "2-fail-20220304-050607"), dir)
+
+	// Without output directory there is no working directory.
+	job.Dir = ""
+	dir, err = job.WorkDir(data)
+	assert.Nil(t, err)
+	assert.Equal(t, "", dir)
+
+	for _, s := range []string{"{{.ID", "{{.Unknown}}", "", "/{{.ID}}", "../{{.ID}}", "{{.ID}}/../.."} {
+		_, err := control.ParseLayout(s)
+		assert.NotNil(t, err, s)
+	}
+}
@@ -0,0 +1,234 @@
+package control
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ErrCycle is returned when ordering constraints of jobs form a cycle.
+var ErrCycle = fmt.Errorf("ordering constraints form a cycle")
+
+// SortJobs orders jobs topologically, so that jobs come after the jobs of
+// the tests named by their After field. The order of independent jobs is
+// preserved. Prerequisites not part of jobs are ignored. SortJobs returns an
+// error wrapping ErrCycle, if the constraints form a cycle.
+func SortJobs(jobs []*Job) ([]*Job, error) {
+	byName := make(map[string][]*Job)
+	for _, j := range jobs {
+		byName[j.Name] = append(byName[j.Name], j)
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	var (
+		state = make(map[string]int)
+		path  []string
+		ret   = make([]*Job, 0, len(jobs))
+	)
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			for i, n := range path {
+				if n == name {
+					return fmt.Errorf("%w: %s", ErrCycle, strings.Join(append(path[i:], name), " -> "))
+				}
+			}
+		}
+		state[name] = visiting
+		path = append(path, name)
+		for _, j := range byName[name] {
+			for _, dep := range j.After {
+				if _, ok := byName[dep]; !ok {
+					continue
+				}
+				if err := visit(dep); err != nil {
+					return err
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+		ret = append(ret, byName[name]...)
+		return nil
+	}
+	for _, j := range jobs {
+		if err := visit(j.Name); err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+// An OrderedQueue dispatches jobs respecting their ordering constraints: A job
+// is held back until all jobs of the tests named by its After field are done
+// and passed. Jobs without pending prerequisites are dispatched immediately,
+// hence independent jobs still run in parallel.
+//
+// Jobs whose prerequisites did not pass are never dispatched. They are
+// reported by Skipped.
+type OrderedQueue struct {
+	mu      sync.Mutex
+	jobs    []*Job          // jobs not dispatched yet
+	pending map[string]int  // number of unfinished jobs per test name
+	failed  map[string]bool // tests with at least one failed job
+	done    map[*Job]bool
+	skipped []*Job
+	notify  chan struct{}
+}
+
+// NewOrderedQueue returns a queue for the given jobs. It returns an error
+// wrapping ErrCycle, if the ordering constraints form a cycle.
+func NewOrderedQueue(jobs []*Job) (*OrderedQueue, error) {
+	sorted, err := SortJobs(jobs)
+	if err != nil {
+		return nil, err
+	}
+	q := &OrderedQueue{
+		jobs:    sorted,
+		pending: make(map[string]int),
+		failed:  make(map[string]bool),
+		done:    make(map[*Job]bool),
+		notify:  make(chan struct{}, 1),
+	}
+	for _, j := range sorted {
+		q.pending[j.Name]++
+	}
+	return q, nil
+}
+
+// Jobs returns a channel dispatching the jobs of the queue. The channel is
+// closed when all jobs are dispatched or skipped, or when ctx is done.
+func (q *OrderedQueue) Jobs(ctx context.Context) <-chan *Job {
+	out := make(chan *Job)
+	go func() {
+		defer close(out)
+		for {
+			q.mu.Lock()
+			job, empty := q.next()
+			q.mu.Unlock()
+
+			switch {
+			case empty:
+				return
+			case job == nil:
+				// Wait for prerequisites to finish.
+				select {
+				case <-q.notify:
+				case <-ctx.Done():
+					return
+				}
+			default:
+				select {
+				case out <- job:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out
+}
+
+// Done marks job as finished. Only the first call for a job is considered.
+func (q *OrderedQueue) Done(job *Job, passed bool) {
+	q.mu.Lock()
+	if job == nil || q.done[job] {
+		q.mu.Unlock()
+		return
+	}
+	q.done[job] = true
+	q.pending[job.Name]--
+	if !passed {
+		q.failed[job.Name] = true
+	}
+	q.mu.Unlock()
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Retry puts job back into the queue, to be dispatched once more, and
+// increments its attempt counter. The job stays unfinished, hence jobs waiting
+// for it continue to wait. Retry returns false, if the job is done or already
+// waiting for dispatch.
+func (q *OrderedQueue) Retry(job *Job) bool {
+	q.mu.Lock()
+	if job == nil || q.done[job] {
+		q.mu.Unlock()
+		return false
+	}
+	for _, j := range q.jobs {
+		if j == job {
+			q.mu.Unlock()
+			return false
+		}
+	}
+	if job.Attempt < 1 {
+		job.Attempt = 1
+	}
+	job.Attempt++
+	q.jobs = append(q.jobs, job)
+	q.mu.Unlock()
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// Len returns the number of jobs not dispatched yet. Jobs retried after the
+// channel returned by Jobs was closed need another call of Jobs.
+func (q *OrderedQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.jobs)
+}
+
+// Skipped returns the jobs not dispatched, because their prerequisites did
+// not pass.
+func (q *OrderedQueue) Skipped() []*Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]*Job(nil), q.skipped...)
+}
+
+// next removes and returns the first job ready for dispatch. Jobs with failed
+// prerequisites are moved to the skipped list. empty is true if there are no
+// jobs left.
+func (q *OrderedQueue) next() (job *Job, empty bool) {
+	for i := 0; i < len(q.jobs); i++ {
+		j := q.jobs[i]
+		ready := true
+		for _, dep := range j.After {
+			if q.failed[dep] {
+				q.jobs = append(q.jobs[:i], q.jobs[i+1:]...)
+				q.skipped = append(q.skipped, j)
+				q.done[j] = true
+				q.pending[j.Name]--
+				q.failed[j.Name] = true
+
+				// Skipping may affect jobs we already looked at.
+				return q.next()
+			}
+			if q.pending[dep] > 0 {
+				ready = false
+			}
+		}
+		if ready {
+			q.jobs = append(q.jobs[:i], q.jobs[i+1:]...)
+			return j, false
+		}
+	}
+	return nil, len(q.jobs) == 0
+}
@@ -0,0 +1,126 @@
+package control_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nokia/ntt/control"
+	"github.com/stretchr/testify/assert"
+)
+
+func ids(jobs []*control.Job) []string {
+	var ret []string
+	for _, j := range jobs {
+		ret = append(ret, j.ID)
+	}
+	return ret
+}
+
+func TestSortJobs(t *testing.T) {
+	jobs := []*control.Job{
+		{ID: "C-0", Name: "C", After: []string{"B"}},
+		{ID: "A-0", Name: "A"},
+		{ID: "B-0", Name: "B", After: []string{"A", "X"}},
+		{ID: "B-1", Name: "B"},
+		{ID: "D-0", Name: "D"},
+	}
+	sorted, err := control.SortJobs(jobs)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"A-0", "B-0", "B-1", "C-0", "D-0"}, ids(sorted))
+
+	_, err = control.SortJobs([]*control.Job{
+		{ID: "A-0", Name: "A", After: []string{"C"}},
+		{ID: "B-0", Name: "B", After: []string{"A"}},
+		{ID: "C-0", Name: "C", After: []string{"B"}},
+	})
+	assert.True(t, errors.Is(err, control.ErrCycle))
+	assert.Contains(t, err.Error(), "A -> C -> B -> A")
+
+	_, err = control.SortJobs([]*control.Job{{ID: "A-0", Name: "A", After: []string{"A"}}})
+	assert.True(t, errors.Is(err, control.ErrCycle))
+}
+
+func TestOrderedQueue(t *testing.T) {
+	a := &control.Job{ID: "A-0", Name: "A"}
+	b := &control.Job{ID: "B-0", Name: "B", After: []string{"A"}}
+	c := &control.Job{ID: "C-0", Name: "C"}
+	d := &control.Job{ID: "D-0", Name: "D", After: []string{"C"}}
+	e := &control.Job{ID: "E-0", Name: "E", After: []string{"D"}}
+
+	q, err := control.NewOrderedQueue([]*control.Job{b, a, e, d, c})
+	if err != nil {
+		t.Fatal(err)
+	}
+	jobs := q.Jobs(context.Background())
+
+	// Independent jobs are dispatched without waiting.
+	assert.Equal(t, "A-0", (<-jobs).ID)
+	assert.Equal(t, "C-0", (<-jobs).ID)
+
+	// B waits for A.
+	q.Done(a, true)
+	assert.Equal(t, "B-0", (<-jobs).ID)
+
+	// D and E are skipped, because C failed.
+	q.Done(c, false)
+	q.Done(c, true) // ignored
+	q.Done(b, true)
+	_, ok := <-jobs
+	assert.False(t, ok)
+	assert.Equal(t, []string{"D-0", "E-0"}, ids(q.Skipped()))
+}
+
+func TestOrderedQueueRetry(t *testing.T) {
+	a := &control.Job{ID: "A-0", Name: "A"}
+	b := &control.Job{ID: "B-0", Name: "B", After: []string{"A"}}
+	q, err := control.NewOrderedQueue([]*control.Job{a, b})
+	if err != nil {
+		t.Fatal(err)
+	}
+	jobs := q.Jobs(context.Background())
+	assert.Equal(t, "A-0", (<-jobs).ID)
+
+	// B keeps waiting for the retry of A.
+	assert.True(t, q.Retry(a))
+	assert.False(t, q.Retry(a), "already queued")
+	assert.Equal(t, 2, a.Attempt)
+	assert.Equal(t, "A-0", (<-jobs).ID)
+	q.Done(a, true)
+	assert.False(t, q.Retry(a), "already done")
+	assert.Equal(t, "B-0", (<-jobs).ID)
+	q.Done(b, true)
+	_, ok := <-jobs
+	assert.False(t, ok)
+
+	// Retries after the channel was closed are dispatched by the next
+	// call of Jobs.
+	c := &control.Job{ID: "C-0", Name: "C"}
+	q, err = control.NewOrderedQueue([]*control.Job{c})
+	if err != nil {
+		t.Fatal(err)
+	}
+	jobs = q.Jobs(context.Background())
+	assert.Equal(t, "C-0", (<-jobs).ID)
+	_, ok = <-jobs
+	assert.False(t, ok)
+	assert.True(t, q.Retry(c))
+	assert.Equal(t, 1, q.Len())
+	assert.Equal(t, "C-0", (<-q.Jobs(context.Background())).ID)
+	assert.Equal(t, 0, q.Len())
+}
+
+func TestOrderedQueueCancel(t *testing.T) {
+	a := &control.Job{ID: "A-0", Name: "A"}
+	b := &control.Job{ID: "B-0", Name: "B", After: []string{"A"}}
+	q, err := control.NewOrderedQueue([]*control.Job{a, b})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	jobs := q.Jobs(ctx)
+	assert.Equal(t, "A-0", (<-jobs).ID)
+	cancel()
+	_, ok := <-jobs
+	assert.False(t, ok)
+}
@@ -26,7 +26,7 @@ func (p *ConsolePrinter) Print(ev control.Event) {
 		ColorRunning.Printf("... active %s\n", ev.Name)
 	case control.StopEvent:
 		c := Colors(ev.Verdict)
-		c.Printf("--- %s %s\t(duration=%.2fs)\n", ev.Verdict, ev.Name, ev.Time().Sub(ev.Begin).Seconds())
+		c.Printf("%s--- %s %s\t(duration=%.2fs)\n", Symbol(ev.Verdict), ev.Verdict, ev.Name, ev.Time().Sub(ev.Begin).Seconds())
 	case control.ErrorEvent:
 		msg := fmt.Sprintf("+++ fatal ")
 		if job := control.UnwrapJob(ev); job != nil {
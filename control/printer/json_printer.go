@@ -1,44 +1,66 @@
 package printer
 
 import (
+	"encoding/json"
 	"fmt"
-	"strings"
 
 	"github.com/nokia/ntt/control"
-	"github.com/nokia/ntt/internal/yaml"
 )
 
 type JSONPrinter struct {
+	// Pretty indents each emitted object for human readers. The default
+	// compact output prints one object per line. Both have the same
+	// fields.
+	Pretty bool
 }
 
 func NewJSONPrinter() *JSONPrinter {
 	return &JSONPrinter{}
 }
 
-func quote(s string) string {
-	quoted, _ := yaml.MarshalJSON(s)
-	return strings.TrimSpace(string(quoted))
+// jsonEvent is the reduced event representation. control.Event provides too
+// much information. Until we've figured out what we need we display reduced
+// information.
+type jsonEvent struct {
+	Time    int64  `json:"time"`
+	Event   string `json:"event"`
+	JobID   string `json:"job_id,omitempty"`
+	Name    string `json:"name,omitempty"`
+	Verdict string `json:"verdict,omitempty"`
+	Text    string `json:"text,omitempty"`
 }
 
 func (p *JSONPrinter) Print(ev control.Event) {
-	// control.Event provides too much information. Until we've figured out
-	// what we need we display reduced information
+	e := jsonEvent{Time: ev.Time().Unix()}
 	switch ev := ev.(type) {
 	case control.LogEvent:
-		fmt.Printf(`{time: %d, event: "log", job_id: "%s", text: %s }`, ev.Time().Unix(), ev.ID, quote(ev.Text))
+		e.Event, e.JobID, e.Text = "log", ev.ID, ev.Text
 	case control.StartEvent:
-		fmt.Printf(`{time: %d, event: "start", job_id: "%s", name: %s }`, ev.Time().Unix(), ev.ID, quote(ev.Name))
+		e.Event, e.JobID, e.Name = "start", ev.ID, ev.Name
 	case control.TickerEvent:
+		return
 	case control.StopEvent:
-		fmt.Printf(`{time: %d, event: "stop", job_id: "%s", name: %s, verdict: %s}`, ev.Time().Unix(), ev.ID, quote(ev.Name), quote(ev.Verdict))
+		e.Event, e.JobID, e.Name, e.Verdict = "stop", ev.ID, ev.Name, ev.Verdict
 	case control.ErrorEvent:
-		jobID := ""
+		e.Event, e.Text = "error", ev.Err.Error()
 		if job := control.UnwrapJob(ev); job != nil {
-			jobID = job.ID + ", "
+			e.JobID = job.ID
 		}
-		fmt.Printf(`{time: %d, event: "error", %stext: %s }`, ev.Time().Unix(), jobID, quote(ev.Err.Error()))
 	default:
 		panic(fmt.Sprintf("unknown event type %T", ev))
 	}
-	fmt.Println()
+
+	var (
+		b   []byte
+		err error
+	)
+	if p.Pretty {
+		b, err = json.MarshalIndent(e, "", "  ")
+	} else {
+		b, err = json.Marshal(e)
+	}
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(string(b))
 }
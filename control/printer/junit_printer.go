@@ -0,0 +1,171 @@
+package printer
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/nokia/ntt/control"
+	"github.com/nokia/ntt/internal/results"
+)
+
+// JUnitPrinter writes the test results as JUnit XML document, as understood by
+// Jenkins and most other CI systems. The document is written when the printer
+// is closed. Tests are grouped into one test suite per module.
+//
+// Runs with verdict pass or done are successful. Fatal verdicts and jobs,
+// which failed without verdict, like timeouts, are reported as <error>,
+// skipped runs as <skipped>, all other verdicts as <failure>. Errors not
+// related to a test are reported as <error> of a test case named ntt.
+type JUnitPrinter struct {
+	file   string
+	suites []*junitSuite
+	raw    map[string]string
+	starts map[*control.Job]time.Time
+}
+
+type junitSuites struct {
+	XMLName xml.Name      `xml:"testsuites"`
+	Suites  []*junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Errors   int          `xml:"errors,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Time     float64      `xml:"time,attr"`
+	Cases    []*junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      float64       `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr,omitempty"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// NewJUnitPrinter returns a printer writing the JUnit document to file. An
+// empty file name writes to stdout.
+func NewJUnitPrinter(file string) *JUnitPrinter {
+	return &JUnitPrinter{
+		file:   file,
+		raw:    make(map[string]string),
+		starts: make(map[*control.Job]time.Time),
+	}
+}
+
+// Print records errors of jobs. Test results are passed by Report.
+func (p *JUnitPrinter) Print(ev control.Event) {
+	switch ev := ev.(type) {
+	case control.StartEvent:
+		if _, ok := p.starts[ev.Job]; !ok {
+			p.starts[ev.Job] = ev.Time()
+		}
+	case control.StopEvent:
+		// Report gets the normalized verdict, which does not
+		// distinguish fatal from error.
+		name := ev.Name
+		if ev.Job != nil && ev.Job.Subtest != "" {
+			name = ev.Job.Subtest
+		}
+		p.raw[name] = ev.Verdict
+		delete(p.starts, ev.Job)
+	case control.ErrorEvent:
+		name, secs := "ntt", 0.0
+		if job := control.UnwrapJob(ev); job != nil {
+			name = job.Name
+			if job.Subtest != "" {
+				name = job.Subtest
+			}
+			if begin, ok := p.starts[job]; ok {
+				secs = ev.Time().Sub(begin).Seconds()
+				delete(p.starts, job)
+			}
+		}
+		c := p.add(name, secs)
+		c.Error = &junitMessage{Message: ev.Err.Error(), Type: "error"}
+		p.suite(name).Errors++
+	}
+}
+
+// Report adds the result of a finished test.
+func (p *JUnitPrinter) Report(r results.Run) {
+	c := p.add(r.Name, r.Duration().Seconds())
+	verdict := string(r.Verdict)
+	msg := &junitMessage{Message: "Verdict: " + verdict, Type: verdict, Text: r.Reason}
+	switch {
+	case r.Verdict == results.PassVerdict || r.Verdict == results.DoneVerdict:
+	case r.Verdict == results.SkippedVerdict:
+		c.Skipped = msg
+		p.suite(r.Name).Skipped++
+	case strings.EqualFold(p.raw[r.Name], "fatal"):
+		msg.Type = "fatal"
+		c.Error = msg
+		p.suite(r.Name).Errors++
+	default:
+		c.Failure = msg
+		p.suite(r.Name).Failures++
+	}
+	delete(p.raw, r.Name)
+}
+
+func (p *JUnitPrinter) add(name string, secs float64) *junitCase {
+	s := p.suite(name)
+	c := &junitCase{Name: name, Classname: s.Name, Time: secs}
+	s.Cases = append(s.Cases, c)
+	s.Tests++
+	s.Time += secs
+	return c
+}
+
+// suite returns the test suite of the module of test name.
+func (p *JUnitPrinter) suite(name string) *junitSuite {
+	mod := name
+	if i := strings.Index(name, "."); i >= 0 {
+		mod = name[:i]
+	}
+	for _, s := range p.suites {
+		if s.Name == mod {
+			return s
+		}
+	}
+	s := &junitSuite{Name: mod}
+	p.suites = append(p.suites, s)
+	return s
+}
+
+// Close writes the JUnit document.
+func (p *JUnitPrinter) Close() error {
+	var w io.Writer = os.Stdout
+	if p.file != "" {
+		f, err := os.Create(p.file)
+		if err != nil {
+			return fmt.Errorf("junit: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitSuites{Suites: p.suites}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -23,7 +23,7 @@ func (p *PlainPrinter) Print(ev control.Event) {
 	case control.TickerEvent:
 	case control.StopEvent:
 		c := Colors(ev.Verdict)
-		c.Printf("%s\t%s\t%.2f\n", ev.Verdict, ev.Name, ev.Time().Sub(ev.Begin).Seconds())
+		c.Printf("%s%s\t%s\t%.2f\n", Symbol(ev.Verdict), ev.Verdict, ev.Name, ev.Time().Sub(ev.Begin).Seconds())
 	case control.ErrorEvent:
 		msg := fmt.Sprintf("error: ")
 		if job := control.UnwrapJob(ev); job != nil {
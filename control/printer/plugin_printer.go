@@ -0,0 +1,138 @@
+package printer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nokia/ntt/control"
+	"github.com/nokia/ntt/internal/log"
+	"github.com/nokia/ntt/internal/proc"
+	"github.com/nokia/ntt/internal/results"
+)
+
+// PluginTimeout is the time a reporter plugin has to exit after its standard
+// input was closed. Then the plugin is killed.
+var PluginTimeout = 10 * time.Second
+
+// PluginPrinter streams events to the standard input of an external reporter
+// program. Each event is written as a single line JSON object (NDJSON):
+//
+//	{"event": "begin",  "time": 1600000000}
+//	{"event": "start",  "time": 1600000000, "job_id": "...", "name": "test.A"}
+//	{"event": "error",  "time": 1600000000, "job_id": "...", "text": "..."}
+//	{"event": "run",    "time": 1600000001, "run": { ...results.Run... }}
+//	{"event": "finish", "time": 1600000001}
+//
+// Stop events are not forwarded by Print. Use Report to send the final
+// results.Run of a test instead.
+//
+// The plugin runs until Close is called, even when the test run is
+// interrupted, so it gets a chance to render partial results.
+type PluginPrinter struct {
+	mu    sync.Mutex
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	enc   *json.Encoder
+	err   error // first write error
+}
+
+type pluginEvent struct {
+	Event string       `json:"event"`
+	Time  int64        `json:"time"`
+	JobID string       `json:"job_id,omitempty"`
+	Name  string       `json:"name,omitempty"`
+	Text  string       `json:"text,omitempty"`
+	Run   *results.Run `json:"run,omitempty"`
+}
+
+// NewPluginPrinter starts the reporter command line s. Standard output and
+// standard error of the plugin are passed through.
+func NewPluginPrinter(s string) (*PluginPrinter, error) {
+	args := strings.Fields(s)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("empty reporter plugin command")
+	}
+
+	cmd := proc.Command(args[0], args[1:]...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	log.Debugf("+ %s\n", cmd.String())
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("reporter plugin: %w", err)
+	}
+
+	p := &PluginPrinter{cmd: cmd, stdin: stdin, enc: json.NewEncoder(stdin)}
+	p.write(pluginEvent{Event: "begin", Time: time.Now().Unix()})
+	return p, nil
+}
+
+func (p *PluginPrinter) Print(ev control.Event) {
+	switch ev := ev.(type) {
+	case control.StartEvent:
+		p.write(pluginEvent{Event: "start", Time: ev.Time().Unix(), JobID: ev.ID, Name: ev.Name})
+	case control.ErrorEvent:
+		e := pluginEvent{Event: "error", Time: ev.Time().Unix(), Text: ev.Err.Error()}
+		if job := control.UnwrapJob(ev); job != nil {
+			e.JobID = job.ID
+		}
+		p.write(e)
+	}
+}
+
+// Report sends the result of a finished test to the plugin.
+func (p *PluginPrinter) Report(r results.Run) {
+	p.write(pluginEvent{Event: "run", Time: r.End.Unix(), Run: &r})
+}
+
+// Close sends the finish event, closes the standard input of the plugin and
+// waits for it to exit. Close returns an error if the plugin could not
+// receive all events or did not exit successfully.
+func (p *PluginPrinter) Close() error {
+	p.write(pluginEvent{Event: "finish", Time: time.Now().Unix()})
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stdin.Close()
+
+	done := make(chan error, 1)
+	go func() { done <- p.cmd.Wait() }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-time.After(PluginTimeout):
+		p.cmd.Process.Kill()
+		<-done
+		err = fmt.Errorf("timeout after %s", PluginTimeout)
+	}
+
+	if err == nil {
+		err = p.err
+	}
+	if err != nil {
+		return fmt.Errorf("reporter plugin: %w", err)
+	}
+	return nil
+}
+
+func (p *PluginPrinter) write(e pluginEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return
+	}
+	if err := p.enc.Encode(e); err != nil {
+		log.Debugf("reporter plugin: %s\n", err.Error())
+		p.err = err
+	}
+}
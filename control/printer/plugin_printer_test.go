@@ -0,0 +1,58 @@
+package printer_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nokia/ntt/control"
+	"github.com/nokia/ntt/control/printer"
+	"github.com/nokia/ntt/internal/results"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPluginPrinter(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "events.json")
+	p, err := printer.NewPluginPrinter("tee -a " + file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	job := &control.Job{ID: "test.A-1", Name: "test.A"}
+	p.Print(control.NewStartEvent(job, "test.A"))
+	p.Print(control.NewLogEvent(job, "ignored"))
+	p.Report(results.Run{Name: "test.A", Verdict: results.PassVerdict})
+	assert.Nil(t, p.Close())
+
+	b, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events []string
+	for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+		var e struct {
+			Event string
+			Run   *results.Run
+		}
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatal(err)
+		}
+		if e.Run != nil {
+			e.Event += ":" + string(e.Run.Verdict)
+		}
+		events = append(events, e.Event)
+	}
+	assert.Equal(t, []string{"begin", "start", "run:pass", "finish"}, events)
+}
+
+func TestPluginPrinterFailure(t *testing.T) {
+	p, err := printer.NewPluginPrinter("false")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotNil(t, p.Close())
+
+	_, err = printer.NewPluginPrinter("")
+	assert.NotNil(t, err)
+}
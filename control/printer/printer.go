@@ -5,8 +5,15 @@ import (
 
 	"github.com/fatih/color"
 	"github.com/nokia/ntt/control"
+	"github.com/nokia/ntt/internal/results"
 )
 
+// A Reporter receives the results of finished tests, in addition to the
+// events passed to Print.
+type Reporter interface {
+	Report(r results.Run)
+}
+
 var (
 	ColorFatal   = color.New(color.FgRed, color.Bold)
 	ColorFailure = color.New(color.FgRed, color.Bold)
@@ -30,9 +37,40 @@ var (
 		}
 	}
 
+	// Symbols selects the status markers prefixing test results in text
+	// and plain output: "none", "ascii" or "unicode".
+	Symbols = "none"
+
 	ErrCommandFailed = fmt.Errorf("command failed")
 )
 
+// Symbol returns the status marker for verdict v, followed by a space, as
+// selected by Symbols. Symbol returns an empty string if Symbols is "none".
+// Synonyms of verdicts, like "passed", have the same marker (see
+// results.NormalizeVerdict).
+func Symbol(v string) string {
+	var ascii, unicode string
+	switch results.NormalizeVerdict(v) {
+	case results.PassVerdict:
+		ascii, unicode = "PASS", "✓"
+	case results.DoneVerdict:
+		ascii, unicode = "DONE", "✓"
+	case results.InconcVerdict, results.NoneVerdict:
+		ascii, unicode = "WARN", "~"
+	case results.SkippedVerdict:
+		ascii, unicode = "SKIP", "-"
+	default:
+		ascii, unicode = "FAIL", "✗"
+	}
+	switch Symbols {
+	case "ascii":
+		return ascii + " "
+	case "unicode":
+		return unicode + " "
+	}
+	return ""
+}
+
 type Printer interface {
 	Print(ev control.Event)
 }
@@ -0,0 +1,283 @@
+package printer_test
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nokia/ntt/control"
+	"github.com/nokia/ntt/control/printer"
+	"github.com/nokia/ntt/internal/results"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSymbol(t *testing.T) {
+	defer func(s string) { printer.Symbols = s }(printer.Symbols)
+
+	verdicts := []string{"pass", "fail", "error", "inconc", "none", "skipped", "done", "PASSED", "inconclusive", "", "bogus"}
+	symbols := func() []string {
+		var ret []string
+		for _, v := range verdicts {
+			ret = append(ret, printer.Symbol(v))
+		}
+		return ret
+	}
+
+	printer.Symbols = "none"
+	assert.Equal(t, []string{"", "", "", "", "", "", "", "", "", "", ""}, symbols())
+
+	printer.Symbols = "ascii"
+	assert.Equal(t, []string{"PASS ", "FAIL ", "FAIL ", "WARN ", "WARN ", "SKIP ", "DONE ", "PASS ", "WARN ", "WARN ", "FAIL "}, symbols())
+
+	printer.Symbols = "unicode"
+	assert.Equal(t, []string{"✓ ", "✗ ", "✗ ", "~ ", "~ ", "- ", "✓ ", "✓ ", "~ ", "~ ", "✗ "}, symbols())
+}
+
+// stdout returns what f prints to standard output.
+func stdout(t *testing.T, f func()) string {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(f *os.File) { os.Stdout = f }(os.Stdout)
+	os.Stdout = w
+	f()
+	w.Close()
+	b, _ := io.ReadAll(r)
+	return string(b)
+}
+
+func TestRedactedJSON(t *testing.T) {
+	redact := func(s string) string { return strings.ReplaceAll(s, "hunter2", "***") }
+	job := &control.Job{ID: "test.A-1"}
+	events := []control.Event{
+		control.NewLogEvent(job, "password: hunter2"),
+		control.NewErrorEvent(&control.JobError{Job: job, Err: errors.New("login hunter2 failed")}),
+	}
+
+	for _, pretty := range []bool{false, true} {
+		p := printer.NewJSONPrinter()
+		p.Pretty = pretty
+		out := stdout(t, func() {
+			for _, e := range events {
+				p.Print(control.RedactEvent(e, redact))
+			}
+		})
+		assert.NotContains(t, out, "hunter2")
+		assert.Contains(t, out, "password: ***")
+		assert.Contains(t, out, "login *** failed")
+		assert.Contains(t, out, "test.A-1")
+	}
+}
+
+func TestJSONPretty(t *testing.T) {
+	job := &control.Job{ID: "test.A-1"}
+	events := []control.Event{
+		control.NewStartEvent(job, "test.A"),
+		control.NewLogEvent(job, "hello"),
+		control.NewStopEvent(job, "test.A", "pass"),
+		control.NewErrorEvent(&control.JobError{Job: job, Err: errors.New("timeout")}),
+		control.NewErrorEvent(errors.New("no runtime")),
+	}
+
+	// Pretty output differs in indentation only.
+	decode := func(pretty bool) []map[string]interface{} {
+		p := printer.NewJSONPrinter()
+		p.Pretty = pretty
+		out := stdout(t, func() {
+			for _, e := range events {
+				p.Print(e)
+			}
+		})
+		var ret []map[string]interface{}
+		dec := json.NewDecoder(strings.NewReader(out))
+		for dec.More() {
+			var m map[string]interface{}
+			if err := dec.Decode(&m); err != nil {
+				t.Fatal(err)
+			}
+			delete(m, "time")
+			ret = append(ret, m)
+		}
+		return ret
+	}
+	compact := decode(false)
+	assert.Equal(t, compact, decode(true))
+	assert.Equal(t, []map[string]interface{}{
+		{"event": "start", "job_id": "test.A-1", "name": "test.A"},
+		{"event": "log", "job_id": "test.A-1", "text": "hello"},
+		{"event": "stop", "job_id": "test.A-1", "name": "test.A", "verdict": "pass"},
+		{"event": "error", "job_id": "test.A-1", "text": "timeout"},
+		{"event": "error", "text": "no runtime"},
+	}, compact)
+}
+
+func TestStatusServer(t *testing.T) {
+	s, err := printer.NewStatusServer("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	a, b := &control.Job{ID: "test.A-1"}, &control.Job{ID: "test.B-2"}
+	s.Print(control.NewStartEvent(a, "test.A"))
+	s.Print(control.NewStartEvent(b, "test.B"))
+	s.Print(control.NewStopEvent(a, "test.A", "fail"))
+
+	resp, err := http.Get("http://" + s.Addr() + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var status struct {
+		Active []struct {
+			JobID string `json:"job_id"`
+		}
+		Completed int
+		Verdicts  map[string]int
+		Recent    []struct {
+			Name    string
+			Verdict string
+		}
+	}
+	assert.Nil(t, json.NewDecoder(resp.Body).Decode(&status))
+	assert.Equal(t, 1, len(status.Active))
+	assert.Equal(t, "test.B-2", status.Active[0].JobID)
+	assert.Equal(t, 1, status.Completed)
+	assert.Equal(t, map[string]int{"fail": 1}, status.Verdicts)
+	assert.Equal(t, "test.A", status.Recent[0].Name)
+
+	assert.Nil(t, s.Close())
+	_, err = http.Get("http://" + s.Addr() + "/")
+	assert.NotNil(t, err)
+}
+
+func TestJUnitPrinter(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "junit.xml")
+	p := printer.NewJUnitPrinter(file)
+
+	begin := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	run := func(name, verdict, raw string) {
+		p.Print(control.NewStopEvent(&control.Job{Name: name}, name, raw))
+		p.Report(results.Run{
+			Name:    name,
+			Verdict: results.Verdict(verdict),
+			Reason:  "reason of " + name,
+			Begin:   results.Timestamp{Time: begin},
+			End:     results.Timestamp{Time: begin.Add(1500 * time.Millisecond)},
+		})
+	}
+	run("A.pass", "pass", "pass")
+	run("A.fail", "fail", "fail")
+	run("A.fatal", "error", "fatal")
+	run("B.skip", "skipped", "")
+	p.Print(control.NewErrorEvent(&control.JobError{Job: &control.Job{Name: "B.crash"}, Err: errors.New("crashed")}))
+
+	// Tests, which time out, have no verdict.
+	timeout := &control.Job{Name: "C.timeout"}
+	p.Print(control.NewStartEvent(timeout, "C.timeout"))
+	p.Print(control.NewErrorEvent(&control.JobError{Job: timeout, Err: errors.New("timeout")}))
+	p.Print(control.NewErrorEvent(errors.New("too many errors. Exiting.")))
+
+	// Nothing is written to stdout, when a file is given.
+	assert.Equal(t, "", stdout(t, func() { assert.Nil(t, p.Close()) }))
+
+	b, err := os.ReadFile(file)
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(string(b), xml.Header))
+
+	var doc struct {
+		Suites []struct {
+			Name     string  `xml:"name,attr"`
+			Tests    int     `xml:"tests,attr"`
+			Failures int     `xml:"failures,attr"`
+			Errors   int     `xml:"errors,attr"`
+			Skipped  int     `xml:"skipped,attr"`
+			Time     float64 `xml:"time,attr"`
+			Cases    []struct {
+				Name    string    `xml:"name,attr"`
+				Time    float64   `xml:"time,attr"`
+				Failure *string   `xml:"failure"`
+				Error   *string   `xml:"error"`
+				Skipped *xml.Name `xml:"skipped"`
+			} `xml:"testcase"`
+		} `xml:"testsuite"`
+	}
+	assert.Nil(t, xml.Unmarshal(b, &doc))
+	assert.Len(t, doc.Suites, 4)
+
+	a := doc.Suites[0]
+	assert.Equal(t, "A", a.Name)
+	assert.Equal(t, []int{3, 1, 1, 0}, []int{a.Tests, a.Failures, a.Errors, a.Skipped})
+	assert.Equal(t, 4.5, a.Time)
+	assert.Nil(t, a.Cases[0].Failure)
+	assert.Nil(t, a.Cases[0].Error)
+	assert.Equal(t, "reason of A.fail", *a.Cases[1].Failure)
+	assert.NotNil(t, a.Cases[2].Error)
+
+	bs := doc.Suites[1]
+	assert.Equal(t, []int{2, 0, 1, 1}, []int{bs.Tests, bs.Failures, bs.Errors, bs.Skipped})
+	assert.NotNil(t, bs.Cases[0].Skipped)
+	assert.Equal(t, "B.crash", bs.Cases[1].Name)
+
+	cs := doc.Suites[2]
+	assert.Equal(t, []int{1, 0, 1, 0}, []int{cs.Tests, cs.Failures, cs.Errors, cs.Skipped})
+	assert.Equal(t, "C.timeout", cs.Cases[0].Name)
+	assert.Contains(t, string(b), `<error message="timeout" type="error"></error>`)
+
+	ntt := doc.Suites[3]
+	assert.Equal(t, "ntt", ntt.Name)
+	assert.Equal(t, []int{1, 0, 1, 0}, []int{ntt.Tests, ntt.Failures, ntt.Errors, ntt.Skipped})
+}
+
+func TestTAPPrinter(t *testing.T) {
+	p := printer.NewTAPPrinter()
+	run := func(name, verdict, raw, reason string) {
+		p.Print(control.NewStopEvent(&control.Job{Name: name}, name, raw))
+		p.Report(results.Run{Name: name, Verdict: results.Verdict(verdict), Reason: reason})
+	}
+	out := stdout(t, func() {
+		p.Print(control.NewStartEvent(&control.Job{ID: "test.A-0"}, "test.A"))
+		run("test.A", "pass", "pass", "")
+		run("test.B", "inconc", "inconc", "")
+		run("test.C", "error", "fatal", "core dumped\nagain")
+		run("test.D", "skipped", "", "prerequisites did not pass: test.C")
+		p.Print(control.NewErrorEvent(&control.JobError{Job: &control.Job{ID: "test.E-0", Name: "test.E"}, Err: errors.New("timeout")}))
+		p.Print(control.NewErrorEvent(errors.New("too many errors. Exiting.")))
+		p.Close()
+	})
+	assert.Equal(t, `TAP version 13
+# test.A (test.A-0): started
+ok 1 - test.A
+not ok 2 - test.B # TODO verdict inconc
+not ok 3 - test.C
+  ---
+  verdict: fatal
+  reason: "core dumped\nagain"
+  ...
+ok 4 - test.D # SKIP prerequisites did not pass: test.C
+not ok 5 - test.E # error
+  ---
+  verdict: error
+  reason: "timeout"
+  ...
+not ok 6 - ntt # error
+  ---
+  verdict: error
+  reason: "too many errors. Exiting."
+  ...
+# failed 4 among 6 tests.
+1..6
+`, out)
+
+	out = stdout(t, func() { printer.NewTAPPrinter().Close() })
+	assert.Equal(t, "TAP version 13\n1..0 # SKIP no tests\n", out)
+}
@@ -0,0 +1,164 @@
+package printer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nokia/ntt/control"
+	"github.com/nokia/ntt/internal/log"
+)
+
+// StatusRecent is the number of recent results reported by a StatusServer.
+var StatusRecent = 20
+
+// StatusServer serves the live status of a test run as JSON via HTTP:
+//
+//	{
+//	  "elapsed": 42.1,
+//	  "active": [{"job_id": "test.A-1", "name": "test.A", "running": 3.2}],
+//	  "completed": 12,
+//	  "errors": 0,
+//	  "verdicts": {"pass": 11, "fail": 1},
+//	  "recent": [{"name": "test.B", "verdict": "fail", "duration": 1.5}]
+//	}
+//
+// The status is collected from the events passed to Print. Recent results
+// are listed most recent first.
+type StatusServer struct {
+	mu        sync.Mutex
+	begin     time.Time
+	active    map[string]control.StartEvent
+	completed int
+	errors    int
+	verdicts  map[string]int
+	recent    []statusResult
+
+	srv *http.Server
+	ln  net.Listener
+}
+
+type statusJob struct {
+	JobID   string  `json:"job_id"`
+	Name    string  `json:"name"`
+	Running float64 `json:"running"` // Seconds since the job started
+}
+
+type statusResult struct {
+	Name     string  `json:"name"`
+	Verdict  string  `json:"verdict"`
+	Duration float64 `json:"duration"` // Seconds
+}
+
+type status struct {
+	Elapsed   float64        `json:"elapsed"` // Seconds since the server started
+	Active    []statusJob    `json:"active"`
+	Completed int            `json:"completed"`
+	Errors    int            `json:"errors"`
+	Verdicts  map[string]int `json:"verdicts"`
+	Recent    []statusResult `json:"recent"`
+}
+
+// NewStatusServer starts serving the status on addr. Addresses without host,
+// like ":8080", are bound to localhost.
+func NewStatusServer(addr string) (*StatusServer, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if host == "" {
+		host = "localhost"
+	}
+	ln, err := net.Listen("tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		return nil, err
+	}
+
+	s := &StatusServer{
+		begin:    time.Now(),
+		active:   make(map[string]control.StartEvent),
+		verdicts: make(map[string]int),
+		ln:       ln,
+	}
+	s.srv = &http.Server{Handler: s}
+	go func() {
+		if err := s.srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("warning: status server: %s\n", err.Error())
+		}
+	}()
+	return s, nil
+}
+
+// Addr returns the address the server is listening on.
+func (s *StatusServer) Addr() string {
+	return s.ln.Addr().String()
+}
+
+func (s *StatusServer) Print(ev control.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch ev := ev.(type) {
+	case control.StartEvent:
+		if ev.Job != nil {
+			s.active[ev.ID] = ev
+		}
+	case control.StopEvent:
+		if ev.Job != nil {
+			delete(s.active, ev.ID)
+		}
+		s.completed++
+		s.verdicts[ev.Verdict]++
+		s.recent = append([]statusResult{{
+			Name:     ev.Name,
+			Verdict:  ev.Verdict,
+			Duration: ev.Time().Sub(ev.Begin).Seconds(),
+		}}, s.recent...)
+		if len(s.recent) > StatusRecent {
+			s.recent = s.recent[:StatusRecent]
+		}
+	case control.ErrorEvent:
+		s.errors++
+		if job := control.UnwrapJob(ev); job != nil {
+			delete(s.active, job.ID)
+		}
+	}
+}
+
+func (s *StatusServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	now := time.Now()
+	st := status{
+		Elapsed:   now.Sub(s.begin).Seconds(),
+		Active:    []statusJob{},
+		Completed: s.completed,
+		Errors:    s.errors,
+		Verdicts:  make(map[string]int, len(s.verdicts)),
+		Recent:    append([]statusResult{}, s.recent...),
+	}
+	for id, ev := range s.active {
+		st.Active = append(st.Active, statusJob{JobID: id, Name: ev.Name, Running: now.Sub(ev.Time()).Seconds()})
+	}
+	for v, n := range s.verdicts {
+		st.Verdicts[v] = n
+	}
+	s.mu.Unlock()
+
+	sort.Slice(st.Active, func(i, j int) bool { return st.Active[i].JobID < st.Active[j].JobID })
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(st)
+}
+
+// Close shuts the server down. Pending requests are given a second to
+// complete.
+func (s *StatusServer) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	return s.srv.Shutdown(ctx)
+}
@@ -1,52 +1,117 @@
 package printer
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 	"unicode"
 
 	"github.com/nokia/ntt/control"
+	"github.com/nokia/ntt/internal/results"
 )
 
+// TAPPrinter prints the test results in the Test Anything Protocol, version
+// 13. Result lines are printed as the results arrive by Report. Tests with
+// verdict inconc or none are marked with a TODO directive, skipped tests with
+// a SKIP directive. Failures carry a YAML block with verdict and reason.
+// Errors without verdict, like timeouts, are failed test points, too. Errors
+// not related to a test are reported as test point named ntt.
+//
+// Jobs are generated lazily, hence the number of tests is not known before
+// the run ends. To stream the results, the plan line 1..N is printed last by
+// Close, which TAP permits.
 type TAPPrinter struct {
+	header  bool
 	n       int
 	success int
 	failed  int
+	raw     map[string]string
 }
 
 func NewTAPPrinter() *TAPPrinter {
-	return &TAPPrinter{}
+	return &TAPPrinter{raw: make(map[string]string)}
+}
+
+func (p *TAPPrinter) printHeader() {
+	if !p.header {
+		p.header = true
+		fmt.Println("TAP version 13")
+	}
 }
 
 func (p *TAPPrinter) Print(ev control.Event) {
+	p.printHeader()
 	switch ev := ev.(type) {
 	case control.LogEvent:
 		fmt.Printf("# %s\n", strings.ReplaceAll(strings.TrimRightFunc(ev.Text, unicode.IsSpace), "\n", "\n# "))
 	case control.StartEvent:
-		p.n++
 		fmt.Printf("# %s (%s): started\n", ev.Name, ev.ID)
 	case control.TickerEvent:
 	case control.StopEvent:
-		verdict := "not ok"
-		if verdict == "pass" || verdict == "done" {
-			verdict = "ok"
-			p.success++
-		} else {
-			p.failed++
+		// Report gets the normalized verdict, which does not
+		// distinguish fatal from error.
+		name := ev.Name
+		if ev.Job != nil && ev.Job.Subtest != "" {
+			name = ev.Job.Subtest
 		}
-		fmt.Printf("%s %d - %s\n", verdict, p.n, ev.ID)
+		p.raw[name] = ev.Verdict
 	case control.ErrorEvent:
-		jobID := ""
+		name := "ntt"
 		if job := control.UnwrapJob(ev); job != nil {
-			jobID = " " + job.ID + ":"
+			name = job.Name
+			if job.Subtest != "" {
+				name = job.Subtest
+			}
 		}
-		fmt.Printf("#%s error: %s\n", jobID, ev.Error())
+		p.n++
+		p.failed++
+		fmt.Printf("not ok %d - %s # error\n", p.n, name)
+		printTAPDiagnostics("error", ev.Error())
 	default:
 		panic(fmt.Sprintf("unknown event type %T", ev))
 	}
 }
 
+// Report prints the result line of a finished test.
+func (p *TAPPrinter) Report(r results.Run) {
+	p.printHeader()
+	p.n++
+	verdict := string(r.Verdict)
+	if raw := strings.ToLower(p.raw[r.Name]); raw == "fatal" {
+		verdict = raw
+	}
+	delete(p.raw, r.Name)
+
+	switch r.Verdict {
+	case results.PassVerdict, results.DoneVerdict:
+		p.success++
+		fmt.Printf("ok %d - %s\n", p.n, r.Name)
+	case results.SkippedVerdict:
+		p.success++
+		fmt.Printf("ok %d - %s # SKIP %s\n", p.n, r.Name, r.Reason)
+	case results.InconcVerdict, results.NoneVerdict:
+		fmt.Printf("not ok %d - %s # TODO verdict %s\n", p.n, r.Name, verdict)
+	default:
+		p.failed++
+		fmt.Printf("not ok %d - %s\n", p.n, r.Name)
+		printTAPDiagnostics(verdict, r.Reason)
+	}
+}
+
+// printTAPDiagnostics prints a YAML block with verdict and reason.
+func printTAPDiagnostics(verdict string, reason string) {
+	fmt.Println("  ---")
+	fmt.Printf("  verdict: %s\n", verdict)
+	if reason != "" {
+		// JSON strings are valid YAML.
+		b, _ := json.Marshal(reason)
+		fmt.Printf("  reason: %s\n", b)
+	}
+	fmt.Println("  ...")
+}
+
 func (p *TAPPrinter) Close() error {
+	p.printHeader()
 	switch {
 	case p.n == 0:
 		fmt.Println("1..0 # SKIP no tests")
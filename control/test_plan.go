@@ -6,7 +6,6 @@ import (
 	"sync"
 	"time"
 
-	"github.com/nokia/ntt/internal/fs"
 	"github.com/nokia/ntt/internal/log"
 	"github.com/nokia/ntt/project"
 	"github.com/nokia/ntt/ttcn3"
@@ -23,7 +22,7 @@ func NewTestPlan(conf *project.Config) (*TestPlan, error) {
 		panic("NewTestPlan: project.Config must not be nil")
 	}
 
-	srcs, err := fs.TTCN3Files(conf.Sources...)
+	srcs, err := project.TestFiles(conf)
 	if err != nil {
 		return nil, err
 	}
@@ -46,6 +45,10 @@ func NewTestPlan(conf *project.Config) (*TestPlan, error) {
 				modLvl, lvl int
 			)
 			root := ttcn3.ParseFile(src)
+			if root.Root == nil {
+				log.Printf("warning: skipping %s: %s\n", src, root.Err)
+				return
+			}
 			root.Inspect(func(n syntax.Node) bool {
 
 				// We need to keep track of the current module
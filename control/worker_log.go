@@ -0,0 +1,74 @@
+package control
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nokia/ntt/internal/log"
+)
+
+// workerLogKey is the context key of the diagnostic log of a worker.
+type workerLogKey struct{}
+
+// WorkerLogf writes a line to the diagnostic log of the worker ctx belongs
+// to. Runners use it to record the commands they spawn. Without worker log
+// WorkerLogf does nothing.
+func WorkerLogf(ctx context.Context, format string, v ...interface{}) {
+	if l, ok := ctx.Value(workerLogKey{}).(log.Logger); ok {
+		s := fmt.Sprintf(format, v...)
+		l.Output(log.PrintLevel, fmt.Sprintf("%s %s\n", time.Now().Format(time.RFC3339Nano), strings.TrimSpace(s)))
+	}
+}
+
+// RotateWorkerLogs renames the worker logs in dir to worker-<n>.log.1,
+// replacing any older ones. Controllers append to existing worker logs,
+// hence tools running several controllers for one run, for example to retry
+// failed tests, rotate the logs once before the first controller starts.
+func RotateWorkerLogs(dir string) error {
+	names, err := filepath.Glob(filepath.Join(dir, "worker-*.log"))
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := os.Rename(name, name+".1"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// openWorkerLog opens the log file of worker i in dir for appending.
+func openWorkerLog(dir string, i int) (*os.File, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	name := filepath.Join(dir, fmt.Sprintf("worker-%d.log", i))
+	return os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+}
+
+// logEvent writes a lifecycle event to the worker log.
+func logEvent(ctx context.Context, e Event) {
+	switch e := e.(type) {
+	case StartEvent:
+		WorkerLogf(ctx, "started %s (job %s)", e.Name, jobID(e.Job))
+	case StopEvent:
+		WorkerLogf(ctx, "finished %s (job %s): %s", e.Name, jobID(e.Job), e.Verdict)
+	case ErrorEvent:
+		if job := UnwrapJob(e); job != nil {
+			WorkerLogf(ctx, "error (job %s): %s", job.ID, e.Err.Error())
+			return
+		}
+		WorkerLogf(ctx, "error: %s", e.Err.Error())
+	}
+}
+
+func jobID(job *Job) string {
+	if job == nil {
+		return "-"
+	}
+	return job.ID
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -40,6 +41,8 @@ var (
 					}
 				case "dot":
 					dot(tree.Root)
+				case "json":
+					dumpJSON(tree)
 				case "text":
 					dumpAST(0, "Root", reflect.ValueOf(tree.Root.NodeList.Nodes))
 					w.Flush()
@@ -113,11 +116,15 @@ func init() {
 }
 
 func dumpJSON(tree *ttcn3.Tree) {
-	b, err := json.MarshalIndent(tree.Root, "", "  ")
+	b, err := syntax.MarshalJSON(tree.Root)
 	if err != nil {
 		fatal(err)
 	}
-	fmt.Println(string(b))
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, b, "", "  "); err != nil {
+		fatal(err)
+	}
+	fmt.Println(buf.String())
 }
 
 func dumpAST(indent int, name string, v reflect.Value) {
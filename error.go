@@ -65,6 +65,17 @@ func (e errMissingCaseElse) Error() string {
 	return fmt.Sprintf("%s:%s: error: missing case else in select statement", syntax.Filename(e.node), syntax.Begin(e.node))
 }
 
+type errUnreachable struct {
+	def  syntax.Node
+	node syntax.Node
+}
+
+func (e errUnreachable) Error() string {
+	return fmt.Sprintf("%s:%s: error: unreachable code in %q", syntax.Filename(e.node), syntax.Begin(e.node), syntax.Name(e.def))
+}
+
+func (e errUnreachable) IsSilent() bool { return isSilent(e.def, "UnreachableCode") }
+
 type errUsageExceedsLimit struct {
 	node  syntax.Node
 	usage int
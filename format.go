@@ -3,11 +3,13 @@ package main
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"runtime"
 
 	"github.com/hashicorp/go-multierror"
 	"github.com/nokia/ntt/internal/fs"
+	"github.com/nokia/ntt/ttcn3"
 	"github.com/nokia/ntt/ttcn3/v2/printer"
 	"github.com/pmezard/go-difflib/difflib"
 	"github.com/spf13/cobra"
@@ -15,20 +17,29 @@ import (
 
 var (
 	FormatCommand = &cobra.Command{
-		Hidden: true,
-		Use:    "format",
-		Short:  "format files according to the canonical TTCN-3 style",
-		Long: `Format files according to the canonical TTCN-3 style.
+		Use:     "fmt",
+		Aliases: []string{"format"},
+		Short:   "Format TTCN-3 files according to the canonical TTCN-3 style",
+		Long: `Format TTCN-3 files according to the canonical TTCN-3 style.
 
-The format command reads the given source files and formats them according to
-the standard TTCN-3 style. Files from imports or generated files are not
-formatted.
+The fmt command formats the given files and directories and rewrites them in
+place. Files from imports or generated files are not formatted. With argument
+'-', standard input is formatted to standard output.
 
 If a directory is given as argument, the command scans the directory for
 manifest files (package.yml) and potential TTCN-3 source files to format.
 
-Without any arguments, the command reads scans the current directory for
-manifest files (package.yml) and potential TTCN-3 source files.
+Without any arguments, the command formats the sources of the test suite in the
+current directory.
+
+With -l the files whose formatting differs are listed and with -d their diffs
+are printed instead; files are not written then.
+
+Files with syntax errors are not formatted. Their errors are reported on
+standard error.
+
+For compatibility, the command is also available as 'format'. Called as
+'format' it prints the formatted files to standard output, unless -i is given.
 
 
 CANONICAL STYLE
@@ -37,13 +48,14 @@ Formatting style is a matter of taste and a subject of debate. A canonical
 style settles such debates and makes code easier to read due to a consistent
 style across projects and teams.
 
-For this reason, the format command does not support custom confirguration like
+For this reason, the fmt command does not support custom confirguration like
 maximum line length or indentation style.
 
 
 EXIT STATUS
 
 Exit status is zero if no errors were encountered, and non-zero otherwise.
+Files with syntax errors are errors.
 
 The --diff or --list flags will cause the command to exit with non-zero status,
 if any files need to be formatted.
@@ -55,26 +67,7 @@ The canonical style is still developing and will change in the future as is
 this tool. Do not use it in production yet.
 
 `,
-
-		RunE: func(cmd *cobra.Command, args []string) error {
-			srcs, err := fs.TTCN3Files(Project.Sources...)
-			if err != nil {
-				return err
-			}
-
-			var merr *multierror.Error
-			for _, src := range srcs {
-				if err := processFile(src); err != nil {
-					merr = multierror.Append(merr, err)
-				}
-			}
-
-			if formattedFiles > 0 && (listFiles || diff) {
-				return fmt.Errorf("%d files with format differences", formattedFiles)
-			}
-
-			return merr.ErrorOrNil()
-		},
+		RunE: formatFiles,
 	}
 
 	listFiles, diff, inplace bool
@@ -83,18 +76,70 @@ this tool. Do not use it in production yet.
 )
 
 func init() {
-	FormatCommand.Flags().BoolVarP(&inplace, "in-place", "i", false, "format files in place")
+	FormatCommand.Flags().BoolVarP(&inplace, "in-place", "i", false, "format files in place (default, unless called as format)")
 	FormatCommand.Flags().BoolVarP(&diff, "diff", "d", false, "display diff instead of rewriting files. Exit with non-zero status if any files need to be formatted")
 	FormatCommand.Flags().BoolVarP(&listFiles, "list", "l", false, "list files whose formatting differs. Exit with non-zero status if any files need to be formatted")
 	FormatCommand.Flags().IntVarP(&spaces, "tabs-to-spaces", "s", 0, "convert each tab to N spaces")
 }
 
-func processFile(path string) error {
-	src, err := fs.Content(path)
+func formatFiles(cmd *cobra.Command, args []string) error {
+	if !listFiles && !diff && cmd.CalledAs() != "format" {
+		inplace = true
+	}
+	formattedFiles = 0
+
+	if len(args) == 1 && args[0] == "-" {
+		src, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		if err := ttcn3.ParseBytes("<stdin>", src).Err; err != nil {
+			return err
+		}
+		b, err := formatSource(src)
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(b)
+		return err
+	}
+
+	var paths []string
+	if len(args) > 0 {
+		paths, _ = splitArgs(args, cmd.ArgsLenAtDash())
+	} else {
+		paths = Project.Sources
+	}
+	srcs, err := fs.TTCN3Files(paths...)
 	if err != nil {
 		return err
 	}
 
+	var (
+		merr   *multierror.Error
+		broken int
+	)
+	for _, src := range srcs {
+		if err := ttcn3.ParseFile(src).Err; err != nil {
+			fmt.Fprintln(os.Stderr, ttcn3.FormatError(err))
+			broken++
+			continue
+		}
+		if err := processFile(src); err != nil {
+			merr = multierror.Append(merr, err)
+		}
+	}
+	if broken > 0 {
+		merr = multierror.Append(merr, fmt.Errorf("%d file(s) with syntax errors not formatted", broken))
+	}
+	if formattedFiles > 0 && (listFiles || diff) {
+		merr = multierror.Append(merr, fmt.Errorf("%d files with format differences", formattedFiles))
+	}
+	return merr.ErrorOrNil()
+}
+
+// formatSource returns src formatted in canonical style.
+func formatSource(src []byte) ([]byte, error) {
 	var buf bytes.Buffer
 	p := printer.NewCanonicalPrinter(&buf)
 	p.Indent = -1
@@ -103,9 +148,21 @@ func processFile(path string) error {
 		p.TabWidth = spaces
 	}
 	if err := p.Fprint(src); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func processFile(path string) error {
+	src, err := fs.Content(path)
+	if err != nil {
+		return err
+	}
+
+	res, err := formatSource(src)
+	if err != nil {
 		return err
 	}
-	res := buf.Bytes()
 
 	if !bytes.Equal(src, res) {
 
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	unformatted = "module m {\nfunction f() {\nvar integer x := 1\n}\n}\n"
+	formatted   = "module m {\nfunction f() {\n\tvar integer x := 1\n}\n}\n"
+)
+
+// runFormat calls the fmt command with the given flags and arguments and
+// returns its standard output and error. stdin is passed as standard input.
+func runFormat(t *testing.T, stdin string, list, showDiff bool, args ...string) (string, error) {
+	t.Helper()
+	listFiles, diff, inplace = list, showDiff, false
+	defer func() { listFiles, diff, inplace = false, false, false }()
+
+	in, err := os.CreateTemp(t.TempDir(), "stdin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	if _, err := in.WriteString(stdin); err != nil {
+		t.Fatal(err)
+	}
+	in.Seek(0, io.SeekStart)
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldStdin, oldStdout := os.Stdin, os.Stdout
+	os.Stdin, os.Stdout = in, w
+	defer func() { os.Stdin, os.Stdout = oldStdin, oldStdout }()
+
+	out := make(chan string)
+	go func() {
+		b, _ := io.ReadAll(r)
+		out <- string(b)
+	}()
+	err = formatFiles(FormatCommand, args)
+	w.Close()
+	return <-out, err
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestFormatInPlace(t *testing.T) {
+	file := writeFile(t, "a.ttcn3", unformatted)
+	out, err := runFormat(t, "", false, false, file)
+	assert.Nil(t, err)
+	assert.Equal(t, "", out)
+	assert.Equal(t, formatted, readFile(t, file))
+}
+
+func TestFormatList(t *testing.T) {
+	file := writeFile(t, "a.ttcn3", unformatted)
+	clean := writeFile(t, "b.ttcn3", formatted)
+	out, err := runFormat(t, "", true, false, file, clean)
+	assert.NotNil(t, err)
+	assert.Equal(t, file+"\n", out)
+	assert.Equal(t, unformatted, readFile(t, file))
+
+	_, err = runFormat(t, "", true, false, clean)
+	assert.Nil(t, err)
+}
+
+func TestFormatDiff(t *testing.T) {
+	file := writeFile(t, "a.ttcn3", unformatted)
+	out, err := runFormat(t, "", false, true, file)
+	assert.NotNil(t, err)
+	assert.Contains(t, out, "--- "+file+".orig\n+++ "+file+"\n")
+	assert.Contains(t, out, "-var integer x := 1\n+\tvar integer x := 1\n")
+	assert.Equal(t, unformatted, readFile(t, file))
+}
+
+func TestFormatStdin(t *testing.T) {
+	out, err := runFormat(t, unformatted, false, false, "-")
+	assert.Nil(t, err)
+	assert.Equal(t, formatted, out)
+
+	_, err = runFormat(t, "module m {", false, false, "-")
+	assert.NotNil(t, err)
+}
+
+func TestFormatSyntaxError(t *testing.T) {
+	broken := writeFile(t, "broken.ttcn3", "module m { function f( }\n")
+	file := writeFile(t, "a.ttcn3", unformatted)
+	_, err := runFormat(t, "", false, false, broken, file)
+	assert.NotNil(t, err)
+
+	// Files with syntax errors are not touched, the others are formatted.
+	assert.Equal(t, "module m { function f( }\n", readFile(t, broken))
+	assert.Equal(t, formatted, readFile(t, file))
+}
@@ -94,6 +94,7 @@ root of the test suite directory structure. Supported fields:
 | name             | string            | Name of the test suite.
 | sources          | string[]          | TTCN-3 Source files containing tests.
 | imports          | string[]          | Packages the suite depends on. This could be adapters, codecs, generators, ...
+| exclude_imports  | bool              | Do not list or run tests from sources located in an import directory.
 | timeout          | number            | Default timeout for tests in seconds.
 | hooks_file       | string            | Path to the hook script.
 | parameters_file  | string            | Path to module parameters file.
@@ -149,6 +150,12 @@ argument. Following events are currently supported:
 
   before-run    Called before run.
 
+  before_all    Called once by 'ntt run' before the first test is started. A
+                non-zero exit code aborts the run.
+
+  before_each   Called before every test. A non-zero exit code fails the test
+                with an error, like a crash. The test is not run.
+
   setup         Called by SutControl.setup
 
   run           Called by SutControl.run. Usually called to execute SUT. Is
@@ -158,8 +165,14 @@ argument. Following events are currently supported:
                 Please note, if the TTCN-3 runtime is forced to quit, for
 		example by signal, this action might not be called.
 
+  after_each    Called after every test. A non-zero exit code is logged.
+
   after-run     Called after run. Can used by post-processing tools.
 
+  after_all     Called once by 'ntt run' after all tests finished, also when
+                the run was interrupted or stopped early. A non-zero exit code
+                is reported as warning.
+
 
 Several environment variables are provided. Test suite specific environment
 variables:
@@ -176,7 +189,12 @@ And all variables from k3.env and variables-sections (try 'k3 show -- env').
 Event specific environment variables:
 
   K3_TEST_NAME      Full qualified test-name
-  K3_TEST_VERDICT   Verdict of executed test case (only available for after-run)
+  K3_TEST_ID        Unique job identifier (only available for before_each and
+                    after_each)
+  K3_TEST_DIR       Working directory of the test (only available for
+                    before_each and after_each)
+  K3_TEST_VERDICT   Verdict of executed test case (only available for after-run
+                    and after_each)
   K3_TEST_LOG       Path to log-file (only available for after-run)
   K3_SUT_ID         String to identify multiple SUT instances. Only availble
                     in setup, run and teardown.
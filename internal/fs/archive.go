@@ -0,0 +1,104 @@
+package fs
+
+import (
+	"archive/zip"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// Files inside a zip archive are addressed by URLs of the form
+//
+//	zip://<path to archive>!/<path inside archive>
+//
+// for example zip:///home/user/suite.zip!/src/test.ttcn3. The path of the
+// archive is a regular file system path. The path inside the archive is
+// separated by slashes; an empty path or a trailing slash refers to the root
+// directory of the archive.
+//
+// Archive support is limited:
+//
+//   - Archives are read-only. SetBytes changes the content in memory only.
+//   - Only zip archives are supported. Nested archives are not.
+//   - Every read opens the archive again. There is no caching of archive
+//     indices, yet.
+//   - Only the virtual file system understands these URLs. Tools working on
+//     the file system directly, like the k3 compiler, need unpacked sources.
+const zipScheme = "zip://"
+
+var ErrNotInArchive = errors.New("file not in archive")
+
+// IsArchive returns true if s is an URL referring to a zip archive or
+// a file in a zip archive.
+func IsArchive(s string) bool {
+	return strings.HasPrefix(s, zipScheme)
+}
+
+// SplitArchive splits an archive URL into the path of the archive and the
+// cleaned path inside the archive, without leading slash. ok is false if s is
+// not an archive URL.
+func SplitArchive(s string) (archive string, name string, ok bool) {
+	if !IsArchive(s) {
+		return "", "", false
+	}
+	archive = strings.TrimPrefix(s, zipScheme)
+	if i := strings.Index(archive, "!/"); i >= 0 {
+		archive, name = archive[:i], archive[i+2:]
+	}
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	return archive, name, true
+}
+
+// readArchive returns the content of the file referenced by archive URL s.
+func readArchive(s string) ([]byte, error) {
+	archive, name, _ := SplitArchive(s)
+	r, err := zip.OpenReader(archive)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	for _, f := range r.File {
+		if f.Name != name {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return io.ReadAll(rc)
+	}
+	return nil, &os.PathError{Op: "open", Path: s, Err: ErrNotInArchive}
+}
+
+// readArchiveDir returns the URLs of the regular files directly contained in
+// the archive directory s. isDir is false if s does not refer to a directory.
+func readArchiveDir(s string) (files []string, isDir bool, err error) {
+	archive, dir, _ := SplitArchive(s)
+	r, err := zip.OpenReader(archive)
+	if err != nil {
+		return nil, false, err
+	}
+	defer r.Close()
+
+	isDir = dir == ""
+	for _, f := range r.File {
+		name := strings.TrimSuffix(f.Name, "/")
+		if dir != "" && !strings.HasPrefix(name, dir+"/") {
+			continue
+		}
+		isDir = true
+		parent := path.Dir(name)
+		if parent == "." {
+			parent = ""
+		}
+		if f.FileInfo().IsDir() || parent != dir {
+			continue
+		}
+		files = append(files, zipScheme+archive+"!/"+name)
+	}
+	return files, isDir, nil
+}
@@ -57,17 +57,23 @@ func (f *File) ID() string {
 }
 
 // Bytes returns the contents of File. If content was not specified using
-// SetBytes, Bytes will try reading the file path's content from disk.
+// SetBytes, Bytes will try reading the file path's content from disk or from
+// the zip archive the path refers to.
 func (f *File) Bytes() ([]byte, error) {
 	if f.bytes == nil && f.err == nil {
-		f.bytes, f.err = ioutil.ReadFile(f.Path())
+		if IsArchive(f.path) {
+			f.bytes, f.err = readArchive(f.path)
+		} else {
+			f.bytes, f.err = ioutil.ReadFile(f.Path())
+		}
 		f.version = 0
 	}
 
 	return f.bytes, f.err
 }
 
-// SetBytes set the contents of the file.
+// SetBytes set the contents of the file. Files in archives are only changed in
+// memory.
 func (f *File) SetBytes(b []byte) {
 	f.bytes = b
 	f.err = nil
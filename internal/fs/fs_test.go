@@ -1,6 +1,7 @@
 package fs_test
 
 import (
+	"archive/zip"
 	"errors"
 	"io/ioutil"
 	"os"
@@ -120,5 +121,55 @@ func TestTTCN3Files(t *testing.T) {
 		assert.True(t, errors.Is(err, fs.ErrInvalidFileExtension))
 		assert.Equal(t, want, got)
 	})
+	t.Run("zip", func(t *testing.T) {
+		archive := writeZip(t, "src/a.ttcn3", "src/b.txt", "src/sub/c.ttcn3", "d.ttcn")
+		want := []string{
+			"zip://" + archive + "!/src/a.ttcn3",
+			"zip://" + archive + "!/d.ttcn",
+		}
+		got, err := fs.TTCN3Files("zip://"+archive+"!/src/", "zip://"+archive)
+		assert.Nil(t, err)
+		assert.Equal(t, want, got)
+	})
+
+}
+
+func TestArchive(t *testing.T) {
+	archive := writeZip(t, "src/a.ttcn3", "src/b.ttcn3")
+
+	b, err := fs.Content("zip://" + archive + "!/src/b.ttcn3")
+	assert.Nil(t, err)
+	assert.Equal(t, "src/b.ttcn3", string(b))
 
+	_, err = fs.Content("zip://" + archive + "!/src/x.ttcn3")
+	assert.True(t, errors.Is(err, fs.ErrNotInArchive))
+
+	assert.Equal(t, []string{
+		"zip://" + archive + "!/src/a.ttcn3",
+		"zip://" + archive + "!/src/b.ttcn3",
+	}, fs.FindTTCN3Files("zip://"+archive+"!/src"))
+}
+
+// writeZip creates a zip archive with the given files. The content of each
+// file is its name.
+func writeZip(t *testing.T, names ...string) string {
+	archive := filepath.Join(t.TempDir(), "suite.zip")
+	f, err := os.Create(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	w := zip.NewWriter(f)
+	for _, name := range names {
+		fw, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Write([]byte(name))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return archive
 }
@@ -42,7 +42,8 @@ func FindTTCN3DirectoriesRecursive(dir string) []string {
 }
 
 // TTCN3Files takes a list of paths and replaces all paths describing a
-// directory with the TTCN-3 files contained in that directory.
+// directory with the TTCN-3 files contained in that directory. Directories
+// inside zip archives (see SplitArchive) are supported, too.
 //
 // A error is returned for each path which is not accessable or for each
 // (non-directory), which does not have a TTCN-3 extension.
@@ -55,6 +56,27 @@ func TTCN3Files(paths ...string) ([]string, error) {
 		ret  []string
 	)
 	for _, path := range paths {
+		if IsArchive(path) {
+			files, isDir, err := readArchiveDir(path)
+			switch {
+			case err != nil:
+				ret = append(ret, path)
+				errs = multierror.Append(errs, err)
+			case isDir:
+				for _, file := range files {
+					if HasTTCN3Extension(file) {
+						ret = append(ret, file)
+					}
+				}
+			default:
+				if !HasTTCN3Extension(path) {
+					errs = multierror.Append(errs, fmt.Errorf("%s: %w", path, ErrInvalidFileExtension))
+				}
+				ret = append(ret, path)
+			}
+			continue
+		}
+
 		info, err := os.Stat(path)
 		switch {
 		case err != nil && !IsURI(path):
@@ -248,6 +270,17 @@ func ReplaceExt(path string, newExt string) string {
 }
 
 func findFiles(dir string, matcher func(name string) bool) []string {
+	if IsArchive(dir) {
+		files, _, _ := readArchiveDir(dir)
+		var sources []string
+		for _, file := range files {
+			if matcher(file) {
+				sources = append(sources, file)
+			}
+		}
+		return sources
+	}
+
 	files, err := ioutil.ReadDir(dir)
 	if err != nil {
 		return nil
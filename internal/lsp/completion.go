@@ -72,7 +72,7 @@ func (s *Server) completion(ctx context.Context, params *protocol.CompletionPara
 		return &protocol.CompletionList{IsIncomplete: false, Items: ret}, nil
 	}
 
-	pos := tree.PosFor(int(params.TextDocumentPositionParams.Position.Line+1), int(params.TextDocumentPositionParams.Position.Character+1))
+	pos := tree.Root.PosFor(int(params.TextDocumentPositionParams.Position.Line+1), int(params.TextDocumentPositionParams.Position.Character+1))
 	nodeStack := LastNonWsToken(tree.Root, pos)
 	if len(nodeStack) == 0 {
 		return nil, nil
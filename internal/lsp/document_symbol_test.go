@@ -17,8 +17,8 @@ func testDocumentSymbol(t *testing.T, text string) []Symbol {
 	)
 
 	makeSymbol = func(sym protocol.DocumentSymbol) Symbol {
-		begin := tree.PosFor(int(sym.Range.Start.Line)+1, int(sym.Range.Start.Character)+1)
-		end := tree.PosFor(int(sym.Range.End.Line)+1, int(sym.Range.End.Character)+1)
+		begin := tree.Root.PosFor(int(sym.Range.Start.Line)+1, int(sym.Range.Start.Character)+1)
+		end := tree.Root.PosFor(int(sym.Range.End.Line)+1, int(sym.Range.End.Character)+1)
 		s := Symbol{
 			Kind:   sym.Kind,
 			Name:   sym.Name,
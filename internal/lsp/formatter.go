@@ -49,8 +49,8 @@ func (s *Server) formatting(ctx context.Context, params *protocol.DocumentFormat
 		log.Debug("skip formatting: ", tree.Err.Error())
 		return nil, nil
 	}
-	begin := tree.Position(0)
-	end := tree.Position(len(b)) // The end position is exclusive.
+	begin := tree.Root.Position(0)
+	end := tree.Root.Position(len(b)) // The end position is exclusive.
 
 	if b[len(b)-1] == '\n' {
 		end.Line++
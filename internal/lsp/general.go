@@ -133,9 +133,13 @@ func (s *Server) initialized(ctx context.Context, params *protocol.InitializedPa
 	s.didChangeConfiguration(ctx, &protocol.DidChangeConfigurationParams{})
 	for _, folder := range s.pendingFolders {
 		log.Printf("Scanning %q for possible TTCN-3 suites\n", folder.URI)
-		for _, root := range project.Discover(folder.URI) {
+		roots, err := project.DiscoverContext(ctx, folder.URI)
+		for _, root := range roots {
 			s.AddSuite(root)
 		}
+		if err != nil {
+			log.Printf("Scanning %q canceled: %s\n", folder.URI, err.Error())
+		}
 	}
 	s.testCtrl = &TestController{}
 	s.testCtrl.Start(s.client, s, &s.Suites)
@@ -33,7 +33,7 @@ func invokedFromMapOrConnect(n syntax.Node, syn *ttcn3.Tree, list []protocol.Loc
 			continue
 		}
 		if idNode.String() == "map" || idNode.String() == "connect" {
-			loc := location(syntax.Span{Begin: syn.Position(node.Pos()), End: syn.Position(node.End()), Filename: syn.Filename()})
+			loc := location(syntax.Span{Begin: syn.Root.Position(node.Pos()), End: syn.Root.Position(node.End()), Filename: syn.Filename()})
 			if !isDuplicate(list, loc) {
 				list = append(list, loc)
 			}
@@ -191,8 +191,8 @@ func (s *Server) semanticTokensRange(ctx context.Context, params *protocol.Seman
 
 	file := string(params.TextDocument.URI)
 	tree := ttcn3.ParseFile(file)
-	begin := tree.PosFor(int(params.Range.Start.Line)+1, int(params.Range.Start.Character+1))
-	end := tree.PosFor(int(params.Range.End.Line+1), int(params.Range.End.Character+1))
+	begin := tree.Root.PosFor(int(params.Range.Start.Line)+1, int(params.Range.Start.Character+1))
+	end := tree.Root.PosFor(int(params.Range.End.Line+1), int(params.Range.End.Character+1))
 	return s.semanticTokensRecover(tree, &s.db, begin, end)
 }
 
@@ -514,8 +514,8 @@ func testTokens(t *testing.T, rng *protocol.Range, text string) []Token {
 	begin := tree.Pos()
 	end := tree.End()
 	if rng != nil {
-		begin = tree.PosFor(int(rng.Start.Line), int(rng.Start.Character))
-		end = tree.PosFor(int(rng.End.Line), int(rng.End.Character))
+		begin = tree.Root.PosFor(int(rng.Start.Line), int(rng.Start.Character))
+		end = tree.Root.PosFor(int(rng.End.Line), int(rng.End.Character))
 	}
 
 	var (
@@ -530,7 +530,7 @@ func testTokens(t *testing.T, rng *protocol.Range, text string) []Token {
 			col = 1
 		}
 		col += int(list[i+1])
-		pos := tree.PosFor(line, col)
+		pos := tree.Root.PosFor(line, col)
 		toks = append(toks, Token{
 			Line: line,
 			Text: Substr(text, pos, pos+int(list[i+2])),
@@ -27,11 +27,16 @@ func (s *Server) didOpen(ctx context.Context, params *protocol.DidOpenTextDocume
 		log.Printf("File %q does not belong to any known test suite\n", uri)
 		dir := filepath.Dir(fs.Open(string(uri.SpanURI())).Path())
 		log.Printf("Scanning %q for possible TTCN-3 suites\n", dir)
-		if roots := project.Discover(dir); len(roots) > 0 {
-			for _, root := range roots {
-				s.AddSuite(root)
-			}
-		} else {
+		roots, err := project.DiscoverContext(ctx, dir)
+		for _, root := range roots {
+			s.AddSuite(root)
+		}
+		switch {
+		case err != nil:
+			// Do not guess a suite from a partial walk.
+			log.Printf("Scanning %q canceled: %s\n", dir, err.Error())
+			return nil
+		case len(roots) == 0:
 			log.Printf("Could not find a good candidate. Trying %q recursively and hope for the best.\n", dir)
 			s.AddSuite(project.Suite{RootDir: dir, SourceDir: dir})
 		}
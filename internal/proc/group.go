@@ -0,0 +1,16 @@
+//go:build !unix && !linux
+// +build !unix,!linux
+
+package proc
+
+import (
+	"os"
+	"os/exec"
+)
+
+func setProcessGroup(cmd *exec.Cmd) {
+}
+
+func killProcessGroup(p *os.Process) error {
+	return p.Kill()
+}
@@ -25,6 +25,27 @@ func CommandContext(ctx context.Context, name string, args ...string) *exec.Cmd
 	return cmd
 }
 
+// StartGroup starts cmd in a new process group. When ctx is done before the
+// returned function is called, the whole group is killed, including processes
+// spawned by the command. Unlike the command itself, these would otherwise
+// keep running and keep the output pipes of the command open. Call the
+// returned function after cmd.Wait returned.
+func StartGroup(ctx context.Context, cmd *exec.Cmd) (func(), error) {
+	setProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			killProcessGroup(cmd.Process)
+		case <-done:
+		}
+	}()
+	return func() { close(done) }, nil
+}
+
 // Exec executes the named program with the given arguments.
 // The parent process will be replaced by the new process, for platforms that support it.
 func Exec(name string, args ...string) error {
@@ -0,0 +1,48 @@
+package proc
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestStartGroup(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no shell")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The background process inherits stdout and would keep it open.
+	cmd := CommandContext(ctx, "sh", "-c", "sleep 60 & echo started; wait")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	release, err := StartGroup(ctx, cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	r := bufio.NewReader(stdout)
+	if line, err := r.ReadString('\n'); err != nil || line != "started\n" {
+		t.Fatalf("unexpected output %q: %v", line, err)
+	}
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		io.ReadAll(r)
+		cmd.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("processes of the group were not killed")
+	}
+}
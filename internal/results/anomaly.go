@@ -0,0 +1,86 @@
+package results
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// An Anomaly describes a test whose latest duration deviates significantly
+// from its historical mean.
+type Anomaly struct {
+	Name    string        `json:"name"`    // Full qualified test name
+	Latest  time.Duration `json:"latest"`  // Duration of the latest run
+	Mean    time.Duration `json:"mean"`    // Mean duration of previous runs
+	StdDev  time.Duration `json:"stddev"`  // Standard deviation of previous runs
+	Sigma   float64       `json:"sigma"`   // Deviation of latest run in standard deviations
+	History int           `json:"history"` // Number of previous runs
+}
+
+// Anomalies compares the latest run of every test with all its previous runs
+// and returns those tests, whose latest duration deviates more than sigma
+// standard deviations from the historical mean.
+//
+// Tests with less than minHistory previous runs are ignored, because mean and
+// deviation of very few samples are not meaningful. The result is sorted by
+// magnitude of deviation, largest first.
+func Anomalies(runs []Run, sigma float64, minHistory int) []Anomaly {
+	byName := make(map[string][]Run)
+	for _, r := range runs {
+		byName[r.Name] = append(byName[r.Name], r)
+	}
+
+	var ret []Anomaly
+	for name, runs := range byName {
+		if len(runs)-1 < minHistory || len(runs) < 2 {
+			continue
+		}
+		sort.SliceStable(runs, func(i, j int) bool {
+			return runs[i].Begin.Before(runs[j].Begin.Time)
+		})
+
+		latest := runs[len(runs)-1].Duration()
+		mean, stddev := meanStdDev(Durations(runs[:len(runs)-1]))
+		if stddev == 0 {
+			continue
+		}
+
+		d := float64(latest-mean) / float64(stddev)
+		if math.Abs(d) > sigma {
+			ret = append(ret, Anomaly{
+				Name:    name,
+				Latest:  latest,
+				Mean:    mean,
+				StdDev:  stddev,
+				Sigma:   d,
+				History: len(runs) - 1,
+			})
+		}
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		if a, b := math.Abs(ret[i].Sigma), math.Abs(ret[j].Sigma); a != b {
+			return a > b
+		}
+		return ret[i].Name < ret[j].Name
+	})
+	return ret
+}
+
+// meanStdDev returns arithmetic mean and population standard deviation.
+func meanStdDev(slice []time.Duration) (time.Duration, time.Duration) {
+	if len(slice) == 0 {
+		return 0, 0
+	}
+	var sum float64
+	for _, d := range slice {
+		sum += float64(d)
+	}
+	mean := sum / float64(len(slice))
+
+	var v float64
+	for _, d := range slice {
+		v += math.Pow(float64(d)-mean, 2)
+	}
+	return time.Duration(mean), time.Duration(math.Sqrt(v / float64(len(slice))))
+}
@@ -0,0 +1,140 @@
+package results
+
+import "sort"
+
+// A Change describes how the verdict of a test differs from its baseline.
+type Change string
+
+const (
+	Unchanged  Change = ""
+	Regression Change = "regression" // passed in the baseline, but not now
+	NewTest    Change = "new"        // not part of the baseline
+)
+
+// A Baseline compares runs with the final verdicts of a previous test run,
+// usually loaded with Load. Compare may be called as the runs complete, for
+// streaming the changes.
+type Baseline struct {
+	verdicts map[string]Verdict
+	seen     map[string]bool
+}
+
+// NewBaseline returns a baseline with the final verdicts of runs.
+func NewBaseline(runs []Run) *Baseline {
+	b := &Baseline{
+		verdicts: make(map[string]Verdict),
+		seen:     make(map[string]bool),
+	}
+	for _, r := range FinalVerdicts(runs) {
+		b.verdicts[r.Name] = NormalizeVerdict(string(r.Verdict))
+	}
+	return b
+}
+
+// Compare returns how run r changed compared to the baseline. Runs with
+// verdict pass or done passed, skipped runs are not compared. Every other
+// verdict of a test, which passed in the baseline, is a regression. Tests not
+// part of the baseline are reported as NewTest only once.
+func (b *Baseline) Compare(r Run) Change {
+	first := !b.seen[r.Name]
+	b.seen[r.Name] = true
+	was, ok := b.verdicts[r.Name]
+	if !ok {
+		if first {
+			return NewTest
+		}
+		return Unchanged
+	}
+	switch NormalizeVerdict(string(r.Verdict)) {
+	case PassVerdict, DoneVerdict, SkippedVerdict:
+		return Unchanged
+	}
+	if was == PassVerdict || was == DoneVerdict {
+		return Regression
+	}
+	return Unchanged
+}
+
+// Removed returns the sorted names of the baseline tests, which were not
+// passed to Compare.
+func (b *Baseline) Removed() []string {
+	var names []string
+	for name := range b.verdicts {
+		if !b.seen[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Verdict returns the baseline verdict of test name.
+func (b *Baseline) Verdict(name string) (Verdict, bool) {
+	v, ok := b.verdicts[name]
+	return v, ok
+}
+
+// A VerdictDiff lists how the final verdicts of tests differ between two sets
+// of results. All lists contain sorted test names.
+type VerdictDiff struct {
+	// NewFailures are tests, which passed in the old results, but not in
+	// the new ones.
+	NewFailures []string `json:"new_failures"`
+
+	// NewPasses are tests, which did not pass in the old results, but
+	// pass in the new ones.
+	NewPasses []string `json:"new_passes"`
+
+	// StillFailing are tests, which did not pass in both results.
+	StillFailing []string `json:"still_failing"`
+
+	// Added are tests only part of the new results, Removed are tests
+	// only part of the old results. They are not listed otherwise.
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+}
+
+// Diff compares the final verdicts of two sets of results, like a known-good
+// baseline and the results of the current run. Verdicts are compared like
+// Baseline.Compare does: verdicts pass and done passed, every other verdict
+// failed, except verdict skipped, which is not compared at all.
+func Diff(old, new *DB) VerdictDiff {
+	final := func(db *DB) map[string]Verdict {
+		m := make(map[string]Verdict)
+		if db != nil {
+			for _, r := range FinalVerdicts(db.Runs()) {
+				m[r.Name] = NormalizeVerdict(string(r.Verdict))
+			}
+		}
+		return m
+	}
+	passed := func(v Verdict) bool {
+		return v == PassVerdict || v == DoneVerdict
+	}
+
+	var d VerdictDiff
+	was, is := final(old), final(new)
+	for name, v := range is {
+		w, ok := was[name]
+		switch {
+		case !ok:
+			d.Added = append(d.Added, name)
+		case v == SkippedVerdict || w == SkippedVerdict:
+		case passed(w) && !passed(v):
+			d.NewFailures = append(d.NewFailures, name)
+		case !passed(w) && passed(v):
+			d.NewPasses = append(d.NewPasses, name)
+		case !passed(w) && !passed(v):
+			d.StillFailing = append(d.StillFailing, name)
+		}
+	}
+	for name := range was {
+		if _, ok := is[name]; !ok {
+			d.Removed = append(d.Removed, name)
+		}
+	}
+	for _, names := range [][]string{d.NewFailures, d.NewPasses, d.StillFailing, d.Added, d.Removed} {
+		sort.Strings(names)
+	}
+	return d
+}
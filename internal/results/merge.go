@@ -0,0 +1,27 @@
+package results
+
+import "fmt"
+
+// Merge merges test results files, like those of sharded test runs, into a
+// single one. The sessions of all files are preserved in order, so Runs
+// returns the runs of all files and MaxJobs the maximum number of parallel
+// jobs of any shard.
+//
+// Files without version are compatible with any version. Merging files of
+// different versions returns an error.
+func Merge(dbs ...*DB) (*DB, error) {
+	merged := &DB{}
+	for i, db := range dbs {
+		if db == nil {
+			continue
+		}
+		if db.Version != "" {
+			if merged.Version != "" && merged.Version != db.Version {
+				return nil, fmt.Errorf("cannot merge results of versions %q and %q (results %d)", merged.Version, db.Version, i+1)
+			}
+			merged.Version = db.Version
+		}
+		merged.Sessions = append(merged.Sessions, db.Sessions...)
+	}
+	return merged, nil
+}
@@ -0,0 +1,40 @@
+package results
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Redacted replaces sensitive strings.
+const Redacted = "***"
+
+// A Redactor replaces all matches of its regular expressions with Redacted.
+// A nil Redactor does not change anything.
+type Redactor []*regexp.Regexp
+
+// NewRedactor compiles the given regular expressions into a Redactor.
+func NewRedactor(patterns ...string) (Redactor, error) {
+	var r Redactor
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("redact pattern %q: %w", p, err)
+		}
+		r = append(r, re)
+	}
+	return r, nil
+}
+
+// Redact returns s with all matches replaced by Redacted.
+func (r Redactor) Redact(s string) string {
+	for _, re := range r {
+		s = re.ReplaceAllLiteralString(s, Redacted)
+	}
+	return s
+}
+
+// RedactRun returns a copy of run with its reason redacted.
+func (r Redactor) RedactRun(run Run) Run {
+	run.Reason = r.Redact(run.Reason)
+	return run
+}
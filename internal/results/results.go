@@ -7,7 +7,6 @@ import (
 	"os"
 	"sort"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/nokia/ntt/internal/fs"
@@ -17,7 +16,12 @@ import (
 var Filename = "test_results.json"
 
 func Latest() (*DB, error) {
-	b, err := fs.Open(Filename).Bytes()
+	return Load(Filename)
+}
+
+// Load reads a test results file. A missing file results in an empty DB.
+func Load(file string) (*DB, error) {
+	b, err := fs.Open(file).Bytes()
 	if err != nil {
 		if os.IsNotExist(err) {
 			return &DB{}, nil
@@ -77,14 +81,24 @@ type Session struct {
 	MaxLoad         int    `json:"max_load,omitempty"`
 	ExpectedVerdict string `json:"expected_verdict,omitempty"`
 	Runs            []Run  `json:"runs,omitempty"`
+
+	// Commit is the git commit of the test suite sources under test.
+	Commit string `json:"commit,omitempty"`
+
+	// Dirty is true if the test suite sources had uncommitted changes.
+	Dirty bool `json:"dirty,omitempty"`
+
+	// Modules holds the aggregate counts per module (ntt run
+	// --group-summary).
+	Modules []ModuleSummary `json:"modules,omitempty"`
 }
 
 // A Run describes the execution of a single test case.
 type Run struct {
-	Name     string `json:"name"`               // Full qualified test name
-	Instance int    `json:"instance,omitempty"` // Test instance
-	Verdict  string `json:"verdict,omitempty"`  // the test verdict (pass, fail, none, ...)
-	Reason   string `json:"reason,omitempty"`   // Optional reason for verdicts
+	Name     string  `json:"name"`               // Full qualified test name
+	Instance int     `json:"instance,omitempty"` // Test instance
+	Verdict  Verdict `json:"verdict,omitempty"`  // the test verdict (pass, fail, none, ...)
+	Reason   string  `json:"reason,omitempty"`   // Optional reason for verdicts
 
 	Begin Timestamp `json:"begin"` // When the test was started
 	End   Timestamp `json:"end"`   // When the test ended
@@ -93,6 +107,26 @@ type Run struct {
 	Load       float64 `json:"load,omitempty"`        // the system load when the test was started
 	MaxMem     int     `json:"max_mem,omitempty"`     // the maximum memory used when the test ended
 
+	// Iteration is the repetition the run belongs to, when tests are
+	// repeated (ntt run --repeat-until). The first iteration is 1.
+	Iteration int `json:"iteration,omitempty"`
+
+	// Slow is set if the run took longer than the soft duration limit
+	// (ntt run --warn-slow). Slow runs are not stopped.
+	Slow bool `json:"slow,omitempty"`
+
+	// Quarantined is set for known flaky tests (ntt run --quarantine).
+	// Failures of quarantined runs do not fail the test session.
+	Quarantined bool `json:"quarantined,omitempty"`
+
+	// Attempts is the number of times the test was run, when it was
+	// retried (ntt run --retry). Only the last attempt is recorded.
+	Attempts int `json:"attempts,omitempty"`
+
+	// Fingerprint is a content hash of the test definition and the
+	// definitions it depends on (ntt run --affected).
+	Fingerprint string `json:"fingerprint,omitempty"`
+
 	RunnerID        string `json:"runner_id,omitempty"`
 	ExpectedVerdict string `json:"expected_verdict,omitempty"`
 }
@@ -151,14 +185,17 @@ func Total(runs []Run) time.Duration {
 }
 
 // Duration returns the time spent between the first and the last test run.
+// Runs without timestamps are ignored (see First and Last).
 func Duration(runs []Run) time.Duration {
-	if len(runs) == 0 {
+	begin, end := First(runs).Begin, Last(runs).End
+	if begin.IsZero() || end.IsZero() {
 		return 0
 	}
-	return Last(runs).End.Sub(First(runs).Begin.Time)
+	return end.Sub(begin.Time)
 }
 
-// First returns the first test run
+// First returns the first test run. Runs without begin time, like skipped
+// runs, are ignored, unless no run has one.
 func First(runs []Run) Run {
 	if len(runs) == 0 {
 		return Run{}
@@ -166,14 +203,15 @@ func First(runs []Run) Run {
 
 	first := runs[0]
 	for _, r := range runs {
-		if r.Begin.Before(first.Begin.Time) {
+		if !r.Begin.IsZero() && (first.Begin.IsZero() || r.Begin.Before(first.Begin.Time)) {
 			first = r
 		}
 	}
 	return first
 }
 
-// Last returns the last test run
+// Last returns the last test run. Runs without end time, like skipped runs,
+// are ignored, unless no run has one.
 func Last(runs []Run) Run {
 	if len(runs) == 0 {
 		return Run{}
@@ -181,7 +219,7 @@ func Last(runs []Run) Run {
 
 	last := runs[0]
 	for _, r := range runs {
-		if r.End.After(last.End.Time) {
+		if !r.End.IsZero() && (last.End.IsZero() || r.End.After(last.End.Time)) {
 			last = r
 		}
 	}
@@ -242,26 +280,6 @@ func Loads(runs []Run) []float64 {
 // * A test is considered "unstable", if only some runs had verdict "pass"
 // * Runs with worse or equal verdict will overwrite previous runs.
 func FinalVerdicts(runs []Run) []Run {
-
-	severity := func(verdict string) int {
-		switch strings.TrimSpace(strings.ToLower(verdict)) {
-		case "skipped":
-			return -1
-		case "pass":
-			return 0
-		case "none", "":
-			return 1
-		case "inconc":
-			return 2
-		case "fail":
-			return 3
-		case "error":
-			return 4
-		default:
-			return 5
-		}
-	}
-
 	var (
 		tests    = make(map[string]Run)
 		jobs     = make([]string, 0, len(runs))
@@ -307,6 +325,27 @@ func FinalVerdicts(runs []Run) []Run {
 	return ret
 }
 
+// severity returns a rank for the given verdict. Worse verdicts have higher
+// ranks.
+func severity(verdict Verdict) int {
+	switch NormalizeVerdict(string(verdict)) {
+	case SkippedVerdict:
+		return -1
+	case PassVerdict:
+		return 0
+	case NoneVerdict:
+		return 1
+	case InconcVerdict:
+		return 2
+	case FailVerdict:
+		return 3
+	case ErrorVerdict:
+		return 4
+	default:
+		return 5
+	}
+}
+
 // Average returns the average test duration (median)
 func Average(slice []time.Duration) time.Duration {
 	if len(slice) == 0 {
@@ -1,9 +1,15 @@
 package results
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -19,7 +25,7 @@ func run(verdict, id string) Run {
 		panic(err)
 	}
 	return Run{
-		Verdict:  verdict,
+		Verdict:  Verdict(verdict),
 		Name:     name,
 		Instance: inst,
 	}
@@ -90,3 +96,362 @@ func TestFinalVerdict(t *testing.T) {
 
 	assert.Equal(t, expected, actual)
 }
+
+func TestSummarize(t *testing.T) {
+	s := Summarize([]Run{
+		run("pass", "Test.A-0"),
+		run("fail", "Test.B-0"),
+		run("inconc", "Test.C-0"),
+		run("pass", "Test.D-0"),
+	})
+	assert.Equal(t, 4, s.Total)
+	assert.Equal(t, map[string]int{"pass": 2, "fail": 1, "inconc": 1}, s.Verdicts)
+	assert.Equal(t, "fail", s.Worst)
+
+	assert.Equal(t, 50.0, s.PassRate())
+	assert.Equal(t, 0, s.Slow)
+
+	// Skipped tests, for example by @skip-if, do not lower the pass rate.
+	s = Summarize([]Run{run("pass", "Test.A-0"), run("skipped", "Test.B-0")})
+	assert.Equal(t, 100.0, s.PassRate())
+	assert.Equal(t, 100.0, Summarize([]Run{run("skipped", "Test.B-0")}).PassRate())
+
+	slow := run("pass", "Test.E-0")
+	slow.Slow = true
+	assert.Equal(t, 1, Summarize([]Run{slow, run("pass", "Test.F-0")}).Slow)
+
+	s = Summarize(nil)
+	assert.Equal(t, 0, s.Total)
+	assert.Equal(t, "", s.Worst)
+	assert.Equal(t, 100.0, s.PassRate())
+}
+
+func TestDuration(t *testing.T) {
+	begin := time.Unix(1700000000, 0)
+	timed := func(id string, from, to int) Run {
+		r := run("pass", id)
+		r.Begin = Timestamp{Time: begin.Add(time.Duration(from) * time.Second)}
+		r.End = Timestamp{Time: begin.Add(time.Duration(to) * time.Second)}
+		return r
+	}
+
+	// Skipped runs have no timestamps.
+	runs := []Run{run("skipped", "Test.A-0"), timed("Test.B-0", 2, 5), timed("Test.C-0", 1, 3), run("skipped", "Test.D-0")}
+	assert.Equal(t, "Test.C", First(runs).Name)
+	assert.Equal(t, "Test.B", Last(runs).Name)
+	assert.Equal(t, 4*time.Second, Duration(runs))
+
+	s := Summarize(runs)
+	assert.Equal(t, begin.Add(time.Second), s.Begin.Time)
+	assert.Equal(t, begin.Add(5*time.Second), s.End.Time)
+
+	runs = []Run{run("skipped", "Test.A-0")}
+	assert.Equal(t, "Test.A", First(runs).Name)
+	assert.Equal(t, time.Duration(0), Duration(runs))
+	assert.Equal(t, time.Duration(0), Duration(nil))
+}
+
+func TestSummarizeModules(t *testing.T) {
+	runs := []Run{
+		run("pass", "A.T1-0"),
+		run("fail", "A.T2-0"),
+		run("pass", "B.T1-0"),
+		run("error", "B.T2-0"),
+		run("fail", "B.T3-0"),
+		run("inconc", "C.T1-0"),
+		run("bogus", "C.T2-0"),
+	}
+	runs[0].End = Timestamp{runs[0].Begin.Add(2 * time.Second)}
+
+	assert.Equal(t, []ModuleSummary{
+		{Module: "B", Total: 3, Pass: 1, Fail: 2},
+		{Module: "A", Total: 2, Pass: 1, Fail: 1, Duration: 2},
+		{Module: "C", Total: 2, Inconc: 1, Unknown: 1},
+	}, SummarizeModules(runs))
+
+	assert.Nil(t, SummarizeModules(nil))
+}
+
+func TestAnomalies(t *testing.T) {
+	history := func(name string, durations ...time.Duration) []Run {
+		var runs []Run
+		begin := time.Unix(0, 0)
+		for _, d := range durations {
+			runs = append(runs, Run{
+				Name:  name,
+				Begin: Timestamp{Time: begin},
+				End:   Timestamp{Time: begin.Add(d)},
+			})
+			begin = begin.Add(time.Hour)
+		}
+		return runs
+	}
+
+	var runs []Run
+	runs = append(runs, history("A", 10*time.Second, 11*time.Second, 9*time.Second, 10*time.Second, 1*time.Second)...)
+	runs = append(runs, history("B", 10*time.Second, 11*time.Second, 9*time.Second, 10*time.Second, 10*time.Second)...)
+	runs = append(runs, history("C", 10*time.Second, 100*time.Second)...)
+
+	list := Anomalies(runs, 3, 3)
+	assert.Equal(t, 1, len(list))
+	assert.Equal(t, "A", list[0].Name)
+	assert.Equal(t, 10*time.Second, list[0].Mean)
+	assert.True(t, list[0].Sigma < -3)
+	assert.Equal(t, 4, list[0].History)
+}
+
+func TestTimeSeries(t *testing.T) {
+	dir := t.TempDir()
+	session := func(id string, begin int64, verdicts ...string) Session {
+		s := Session{Id: id}
+		for i, v := range verdicts {
+			s.Runs = append(s.Runs, Run{
+				Name:    "M.T" + strconv.Itoa(i),
+				Verdict: Verdict(v),
+				Begin:   Timestamp{Time: time.Unix(begin, 0)},
+				End:     Timestamp{Time: time.Unix(begin+10, 0)},
+			})
+		}
+		return s
+	}
+	write := func(name string, sessions ...Session) {
+		b, err := json.Marshal(DB{Sessions: sessions})
+		if err != nil {
+			t.Fatal(err)
+		}
+		os.MkdirAll(filepath.Join(dir, name), 0755)
+		if err := os.WriteFile(filepath.Join(dir, name, Filename), b, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("b", session("1", 100, "pass", "fail", "error", "pass"))
+	write("a", session("1", 200, "pass"), session("2", 50, "pass", "inconc"), session("3", 300))
+
+	dbs, err := LoadHistory(dir)
+	assert.Nil(t, err)
+	assert.Len(t, dbs, 2)
+
+	var actual []string
+	for _, p := range TimeSeries(dbs) {
+		actual = append(actual, fmt.Sprintf("%d %s/%s %d %d %.0f %.0f",
+			p.Time.Unix(), filepath.Base(filepath.Dir(p.File)), p.Session, p.Tests, p.Failures, p.PassRate, p.Duration))
+	}
+	assert.Equal(t, []string{
+		"50 a/2 2 0 50 10",
+		"100 b/1 4 2 50 10",
+		"200 a/1 1 0 100 10",
+	}, actual)
+
+	v, err := Point{Failures: 3}.Metric("failures")
+	assert.Nil(t, err)
+	assert.Equal(t, 3.0, v)
+	_, err = Point{}.Metric("foo")
+	assert.NotNil(t, err)
+}
+
+func TestWriteFile(t *testing.T) {
+	RetryDelay = 0
+	t.Setenv("TMPDIR", t.TempDir())
+	name := filepath.Join(t.TempDir(), "test_results.json")
+
+	// failingWriter fails the first n writes to name.
+	failingWriter := func(n int) func(string, []byte, os.FileMode) error {
+		return func(file string, b []byte, perm os.FileMode) error {
+			if file == name && n > 0 {
+				n--
+				return errors.New("no space left on device")
+			}
+			return os.WriteFile(file, b, perm)
+		}
+	}
+
+	t.Run("transient", func(t *testing.T) {
+		file, err := writeFile(failingWriter(2), name, []byte("data"), 3)
+		assert.Nil(t, err)
+		assert.Equal(t, name, file)
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		file, err := writeFile(failingWriter(10), name, []byte("data"), 3)
+		assert.Nil(t, err)
+		assert.Equal(t, os.Getenv("TMPDIR"), filepath.Dir(file))
+		assert.True(t, strings.HasPrefix(filepath.Base(file), "ntt-test_results-"))
+		b, _ := os.ReadFile(file)
+		assert.Equal(t, "data", string(b))
+	})
+}
+
+func TestNormalizeVerdict(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Verdict
+	}{
+		{"", NoneVerdict},
+		{"none", NoneVerdict},
+		{"pass", PassVerdict},
+		{" PASSED ", PassVerdict},
+		{"Inconclusive", InconcVerdict},
+		{"fail", FailVerdict},
+		{"FAILED", FailVerdict},
+		{"fatal", ErrorVerdict},
+		{"error", ErrorVerdict},
+		{"done", DoneVerdict},
+		{"skipped", SkippedVerdict},
+		{"unstable", UnstableVerdict},
+		{"bogus", UnknownVerdict},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, NormalizeVerdict(tt.input), "input %q", tt.input)
+	}
+}
+
+func TestRedactor(t *testing.T) {
+	_, err := NewRedactor("(")
+	assert.NotNil(t, err)
+
+	r, err := NewRedactor(`password=\S+`, `\d{4}-\d{4}`)
+	assert.Nil(t, err)
+	assert.Equal(t, "login failed: *** (card ***)", r.Redact("login failed: password=hunter2 (card 1234-5678)"))
+	assert.Equal(t, "no secrets", r.Redact("no secrets"))
+
+	run := r.RedactRun(Run{Name: "test.A", Reason: "password=hunter2"})
+	b, err := json.Marshal(run)
+	assert.Nil(t, err)
+	assert.NotContains(t, string(b), "hunter2")
+	assert.Contains(t, string(b), `"reason":"***"`)
+
+	var nilRedactor Redactor
+	assert.Equal(t, "password=hunter2", nilRedactor.Redact("password=hunter2"))
+}
+
+func TestBaseline(t *testing.T) {
+	b := NewBaseline([]Run{
+		run("pass", "A-1"),
+		run("pass", "B-1"),
+		run("fail", "C-1"),
+		run("pass", "D-1"),
+		run("pass", "E-1"),
+		run("fail", "E-2"),
+	})
+
+	assert.Equal(t, Unchanged, b.Compare(Run{Name: "A", Verdict: "pass"}))
+	assert.Equal(t, Regression, b.Compare(Run{Name: "B", Verdict: "fail"}))
+	assert.Equal(t, Unchanged, b.Compare(Run{Name: "C", Verdict: "error"}))
+	assert.Equal(t, Unchanged, b.Compare(Run{Name: "E", Verdict: "fail"}), "unstable in baseline")
+	assert.Equal(t, NewTest, b.Compare(Run{Name: "F", Verdict: "pass"}))
+	assert.Equal(t, Unchanged, b.Compare(Run{Name: "F", Verdict: "fail"}))
+	assert.Equal(t, []string{"D"}, b.Removed())
+
+	v, ok := b.Verdict("E")
+	assert.True(t, ok)
+	assert.Equal(t, UnstableVerdict, v)
+}
+
+func TestMerge(t *testing.T) {
+	a := &DB{Version: "1", Sessions: []Session{
+		{Id: "1", MaxJobs: 4, Runs: []Run{{Name: "A.a"}, {Name: "A.b"}}},
+	}}
+	b := &DB{Sessions: []Session{
+		{Id: "1", MaxJobs: 8, Runs: []Run{{Name: "B.a"}}},
+	}}
+
+	db, err := Merge(a, nil, b)
+	assert.Nil(t, err)
+	assert.Equal(t, "1", db.Version)
+	assert.Len(t, db.Sessions, 2)
+	assert.Equal(t, 8, db.MaxJobs())
+	var names []string
+	for _, r := range db.Runs() {
+		names = append(names, r.Name)
+	}
+	assert.Equal(t, []string{"A.a", "A.b", "B.a"}, names)
+
+	_, err = Merge(a, &DB{Version: "2"})
+	assert.ErrorContains(t, err, `versions "1" and "2"`)
+}
+
+func TestDiff(t *testing.T) {
+	db := func(verdicts ...string) *DB {
+		var runs []Run
+		for i := 0; i < len(verdicts); i += 2 {
+			runs = append(runs, Run{Name: verdicts[i], Verdict: Verdict(verdicts[i+1])})
+		}
+		return &DB{Sessions: []Session{{Runs: runs}}}
+	}
+	old := db("A", "pass", "B", "fail", "C", "error", "D", "pass", "E", "pass", "F", "done", "G", "pass")
+	new := db("A", "fail", "B", "pass", "C", "fail", "D", "pass", "F", "skipped", "G", "inconc", "H", "fail")
+
+	assert.Equal(t, VerdictDiff{
+		NewFailures:  []string{"A", "G"},
+		NewPasses:    []string{"B"},
+		StillFailing: []string{"C"},
+		Added:        []string{"H"},
+		Removed:      []string{"E"},
+	}, Diff(old, new))
+
+	assert.Equal(t, VerdictDiff{Added: []string{"A"}}, Diff(nil, db("A", "fail")))
+}
+
+func TestStats(t *testing.T) {
+	s := (&DB{}).Stats()
+	assert.Equal(t, 0, s.Total)
+	assert.Equal(t, 0.0, s.MeanDuration)
+	assert.Nil(t, s.Slowest)
+	assert.Nil(t, s.Flakiest)
+	_, err := json.Marshal(s)
+	assert.Nil(t, err)
+
+	run := func(name, verdict string, begin, end int64) Run {
+		return Run{Name: name, Verdict: Verdict(verdict), Begin: Timestamp{time.Unix(begin, 0)}, End: Timestamp{time.Unix(end, 0)}}
+	}
+	s = (&DB{Sessions: []Session{{Runs: []Run{run("A", "pass", 0, 2)}}}}).Stats()
+	assert.Equal(t, 1, s.Total)
+	assert.Equal(t, map[string]int{"pass": 1}, s.Verdicts)
+	assert.Equal(t, 2.0, s.TotalDuration)
+	assert.Equal(t, 2.0, s.MeanDuration)
+	assert.Equal(t, []Run{run("A", "pass", 0, 2)}, s.Slowest)
+	assert.Nil(t, s.Flakiest)
+
+	db := &DB{Sessions: []Session{{Runs: []Run{
+		run("A", "pass", 0, 1),
+		run("B", "pass", 0, 4),
+		run("A", "fail", 1, 2),
+		run("C", "pass", 0, 1),
+		run("C", "pass", 1, 2),
+		run("C", "error", 2, 3),
+		run("D", "done", 0, 1),
+		run("A", "skipped", 2, 2),
+	}}}}
+	s = db.Stats()
+	assert.Equal(t, 8, s.Total)
+	assert.Equal(t, 10.0, s.TotalDuration)
+	assert.Equal(t, 10.0/8, s.MeanDuration)
+	assert.Equal(t, "B", s.Slowest[0].Name)
+	assert.Equal(t, []FlakyTest{
+		{Name: "A", Runs: 2, Passed: 1, Failed: 1},
+		{Name: "C", Runs: 3, Passed: 2, Failed: 1},
+	}, s.Flakiest)
+}
+
+func TestStream(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "stream.jsonl")
+	s, err := CreateStream(file)
+	assert.Nil(t, err)
+	runs := []Run{run("pass", "a-1"), run("fail", "b-1"), run("none", "c-1")}
+	for _, r := range runs {
+		assert.Nil(t, s.Write(r))
+	}
+
+	// Written runs must be readable before the stream is closed.
+	b, err := os.ReadFile(file)
+	assert.Nil(t, err)
+	lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	assert.Equal(t, len(runs), len(lines))
+	for i, line := range lines {
+		var r Run
+		assert.Nil(t, json.Unmarshal([]byte(line), &r))
+		assert.Equal(t, runs[i].Name, r.Name)
+		assert.Equal(t, runs[i].Verdict, r.Verdict)
+	}
+	assert.Nil(t, s.Close())
+}
@@ -0,0 +1,119 @@
+package results
+
+import "sort"
+
+// StatsTop is the maximum number of slowest and flakiest tests reported by
+// Stats.
+var StatsTop = 10
+
+// Stats provides aggregate statistics of test results.
+type Stats struct {
+	Summary
+
+	// TotalDuration is the sum of the durations of all runs in seconds.
+	TotalDuration float64 `json:"total_duration"`
+
+	// MeanDuration is the mean duration of a run in seconds.
+	MeanDuration float64 `json:"mean_duration"`
+
+	// Slowest are the runs with the longest duration, longest first.
+	Slowest []Run `json:"slowest,omitempty"`
+
+	// Flakiest are the tests with passing and failing runs, flakiest
+	// first.
+	Flakiest []FlakyTest `json:"flakiest,omitempty"`
+}
+
+// A FlakyTest is a test with passing and failing runs.
+type FlakyTest struct {
+	Name   string `json:"name"`
+	Runs   int    `json:"runs"`
+	Passed int    `json:"passed"`
+	Failed int    `json:"failed"`
+}
+
+// Stats returns aggregate statistics of all runs of all sessions. At most
+// StatsTop slowest and flakiest tests are reported.
+func (db *DB) Stats() Stats {
+	runs := db.Runs()
+	s := Stats{
+		Summary:       Summarize(runs),
+		TotalDuration: Total(runs).Seconds(),
+		Slowest:       Slowest(runs, StatsTop),
+		Flakiest:      Flakiest(runs, StatsTop),
+	}
+	if len(runs) > 0 {
+		s.MeanDuration = s.TotalDuration / float64(len(runs))
+	}
+	return s
+}
+
+// Slowest returns the n runs with the longest duration, longest first. Ties
+// are broken by name.
+func Slowest(runs []Run, n int) []Run {
+	ret := append([]Run(nil), runs...)
+	sort.SliceStable(ret, func(i, j int) bool {
+		if di, dj := ret[i].Duration(), ret[j].Duration(); di != dj {
+			return di > dj
+		}
+		return ret[i].Name < ret[j].Name
+	})
+	if len(ret) > n {
+		ret = ret[:n]
+	}
+	return ret
+}
+
+// Flakiest returns at most n tests with passing and failing runs. Tests are
+// the flakier the more evenly their runs pass and fail. Ties are broken by
+// the number of runs, then by name. Runs with verdict done or skipped are not
+// counted.
+func Flakiest(runs []Run, n int) []FlakyTest {
+	index := make(map[string]int)
+	var tests []FlakyTest
+	for _, r := range runs {
+		v := NormalizeVerdict(string(r.Verdict))
+		if v == DoneVerdict || v == SkippedVerdict {
+			continue
+		}
+		i, ok := index[r.Name]
+		if !ok {
+			i = len(tests)
+			index[r.Name] = i
+			tests = append(tests, FlakyTest{Name: r.Name})
+		}
+		tests[i].Runs++
+		if v == PassVerdict {
+			tests[i].Passed++
+		} else {
+			tests[i].Failed++
+		}
+	}
+
+	var ret []FlakyTest
+	for _, t := range tests {
+		if t.Passed > 0 && t.Failed > 0 {
+			ret = append(ret, t)
+		}
+	}
+	flakiness := func(t FlakyTest) float64 {
+		min := t.Passed
+		if t.Failed < min {
+			min = t.Failed
+		}
+		return float64(min) / float64(t.Runs)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if fi, fj := flakiness(ret[i]), flakiness(ret[j]); fi != fj {
+			return fi > fj
+		}
+		if ret[i].Runs != ret[j].Runs {
+			return ret[i].Runs > ret[j].Runs
+		}
+		return ret[i].Name < ret[j].Name
+	})
+	if len(ret) > n {
+		ret = ret[:n]
+	}
+	return ret
+}
@@ -0,0 +1,53 @@
+package results
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// SyncInterval is the time after which a Stream syncs written runs to disk.
+var SyncInterval = 5 * time.Second
+
+// A Stream writes runs to a file as they complete, as JSON objects, one per
+// line (JSON Lines). Each run is written with a single write, hence the
+// file stays valid, when the process is killed. Runs are synced to disk
+// periodically, at most every SyncInterval.
+type Stream struct {
+	f        *os.File
+	lastSync time.Time
+}
+
+// CreateStream creates or truncates the named file for streaming runs.
+func CreateStream(name string) (*Stream, error) {
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &Stream{f: f, lastSync: time.Now()}, nil
+}
+
+// Write appends run r to the stream.
+func (s *Stream) Write(r Run) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if _, err := s.f.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	if time.Since(s.lastSync) >= SyncInterval {
+		s.lastSync = time.Now()
+		return s.f.Sync()
+	}
+	return nil
+}
+
+// Close syncs and closes the stream.
+func (s *Stream) Close() error {
+	if err := s.f.Sync(); err != nil {
+		s.f.Close()
+		return err
+	}
+	return s.f.Close()
+}
@@ -0,0 +1,121 @@
+package results
+
+import (
+	"sort"
+	"strings"
+)
+
+// Summary provides aggregate counts of a test run.
+type Summary struct {
+	// Total is the number of runs.
+	Total int `json:"total"`
+
+	// Verdicts maps each verdict to the number of runs with that verdict.
+	Verdicts map[string]int `json:"verdicts"`
+
+	// Worst is the worst verdict of all runs.
+	Worst string `json:"worst_verdict,omitempty"`
+
+	Begin Timestamp `json:"begin"` // When the first test was started
+	End   Timestamp `json:"end"`   // When the last test ended
+
+	// Duration in seconds between the first and the last test run.
+	Duration float64 `json:"duration"`
+
+	// Slow is the number of runs exceeding the soft duration limit.
+	Slow int `json:"slow,omitempty"`
+
+	// Modules breaks the counts down by module. It is only set on request.
+	Modules []ModuleSummary `json:"modules,omitempty"`
+
+	// Seed is the seed of the random job order, if the jobs were shuffled.
+	Seed *int64 `json:"seed,omitempty"`
+
+	// Shard is the shard I/N of the jobs, if the run was sharded.
+	Shard string `json:"shard,omitempty"`
+}
+
+// ModuleSummary provides aggregate counts of the runs of a single module.
+type ModuleSummary struct {
+	Module  string `json:"module"`
+	Total   int    `json:"total"`
+	Pass    int    `json:"pass"`
+	Fail    int    `json:"fail"` // Runs with verdict fail or error
+	Inconc  int    `json:"inconc"`
+	Unknown int    `json:"unknown"` // Runs with a verdict not understood
+
+	// Duration in seconds summed over all runs of the module.
+	Duration float64 `json:"duration"`
+}
+
+// Summarize returns the aggregate counts of the given runs.
+func Summarize(runs []Run) Summary {
+	s := Summary{
+		Total:    len(runs),
+		Verdicts: make(map[string]int),
+		Begin:    First(runs).Begin,
+		End:      Last(runs).End,
+		Duration: Duration(runs).Seconds(),
+	}
+	for i, r := range runs {
+		s.Verdicts[string(r.Verdict)]++
+		if i == 0 || severity(r.Verdict) > severity(Verdict(s.Worst)) {
+			s.Worst = string(r.Verdict)
+		}
+		if r.Slow {
+			s.Slow++
+		}
+	}
+	return s
+}
+
+// PassRate returns the percentage of runs with verdict pass. Runs of control
+// parts (verdict done) and skipped runs are not counted. PassRate returns 100
+// if there are no runs.
+func (s Summary) PassRate() float64 {
+	n := s.Total - s.Verdicts["done"] - s.Verdicts["skipped"]
+	if n <= 0 {
+		return 100
+	}
+	return float64(s.Verdicts["pass"]) / float64(n) * 100
+}
+
+// SummarizeModules returns the aggregate counts of the given runs per module.
+// The module is the first part of the qualified test name. The result is
+// sorted by number of failures, modules with most failures first.
+func SummarizeModules(runs []Run) []ModuleSummary {
+	index := make(map[string]int)
+	var ret []ModuleSummary
+	for _, r := range runs {
+		mod := ""
+		if i := strings.Index(r.Name, "."); i >= 0 {
+			mod = r.Name[:i]
+		}
+		i, ok := index[mod]
+		if !ok {
+			i = len(ret)
+			index[mod] = i
+			ret = append(ret, ModuleSummary{Module: mod})
+		}
+		m := &ret[i]
+		m.Total++
+		m.Duration += r.Duration().Seconds()
+		switch NormalizeVerdict(string(r.Verdict)) {
+		case PassVerdict:
+			m.Pass++
+		case InconcVerdict:
+			m.Inconc++
+		case FailVerdict, ErrorVerdict:
+			m.Fail++
+		case UnknownVerdict:
+			m.Unknown++
+		}
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		if ret[i].Fail != ret[j].Fail {
+			return ret[i].Fail > ret[j].Fail
+		}
+		return ret[i].Module < ret[j].Module
+	})
+	return ret
+}
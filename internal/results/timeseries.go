@@ -0,0 +1,108 @@
+package results
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Metrics which may be computed for a time series.
+var Metrics = []string{"passrate", "duration", "tests", "failures"}
+
+// A Point is the aggregate of a single session of a results history.
+type Point struct {
+	File    string    `json:"file,omitempty"`   // Results file the session was loaded from
+	Session string    `json:"session"`          // Session ID
+	Commit  string    `json:"commit,omitempty"` // Git commit of the test suite
+	Time    Timestamp `json:"time"`             // When the first test was started
+
+	Tests    int     `json:"tests"`     // Number of runs
+	Failures int     `json:"failures"`  // Runs with verdict fail, error or unknown
+	PassRate float64 `json:"pass_rate"` // Percentage of passed runs, see Summary.PassRate
+	Duration float64 `json:"duration"`  // Seconds between the first and the last run
+}
+
+// Metric returns the value of the named metric. See Metrics for valid names.
+func (p Point) Metric(name string) (float64, error) {
+	switch name {
+	case "passrate":
+		return p.PassRate, nil
+	case "duration":
+		return p.Duration, nil
+	case "tests":
+		return float64(p.Tests), nil
+	case "failures":
+		return float64(p.Failures), nil
+	}
+	return 0, fmt.Errorf("unknown metric %q: must be one of %v", name, Metrics)
+}
+
+// LoadHistory loads the results files found in the given paths. Directories
+// are searched recursively for files named like Filename. The returned map
+// is indexed by file path.
+func LoadHistory(paths ...string) (map[string]*DB, error) {
+	dbs := make(map[string]*DB)
+	for _, arg := range paths {
+		err := filepath.Walk(arg, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() || (path != arg && info.Name() != Filename) {
+				return nil
+			}
+			db, err := Load(path)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			dbs[path] = db
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return dbs, nil
+}
+
+// TimeSeries aggregates every session of the given results files into
+// a Point. Sessions are aggregated independently, hence the tests run may
+// vary from session to session. Sessions without runs are ignored. The points
+// are sorted by the start time of their sessions.
+func TimeSeries(dbs map[string]*DB) []Point {
+	var ret []Point
+	for file, db := range dbs {
+		for _, s := range db.Sessions {
+			if len(s.Runs) == 0 {
+				continue
+			}
+			sum := Summarize(s.Runs)
+			p := Point{
+				File:     file,
+				Session:  s.Id,
+				Commit:   s.Commit,
+				Time:     sum.Begin,
+				Tests:    sum.Total,
+				PassRate: sum.PassRate(),
+				Duration: sum.Duration,
+			}
+			for v, n := range sum.Verdicts {
+				switch NormalizeVerdict(v) {
+				case FailVerdict, ErrorVerdict, UnknownVerdict:
+					p.Failures += n
+				}
+			}
+			ret = append(ret, p)
+		}
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		if !ret[i].Time.Equal(ret[j].Time.Time) {
+			return ret[i].Time.Before(ret[j].Time.Time)
+		}
+		if ret[i].File != ret[j].File {
+			return ret[i].File < ret[j].File
+		}
+		return ret[i].Session < ret[j].Session
+	})
+	return ret
+}
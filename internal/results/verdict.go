@@ -0,0 +1,45 @@
+package results
+
+import "strings"
+
+// A Verdict is the canonical verdict of a test run.
+type Verdict string
+
+const (
+	NoneVerdict     Verdict = "none"
+	PassVerdict     Verdict = "pass"
+	InconcVerdict   Verdict = "inconc"
+	FailVerdict     Verdict = "fail"
+	ErrorVerdict    Verdict = "error"
+	DoneVerdict     Verdict = "done"     // control part finished
+	SkippedVerdict  Verdict = "skipped"  // test was not executed
+	UnstableVerdict Verdict = "unstable" // only some instances passed (see FinalVerdicts)
+	UnknownVerdict  Verdict = "unknown"  // verdict not understood
+)
+
+// NormalizeVerdict maps a verdict string reported by a test backend to the
+// canonical verdict. The mapping is case-insensitive and accepts common
+// synonyms, like "passed" or "inconclusive". An empty string maps to
+// NoneVerdict, unrecognized verdicts map to UnknownVerdict.
+func NormalizeVerdict(s string) Verdict {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "none", "":
+		return NoneVerdict
+	case "pass", "passed", "ok", "success":
+		return PassVerdict
+	case "inconc", "inconclusive":
+		return InconcVerdict
+	case "fail", "failed", "failure":
+		return FailVerdict
+	case "error", "fatal", "crash", "crashed":
+		return ErrorVerdict
+	case "done":
+		return DoneVerdict
+	case "skipped", "skip":
+		return SkippedVerdict
+	case "unstable":
+		return UnstableVerdict
+	default:
+		return UnknownVerdict
+	}
+}
@@ -0,0 +1,50 @@
+package results
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nokia/ntt/internal/log"
+)
+
+// RetryDelay is the time to wait before retrying a failed write.
+var RetryDelay = 500 * time.Millisecond
+
+// WriteFile writes data to the named file. A failed write is retried up to
+// retries times after a short delay. When the file still cannot be written,
+// data is written to a new file in the temporary directory instead.
+//
+// WriteFile returns the name of the file actually written. Results are only
+// lost, if both locations fail.
+func WriteFile(name string, data []byte, retries int) (string, error) {
+	return writeFile(os.WriteFile, name, data, retries)
+}
+
+func writeFile(write func(string, []byte, os.FileMode) error, name string, data []byte, retries int) (string, error) {
+	err := write(name, data, 0644)
+	for i := 0; err != nil && i < retries; i++ {
+		log.Debugf("writing %s failed: %s: retrying\n", name, err.Error())
+		time.Sleep(RetryDelay)
+		err = write(name, data, 0644)
+	}
+	if err == nil {
+		return name, nil
+	}
+
+	base := filepath.Base(name)
+	ext := filepath.Ext(base)
+	f, terr := os.CreateTemp("", fmt.Sprintf("ntt-%s-*%s", strings.TrimSuffix(base, ext), ext))
+	if terr != nil {
+		return "", err
+	}
+	f.Close()
+	if terr := write(f.Name(), data, 0644); terr != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	log.Printf("warning: writing %s failed: %s: results saved in %s\n", name, err.Error(), f.Name())
+	return f.Name(), nil
+}
@@ -34,6 +34,8 @@ Formatting Checks
     max_lines          Number of lines a behaviour body must not exceed.
     aligned_braces     Braces must be in the same column or same line.
     require_case_else  Every select-statement must have one case-else.
+    unreachable_code   Statements after return, stop, repeat, break, ... are
+                       reported as unreachable.
 
 
 Cyclomatic Complexity Checks
@@ -83,6 +85,7 @@ Example configuration file:
 
 	aligned_braces: true
 	require_case_else: true
+	unreachable_code: true
 	max_lines: 40
 
 	usage:
@@ -139,6 +142,7 @@ For information on writing new checks, see <TBD>.
 		MaxLines        int  `yaml:"max_lines"`
 		AlignedBraces   bool `yaml:"aligned_braces"`
 		RequireCaseElse bool `yaml:"require_case_else"`
+		UnreachableCode bool `yaml:"unreachable_code"`
 		Complexity      struct {
 			Max          int
 			IgnoreGuards bool `yaml:"ignore_guards"`
@@ -264,6 +268,10 @@ func lint(cmd *cobra.Command, args []string) error {
 						}
 
 						checkLines(n)
+						checkUnreachable(n, tree)
+
+					case *syntax.ControlPart:
+						checkUnreachable(n, tree)
 
 					case *syntax.FormalPar:
 						checkNaming(n, style.Naming.Parameters)
@@ -461,6 +469,15 @@ func checkLines(n syntax.Node) {
 
 }
 
+func checkUnreachable(n syntax.Node, tree *ttcn3.Tree) {
+	if !style.UnreachableCode {
+		return
+	}
+	for _, d := range ttcn3.UnreachableCode(&ttcn3.Definition{Node: n, Tree: tree}) {
+		reportError(&errUnreachable{def: n, node: d.Node})
+	}
+}
+
 func checkBraces(left syntax.Node, right syntax.Node) {
 	if !style.AlignedBraces {
 		return
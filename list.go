@@ -3,13 +3,13 @@ package main
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 
-	"github.com/nokia/ntt/internal/fs"
+	"github.com/nokia/ntt/internal/log"
 	"github.com/nokia/ntt/project"
 	"github.com/nokia/ntt/ttcn3"
-	"github.com/nokia/ntt/ttcn3/doc"
 	"github.com/nokia/ntt/ttcn3/syntax"
 	"github.com/spf13/cobra"
 )
@@ -89,6 +89,8 @@ variable NTT_LIST_BASKETS. If you specify multiple baskets, at least of them
 must match (OR).
 
 Rule of thumb: all baskets are ORed, all explicit filter options are ANDed.
+Hence exclusion filters (--exclude, --tags-exclude, not) take precedence over
+any inclusion filter of the same basket.
 Example:
 
 	$ export NTT_LIST_BASKETS_stable="--tags-exclude @wip|@flaky"
@@ -109,6 +111,51 @@ If a basket is not defined by an environment variable, it's equivalent to a
 	# This does the same:
 	$ ntt list --tags-regex="@wip|@flaky"
 
+
+Compound conditions on tags are expressed by boolean tag expressions with the
+operators and, or, not and parentheses. A list of tags without operators
+must match all tags. A ~pattern atom matches the qualified name of the object
+against a regular expression. Expressions may be given with --basket, as basket
+definition or listed in NTT_LIST_BASKETS directly:
+
+	$ ntt list --basket="@stable and not (@slow or @flaky)"
+	$ ntt list --basket="~^ipv6\.(TC|TP)_ and not @wip"
+
+	$ export NTT_LIST_BASKETS_fast="@stable and not @slow"
+	$ NTT_LIST_BASKETS="fast:@ipv6 @smoke" ntt list
+
+
+Baskets may also be defined in a YAML file, which maps basket names to
+definitions. Definitions of the file take precedence over definitions from
+environment or configuration. Explicit filters like --basket always apply:
+
+	$ cat baskets.yml
+	fast: "@stable and not @slow"
+	ipv6: --tags-regex @ipv6
+	$ NTT_LIST_BASKETS=fast:ipv6 ntt list --basket-file baskets.yml
+
+
+Subtests
+--------
+
+Data-driven testcases may describe the data set they iterate over with
+a @subtests tag. The tag names a module parameter and a comma separated list of
+values:
+
+	// @subtests: PX_RATE = 10, 20, 30
+	testcase TC_throughput() runs on C { ... }
+
+With --include-subtests each value is listed as a separate test:
+
+	$ ntt list --include-subtests
+	example.TC_throughput[PX_RATE=10]
+	example.TC_throughput[PX_RATE=20]
+	example.TC_throughput[PX_RATE=30]
+
+These identifiers may be passed to ntt run, which sets the module parameter
+(here example.PX_RATE) accordingly. Unqualified parameter names refer to the
+module of the testcase. Values must not contain commas.
+
 `,
 
 		// Listing tests is the default command
@@ -117,11 +164,12 @@ If a basket is not defined by an environment variable, it's equivalent to a
 
 	w = bufio.NewWriter(os.Stdout)
 
-	showFiles   = false
-	showTags    = false
-	formatJSON  = false
-	formatPlain = true
-	first       = true
+	showFiles      = false
+	showTags       = false
+	formatJSON     = false
+	excludeImports = false
+	formatPlain    = true
+	first          = true
 )
 
 func init() {
@@ -130,6 +178,8 @@ func init() {
 	flags.BoolVar(&showTags, "with-tags", false, "Print documentation tags for each match.")
 	flags.BoolVarP(&showTags, "tags", "t", false, "Print documentation tags for each match.")
 	flags.MarkDeprecated("tags", "please use --with-tags instead")
+	flags.BoolVar(&excludeImports, "exclude-imports", false, "do not list tests located in import directories")
+	flags.BoolVar(&IncludeSubtests, "include-subtests", false, "list each value of a @subtests tag as a separate test")
 	flags.AddFlagSet(BasketFlags())
 	ListCommand.AddCommand(
 		&cobra.Command{Use: `tests`, RunE: list},
@@ -142,6 +192,9 @@ func init() {
 }
 
 func list(cmd *cobra.Command, args []string) error {
+	if excludeImports {
+		Project.ExcludeImports = true
+	}
 
 	basket, err := NewBasketWithFlags("list", cmd.Flags())
 	basket.LoadFromEnvOrConfig(Project, "NTT_LIST_BASKETS")
@@ -159,8 +212,12 @@ func list(cmd *cobra.Command, args []string) error {
 	files, err := filesOfInterest(cmd.Use, Project)
 	for _, f := range files {
 		tree := ttcn3.ParseFile(f)
+		if tree.Root == nil {
+			log.Printf("warning: skipping %s: %s\n", f, tree.Err)
+			continue
+		}
 		if tree.Err != nil {
-			return tree.Err
+			return errors.New(ttcn3.FormatError(tree.Err))
 		}
 
 		var module string
@@ -170,25 +227,38 @@ func list(cmd *cobra.Command, args []string) error {
 				case *syntax.Module:
 					module = syntax.Name(n.Name)
 					if cmd.Use == "modules" {
-						Print(basket, n, module)
+						Print(basket, tree, n, module)
 						return false
 					}
 					return true
 				case *syntax.FuncDecl:
 					if n.IsTest() && (cmd.Use == "list" || cmd.Use == "tests") {
-						Print(basket, n, module+"."+n.Name.String())
+						name := module + "." + n.Name.String()
+						if IncludeSubtests {
+							subtests, err := ttcn3.Subtests(name, tree.DocTags(n))
+							if err != nil {
+								log.Printf("warning: %s\n", err.Error())
+							}
+							for _, sub := range subtests {
+								Print(basket, tree, n, sub.ID())
+							}
+							if len(subtests) > 0 {
+								return false
+							}
+						}
+						Print(basket, tree, n, name)
 					}
 				case *syntax.ImportDecl:
 					if cmd.Use == "imports" {
-						Print(basket, n, fmt.Sprintf("%s\t%s", module, n.Module.String()))
+						Print(basket, tree, n, fmt.Sprintf("%s\t%s", module, n.Module.String()))
 					}
 				case *syntax.ControlPart:
 					if cmd.Use == "controls" {
-						Print(basket, n, module+".control")
+						Print(basket, tree, n, module+".control")
 					}
 				case *syntax.Declarator:
 					if cmd.Use == "modulepars" {
-						Print(basket, n, module+"."+n.Name.String())
+						Print(basket, tree, n, module+"."+n.Name.String())
 					}
 				case *syntax.ValueDecl:
 					if n.Kind == nil && n.Kind.Kind() == syntax.MODULEPAR {
@@ -233,8 +303,8 @@ func (t Tag) MarshalJSON() ([]byte, error) {
 	return json.Marshal(t.String())
 }
 
-func Print(basket Basket, n syntax.Node, id string) {
-	tags := doc.FindAllTags(syntax.Doc(n))
+func Print(basket Basket, tree *ttcn3.Tree, n syntax.Node, id string) {
+	tags := tree.DocTags(n)
 	if !basket.Match(id, tags) {
 		return
 	}
@@ -294,7 +364,7 @@ func PrintMatch(filename string, pos syntax.Position, id string, tags ...string)
 func filesOfInterest(cmd string, conf *project.Config) ([]string, error) {
 	switch cmd {
 	case "tests", "controls", "list":
-		return fs.TTCN3Files(conf.Sources...)
+		return project.TestFiles(conf)
 	default:
 		return project.Files(conf)
 	}
@@ -58,19 +58,40 @@ var (
 			}
 
 			// Skip opening the project if we're running a custom command or version.
-			if cmd.Use == "ntt" || cmd.Use == "version" || cmd.Use == "stdout" || strings.HasPrefix(cmd.Use, "help") || cmd.Use == "docs" || cmd.Use == "objdump" || cmd.Use == "t3xfasm" {
+			if cmd.Use == "ntt" || cmd.Use == "version" || cmd.Use == "stdout" || strings.HasPrefix(cmd.Use, "help") || cmd.Use == "docs" || cmd.Use == "objdump" || cmd.Use == "t3xfasm" || cmd.Use == "anomalies" || cmd.Use == "merge" || cmd.Use == "diff" || cmd.Parent() == BasketCommand || (cmd.Use == "parse" || cmd.Use == "fmt") && len(args) == 1 && args[0] == "-" {
 				// first arg is either an external subkommand of the form
 				// k3-Arg[0] or ntt-Arg[0] or unknown
 				return nil
 			}
 
+			var opts []project.ConfigOption
+			if k3AuxAppend {
+				opts = append(opts, project.AppendK3Includes())
+			}
+
+			// Overrides are applied before the configuration is
+			// derived, like settings of the manifest.
+			for _, kv := range configOverrides {
+				f := strings.SplitN(kv, "=", 2)
+				if len(f) != 2 {
+					return fmt.Errorf("config override %q: expected key=value", kv)
+				}
+				opts = append(opts, project.WithOverride(f[0], f[1]))
+			}
+
 			files, _ := splitArgs(args, cmd.ArgsLenAtDash())
-			p, err := project.Open(files...)
+			p, err := project.OpenWith(opts, files...)
 			if err != nil {
 				return err
 			}
 			Project = p
 
+			if strictConfig {
+				if err := Project.Validate(); err != nil {
+					return err
+				}
+			}
+
 			return nil
 		},
 
@@ -97,15 +118,20 @@ var (
 		},
 	}
 
-	verbose        int
-	ShSetup        bool
-	outputQuiet    bool
-	outputJSON     bool
-	outputPlain    bool
-	outputProgress bool
-	outputTAP      bool
-	testsFiles     []string
-	chdir          string
+	verbose         int
+	ShSetup         bool
+	showResolved    bool
+	outputQuiet     bool
+	outputJSON      bool
+	outputPlain     bool
+	outputProgress  bool
+	outputTAP       bool
+	outputJUnit     bool
+	testsFiles      []string
+	chdir           string
+	configOverrides []string
+	k3AuxAppend     bool
+	strictConfig    bool
 
 	version = "dev"
 	commit  = "none"
@@ -124,11 +150,16 @@ func init() {
 	flags.BoolVarP(&outputJSON, "json", "", false, "output in JSON format")
 	flags.BoolVarP(&outputPlain, "plain", "", false, "output in plain format (for grep and awk)")
 	RunCommand.PersistentFlags().BoolVarP(&outputTAP, "tap", "", false, "output in test anything (TAP) format")
+	RunCommand.PersistentFlags().BoolVarP(&outputJUnit, "junit", "", false, "output in JUnit XML format (written to junit.xml in the output directory, if given)")
 	flags.StringVarP(&cpuprofile, "cpuprofile", "", "", "write cpu profile to `file`")
 	flags.StringVarP(&chdir, "chdir", "C", "", "change to DIR before doing anything else")
+	flags.BoolVar(&k3AuxAppend, "k3-aux-append", false, "append k3_includes (or NTT_K3_INCLUDES) to the discovered k3 include directories instead of replacing them")
+	flags.BoolVar(&strictConfig, "strict-config", false, "fail if the test suite configuration has problems, like missing sources or hooks files")
+	flags.StringArrayVar(&configOverrides, "config-override", nil, "override configuration value KEY=VALUE, for example timeout=10 or k3.runtime=/path/to/k3r (see ntt show)")
 
 	RootCommand.Flags().BoolP("interactive", "i", false, "run in interactive mode")
 
+	root.AddCommand(BasketCommand)
 	root.AddCommand(BuildCommand)
 	root.AddCommand(CompileCommand)
 	root.AddCommand(DumpCommand)
@@ -137,14 +168,19 @@ func init() {
 	root.AddCommand(LintCommand)
 	root.AddCommand(ListCommand)
 	root.AddCommand(LocateFileCommand)
+	root.AddCommand(MetricsCommand)
 	root.AddCommand(ReportCommand)
 	root.AddCommand(RunCommand)
 	root.AddCommand(ShowCommand)
 	root.AddCommand(TagsCommand)
 	root.AddCommand(ObjdumpCommand)
+	root.AddCommand(ParseCommand)
+	root.AddCommand(ParamsCommand)
 	root.AddCommand(T3xfasmCommand)
 
 	ShowCommand.PersistentFlags().BoolVarP(&ShSetup, "sh", "", false, "output test suite data for shell consumption")
+	ShowCommand.PersistentFlags().BoolVarP(&showResolved, "resolved", "", false, "output all variables and module parameters after expansion")
+	ShowCommand.PersistentFlags().StringSliceVar(&SecretVars, "secret-pattern", secretPatterns, "redact values of --resolved variables with names matching regular expression")
 }
 
 func Format() string {
@@ -159,6 +195,8 @@ func Format() string {
 		return "progress"
 	case outputTAP:
 		return "tap"
+	case outputJUnit:
+		return "junit"
 	case outputTTCN3:
 		return "ttcn3"
 	case outputDot:
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/nokia/ntt/project"
+	"github.com/nokia/ntt/ttcn3"
+	"github.com/nokia/ntt/ttcn3/syntax"
+	"github.com/spf13/cobra"
+)
+
+var MetricsCommand = &cobra.Command{
+	Use:   "metrics",
+	Short: "Show code metrics of the test suite",
+	Long: `Show code metrics of the test suite.
+
+The metrics command lists every function, testcase, altstep and control part
+of the test suite sources with its cyclomatic complexity, most complex
+definitions first. External functions are not listed.
+
+The cyclomatic complexity is one plus the number of decision points: if
+statements (including every "else if"), loops, select cases except "case
+else" and alternatives except "[else]".
+
+Use --json for machine readable output.
+`,
+	RunE: metrics,
+}
+
+// metric describes the code metrics of a single definition.
+type metric struct {
+	Name       string `json:"name"`
+	Kind       string `json:"kind"`
+	Filename   string `json:"filename"`
+	Line       int    `json:"line"`
+	Complexity int    `json:"complexity"`
+}
+
+func metrics(cmd *cobra.Command, args []string) error {
+	files, err := project.Files(Project)
+	if err != nil {
+		return err
+	}
+
+	ms := collectMetrics(files)
+	sort.SliceStable(ms, func(i, j int) bool {
+		return ms[i].Complexity > ms[j].Complexity
+	})
+
+	if outputJSON {
+		b, err := json.MarshalIndent(ms, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+		return nil
+	}
+	for _, m := range ms {
+		fmt.Printf("%d\t%s\t%s:%d\n", m.Complexity, m.Name, m.Filename, m.Line)
+	}
+	return nil
+}
+
+// collectMetrics parses the given files concurrently and returns the metrics
+// of all definitions in file order.
+func collectMetrics(files []string) []metric {
+	ms := make([][]metric, len(files))
+	var wg sync.WaitGroup
+	wg.Add(len(files))
+	for i, file := range files {
+		go func(i int, file string) {
+			defer wg.Done()
+			tree := ttcn3.ParseFile(file)
+			if tree.Root == nil {
+				return
+			}
+			add := func(n syntax.Node, name string, kind string) {
+				p := syntax.Begin(n)
+				ms[i] = append(ms[i], metric{
+					Name:       name,
+					Kind:       kind,
+					Filename:   syntax.Filename(n),
+					Line:       p.Line,
+					Complexity: ttcn3.Complexity(&ttcn3.Definition{Node: n, Tree: tree}),
+				})
+			}
+			tree.Inspect(func(n syntax.Node) bool {
+				switch n := n.(type) {
+				case *syntax.FuncDecl:
+					if n.Body != nil {
+						add(n, tree.QualifiedName(n), n.Kind.String())
+					}
+					return false
+				case *syntax.ControlPart:
+					add(n, tree.QualifiedName(n), "control")
+					return false
+				}
+				return true
+			})
+		}(i, file)
+	}
+	wg.Wait()
+
+	var ret []metric
+	for _, m := range ms {
+		ret = append(ret, m...)
+	}
+	return ret
+}
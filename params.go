@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/nokia/ntt/internal/fs"
+	"github.com/nokia/ntt/internal/yaml"
+	"github.com/nokia/ntt/project"
+	"github.com/nokia/ntt/ttcn3"
+	"github.com/spf13/cobra"
+)
+
+var (
+	ParamsCommand = &cobra.Command{
+		Use:   "params",
+		Short: "Work with module parameters and parameters files",
+	}
+
+	ParamsScaffoldCommand = &cobra.Command{
+		Use:   "scaffold",
+		Short: "Generate a parameters file skeleton",
+		Long: `Generate a parameters file skeleton.
+
+The scaffold command scans sources and imports of the test suite for module
+parameters and writes a YAML parameters file listing every module parameter,
+grouped by module. The type of each parameter is written as a comment above
+its entry and the default value, if any, becomes the value. Parameters without
+default value are written commented out.
+
+Use --output to write the skeleton into a file instead of standard output.
+
+With --merge the existing parameters file (--output or the parameters file of
+the test suite) is read first. Its values replace the defaults and parameters
+not declared by the test suite are kept. Other configuration, like presets and
+execute rules, is preserved, but comments are not.
+`,
+		RunE: paramsScaffold,
+	}
+
+	paramsOutput string
+	paramsMerge  bool
+)
+
+func init() {
+	ParamsScaffoldCommand.Flags().StringVarP(&paramsOutput, "output", "o", "", "write parameters file to `file`")
+	ParamsScaffoldCommand.Flags().BoolVar(&paramsMerge, "merge", false, "preserve values of an existing parameters file")
+	ParamsCommand.AddCommand(ParamsScaffoldCommand)
+}
+
+func paramsScaffold(cmd *cobra.Command, args []string) error {
+	files, err := project.Files(Project)
+	if err != nil {
+		return err
+	}
+
+	var existing project.Parameters
+	if paramsMerge {
+		file := paramsOutput
+		if file == "" {
+			file = Project.ParametersFile
+		}
+		if file != "" {
+			b, err := fs.Content(file)
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			if err := yaml.Unmarshal(b, &existing); err != nil {
+				return fmt.Errorf("%s: %w", file, err)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := scaffoldParameters(&buf, collectModulePars(files), existing); err != nil {
+		return err
+	}
+
+	if paramsOutput == "" {
+		_, err := os.Stdout.Write(buf.Bytes())
+		return err
+	}
+	return os.WriteFile(paramsOutput, buf.Bytes(), 0644)
+}
+
+// scaffoldPar is a module parameter qualified by its module name.
+type scaffoldPar struct {
+	Module string
+	ttcn3.ModulePar
+}
+
+// collectModulePars parses the given files concurrently and returns all
+// module parameters in file order.
+func collectModulePars(files []string) []scaffoldPar {
+	pars := make([][]scaffoldPar, len(files))
+	var wg sync.WaitGroup
+	wg.Add(len(files))
+	for i, file := range files {
+		go func(i int, file string) {
+			defer wg.Done()
+			tree := ttcn3.ParseFile(file)
+			if tree.Root == nil {
+				return
+			}
+			for _, p := range tree.ModulePars() {
+				mod := ""
+				if m := tree.ModuleOf(p.Node); m != nil {
+					mod = m.Name.String()
+				}
+				pars[i] = append(pars[i], scaffoldPar{Module: mod, ModulePar: p})
+			}
+		}(i, file)
+	}
+	wg.Wait()
+
+	var ret []scaffoldPar
+	for _, p := range pars {
+		ret = append(ret, p...)
+	}
+	return ret
+}
+
+// scaffoldParameters writes a parameters file with the given module
+// parameters to w. Values of existing replace the default values.
+func scaffoldParameters(w io.Writer, pars []scaffoldPar, existing project.Parameters) error {
+	values := existing.Parameters
+	existing.Parameters = nil
+
+	// Preserve presets, execute rules, ... of the existing file.
+	if !reflect.DeepEqual(existing, project.Parameters{}) {
+		b, err := yaml.Marshal(existing)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\n\n", strings.TrimSpace(string(b)))
+	}
+
+	byModule := make(map[string][]scaffoldPar)
+	seen := make(map[string]bool)
+	for _, p := range pars {
+		name := ttcn3.JoinNames(p.Module, p.Name)
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		byModule[p.Module] = append(byModule[p.Module], p)
+	}
+	modules := make([]string, 0, len(byModule))
+	for m := range byModule {
+		modules = append(modules, m)
+	}
+	sort.Strings(modules)
+
+	fmt.Fprintln(w, "parameters:")
+	for _, m := range modules {
+		fmt.Fprintf(w, "\n  # Module %s\n", m)
+		for _, p := range byModule[m] {
+			name := ttcn3.JoinNames(p.Module, p.Name)
+			fmt.Fprintf(w, "  # %s\n", p.Type)
+			if v, ok := values[name]; ok {
+				writeParameter(w, name, v)
+				continue
+			}
+			if p.Default == "" {
+				fmt.Fprintf(w, "  # %s:\n", name)
+				continue
+			}
+			writeParameter(w, name, p.Default)
+		}
+	}
+
+	var unknown []string
+	for name := range values {
+		if !seen[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		fmt.Fprintf(w, "\n  # Not declared in the test suite\n")
+		for _, name := range unknown {
+			writeParameter(w, name, values[name])
+		}
+	}
+	return nil
+}
+
+// writeParameter writes a single parameters entry. Values, which would not
+// survive a YAML round trip as plain scalar (strings with quotes, multi-line
+// records, ...), are written as literal block.
+func writeParameter(w io.Writer, name string, value string) {
+	line := fmt.Sprintf("%s: %s", name, value)
+	var m map[string]string
+	if err := yaml.Unmarshal([]byte(line), &m); err == nil && m[name] == value {
+		fmt.Fprintf(w, "  %s\n", line)
+		return
+	}
+
+	chomp := "-"
+	if strings.HasSuffix(value, "\n") {
+		chomp = ""
+	}
+	fmt.Fprintf(w, "  %s: |%s\n", name, chomp)
+	for _, l := range strings.Split(strings.TrimSuffix(value, "\n"), "\n") {
+		fmt.Fprintf(w, "    %s\n", l)
+	}
+}
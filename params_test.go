@@ -0,0 +1,77 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nokia/ntt/internal/yaml"
+	"github.com/nokia/ntt/project"
+	"github.com/nokia/ntt/ttcn3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScaffoldParameters(t *testing.T) {
+	tree := ttcn3.Parse(`
+		module B {
+			modulepar integer PX_A := 1, PX_B;
+			modulepar charstring PX_C := "foo";
+			modulepar R PX_D := {
+				x := 1
+			}
+		}
+		module A { modulepar boolean PX_E := true }`)
+
+	var pars []scaffoldPar
+	for _, p := range tree.ModulePars() {
+		pars = append(pars, scaffoldPar{Module: tree.ModuleOf(p.Node).Name.String(), ModulePar: p})
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		var b strings.Builder
+		assert.Nil(t, scaffoldParameters(&b, pars, project.Parameters{}))
+		assert.Equal(t, `parameters:
+
+  # Module A
+  # boolean
+  A.PX_E: true
+
+  # Module B
+  # integer
+  B.PX_A: 1
+  # integer
+  # B.PX_B:
+  # charstring
+  B.PX_C: |-
+    "foo"
+  # R
+  B.PX_D: |-
+    {
+    				x := 1
+    			}
+`, b.String())
+
+		var p project.Parameters
+		assert.Nil(t, yaml.Unmarshal([]byte(b.String()), &p))
+		assert.Equal(t, `"foo"`, p.Parameters["B.PX_C"])
+	})
+
+	t.Run("merge", func(t *testing.T) {
+		var existing project.Parameters
+		assert.Nil(t, yaml.Unmarshal([]byte(`
+timeout: 5
+parameters:
+  B.PX_B: 23
+  X.PX_X: 42
+`), &existing))
+
+		var b strings.Builder
+		assert.Nil(t, scaffoldParameters(&b, pars, existing))
+
+		var p project.Parameters
+		assert.Nil(t, yaml.Unmarshal([]byte(b.String()), &p))
+		assert.Equal(t, 5.0, p.Timeout.Seconds())
+		assert.Equal(t, "23", p.Parameters["B.PX_B"])
+		assert.Equal(t, "42", p.Parameters["X.PX_X"])
+		assert.Equal(t, "1", p.Parameters["B.PX_A"])
+	})
+}
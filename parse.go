@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/nokia/ntt/internal/fs"
+	"github.com/nokia/ntt/ttcn3"
+	"github.com/nokia/ntt/ttcn3/syntax"
+	"github.com/spf13/cobra"
+)
+
+var (
+	ParseCommand = &cobra.Command{
+		Use:   "parse",
+		Short: "Parse TTCN-3 files and report syntax errors",
+		Long: `Parse TTCN-3 files and report syntax errors.
+
+The parse command parses the given files (or the sources of the test suite) and
+reports all syntax errors with their positions. Use '-' to read the list of
+files from standard input, one file per line.
+
+With --check the command exits with a non-zero exit code if any file has syntax
+errors. This is useful as fast pre-commit check, because nothing is built or
+executed.
+
+With --stats the parser's error recovery statistics are printed for each file
+with syntax errors, worst file first: the number of times the parser had to
+recover from a syntax error and the number of tokens it skipped doing so.
+These numbers tell how broken a file is.
+
+With --dump-ast the syntax tree of a single definition is printed instead of
+syntax errors. The definition is selected by its qualified name using --id:
+
+    ntt parse --dump-ast --id example.TC_A example.ttcn3
+
+The output shows the kind and span ([begin:end) byte offsets) of every node and
+the literal of every token. Use --format=json for machine readable output, which
+has the same schema as the output of 'ntt dump --format=json'.
+`,
+		RunE: parseFiles,
+	}
+
+	parseCheck   bool
+	parseStats   bool
+	parseDumpAST bool
+	parseID      string
+	parseFormat  string
+)
+
+func init() {
+	ParseCommand.Flags().BoolVar(&parseCheck, "check", false, "exit with non-zero exit code if any file has errors")
+	ParseCommand.Flags().BoolVar(&parseStats, "stats", false, "print error recovery statistics per file")
+	ParseCommand.Flags().BoolVar(&parseDumpAST, "dump-ast", false, "print the syntax tree of the definition given by --id")
+	ParseCommand.Flags().StringVar(&parseID, "id", "", "qualified name of the definition to dump (e.g. example.TC_A)")
+	ParseCommand.Flags().StringVar(&parseFormat, "format", "tree", "output format of --dump-ast: json or tree")
+}
+
+func parseFiles(cmd *cobra.Command, args []string) error {
+	if parseDumpAST {
+		if parseID == "" {
+			return fmt.Errorf("--dump-ast requires --id")
+		}
+		if parseFormat != "json" && parseFormat != "tree" {
+			return fmt.Errorf("invalid format %q: must be json or tree", parseFormat)
+		}
+	} else if parseID != "" {
+		return fmt.Errorf("--id requires --dump-ast")
+	}
+
+	var (
+		files []string
+		err   error
+	)
+	if len(args) == 1 && args[0] == "-" {
+		s := bufio.NewScanner(os.Stdin)
+		for s.Scan() {
+			if line := strings.TrimSpace(s.Text()); line != "" {
+				files = append(files, line)
+			}
+		}
+		if err := s.Err(); err != nil {
+			return err
+		}
+	} else {
+		paths := Project.Sources
+		if len(args) > 0 {
+			paths, _ = splitArgs(args, cmd.ArgsLenAtDash())
+		}
+		files, err = fs.TTCN3Files(paths...)
+		if err != nil {
+			return err
+		}
+	}
+
+	trees := make([]*ttcn3.Tree, len(files))
+	var wg sync.WaitGroup
+	wg.Add(len(files))
+	for i := range files {
+		go func(i int) {
+			defer wg.Done()
+			trees[i] = ttcn3.ParseFile(files[i])
+		}(i)
+	}
+	wg.Wait()
+
+	if parseDumpAST {
+		defs, err := findDefinitions(parseID, trees)
+		if err != nil {
+			return err
+		}
+		if parseFormat == "json" {
+			b, err := marshalDefinitions(defs)
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(b))
+			return nil
+		}
+		for _, def := range defs {
+			fmt.Fprintf(w, "%s:\n", def.Tree.Filename())
+			dumpAST(0, parseID, reflect.ValueOf(def.Node))
+		}
+		return w.Flush()
+	}
+
+	failed, errs := 0, 0
+	for i, tree := range trees {
+		err := tree.Err
+		if err == nil {
+			continue
+		}
+		failed++
+		errs += countErrors(err)
+		msg := ttcn3.FormatError(err)
+		if !strings.HasPrefix(msg, files[i]) {
+			msg = files[i] + ": " + msg
+		}
+		fmt.Fprintln(os.Stderr, msg)
+	}
+
+	if parseStats {
+		printRecoveryStats(files, trees)
+	}
+
+	fmt.Fprintf(os.Stderr, "%d file(s) checked, %d syntax error(s) in %d file(s)\n", len(files), errs, failed)
+	if parseCheck && errs > 0 {
+		return fmt.Errorf("%w: %d syntax error(s)", ErrCommandFailed, errs)
+	}
+	return nil
+}
+
+// countErrors returns the number of errors err consists of.
+func countErrors(err error) int {
+	var merr *multierror.Error
+	if errors.As(err, &merr) {
+		return len(merr.Errors)
+	}
+	return 1
+}
+
+// printRecoveryStats prints the error recovery statistics of all files needing
+// recovery, most skipped tokens first.
+func printRecoveryStats(files []string, trees []*ttcn3.Tree) {
+	type fileStats struct {
+		file string
+		syntax.RecoveryStats
+	}
+	var stats []fileStats
+	for i, tree := range trees {
+		if tree.Root == nil || tree.RecoveryStats == (syntax.RecoveryStats{}) {
+			continue
+		}
+		stats = append(stats, fileStats{file: files[i], RecoveryStats: tree.RecoveryStats})
+	}
+	sort.SliceStable(stats, func(i, j int) bool {
+		if stats[i].SkippedTokens != stats[j].SkippedTokens {
+			return stats[i].SkippedTokens > stats[j].SkippedTokens
+		}
+		return stats[i].Points > stats[j].Points
+	})
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "POINTS\tSKIPPED\tFILE")
+	for _, s := range stats {
+		fmt.Fprintf(w, "%d\t%d\t%s\n", s.Points, s.SkippedTokens, s.file)
+	}
+	w.Flush()
+}
+
+// findDefinitions returns the definitions with qualified name id.
+func findDefinitions(id string, trees []*ttcn3.Tree) ([]*ttcn3.Node, error) {
+	var defs []*ttcn3.Node
+	for _, tree := range trees {
+		for _, mod := range tree.Modules() {
+			if mod.Ident.String() != ttcn3.ModuleName(id) {
+				continue
+			}
+			for _, def := range ttcn3.Definitions(strings.TrimPrefix(id, mod.Ident.String()+"."), mod.Node, tree) {
+				if _, ok := def.Node.(*syntax.ImportDecl); !ok {
+					defs = append(defs, def)
+				}
+			}
+		}
+	}
+	if len(defs) == 0 {
+		return nil, fmt.Errorf("definition %q not found", id)
+	}
+	return defs, nil
+}
+
+// marshalDefinitions returns the syntax trees of defs as indented JSON array
+// (see syntax.MarshalJSON).
+func marshalDefinitions(defs []*ttcn3.Node) ([]byte, error) {
+	nodes := make([]json.RawMessage, 0, len(defs))
+	for _, def := range defs {
+		b, err := syntax.MarshalJSON(def.Node)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, b)
+	}
+	return json.MarshalIndent(nodes, "", "  ")
+}
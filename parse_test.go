@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/nokia/ntt/ttcn3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDumpDefinition(t *testing.T) {
+	trees := []*ttcn3.Tree{
+		ttcn3.ParseBytes("a.ttcn3", []byte(`module m { import from x all; const integer c := 1 }`)),
+		ttcn3.ParseBytes("b.ttcn3", []byte(`module n { const integer c := 2 }`)),
+	}
+
+	_, err := findDefinitions("m.missing", trees)
+	assert.ErrorContains(t, err, `definition "m.missing" not found`)
+
+	defs, err := findDefinitions("m.c", trees)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := marshalDefinitions(defs)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `[{"type": "ValueDecl", "pos": 30, "end": 50, "children": [
+		{"type": "Token", "kind": "const", "text": "const", "pos": 30, "end": 35},
+		{"type": "Ident", "pos": 36, "end": 43, "children": [
+			{"type": "Token", "kind": "IDENT", "text": "integer", "pos": 36, "end": 43}]},
+		{"type": "Declarator", "pos": 44, "end": 50, "children": [
+			{"type": "Ident", "pos": 44, "end": 45, "children": [
+				{"type": "Token", "kind": "IDENT", "text": "c", "pos": 44, "end": 45}]},
+			{"type": "Token", "kind": ":=", "text": ":=", "pos": 46, "end": 48},
+			{"type": "ValueLiteral", "pos": 49, "end": 50, "children": [
+				{"type": "Token", "kind": "INT", "text": "1", "pos": 49, "end": 50}]}]}]}]`, string(b))
+}
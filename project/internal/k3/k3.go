@@ -462,8 +462,9 @@ func NewPlugin(vars map[string]string, name string, srcs ...string) []*proc.Cmd
 	return []*proc.Cmd{p}
 }
 
-// NewT3XF returns the commands for building a T3XF.
-func NewT3XF(vars map[string]string, t3xf string, srcs []string, imports ...string) []*proc.Cmd {
+// NewT3XF returns the commands for building a T3XF. includes are the TTCN-3
+// include directories of k3, usually those returned by Includes.
+func NewT3XF(vars map[string]string, t3xf string, includes []string, srcs []string, imports ...string) []*proc.Cmd {
 	if vars == nil {
 		vars = make(map[string]string)
 		for k, v := range DefaultEnv {
@@ -476,9 +477,9 @@ func NewT3XF(vars map[string]string, t3xf string, srcs []string, imports ...stri
 
 	// We need to remove k3 stdlib files from the source list, (if accidentally
 	// inserted by the user) because of a missing module (PCMDmod).
-	vars["_sources"] = strings.Join(removeStdlib(srcs), " ")
+	vars["_sources"] = strings.Join(removeStdlib(includes, srcs), " ")
 
-	for _, dir := range k3.Includes {
+	for _, dir := range includes {
 		vars["_includes"] += fmt.Sprintf(" -I%s", dir)
 	}
 
@@ -507,7 +508,7 @@ func NewT3XF(vars map[string]string, t3xf string, srcs []string, imports ...stri
 	return []*proc.Cmd{t}
 }
 
-func removeStdlib(srcs []string) []string {
+func removeStdlib(includes []string, srcs []string) []string {
 
 	// There are multiple installations of the stdlib, so we cannot compare
 	// for identity but use a hash instead.
@@ -528,7 +529,7 @@ func removeStdlib(srcs []string) []string {
 	// We build a map of hashes of the stdlib files. The key is the base
 	// name of the file.
 	stdlib := make(map[string][]byte)
-	for _, dir := range Includes() {
+	for _, dir := range includes {
 		for _, file := range fs.FindTTCN3Files(dir) {
 			if s, err := sum(file); err == nil {
 				stdlib[fs.Stem(file)] = s
@@ -12,12 +12,17 @@
 package project
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/hashicorp/go-multierror"
@@ -28,6 +33,8 @@ import (
 	"github.com/nokia/ntt/internal/results"
 	"github.com/nokia/ntt/internal/yaml"
 	"github.com/nokia/ntt/project/internal/k3"
+	"github.com/nokia/ntt/ttcn3"
+	"github.com/nokia/ntt/ttcn3/syntax"
 )
 
 var (
@@ -98,6 +105,12 @@ type Manifest struct {
 	// test-cases. E.g. common code, adapters, codecs, ...
 	Imports []string
 
+	// ExcludeImports excludes source files from test enumeration, which
+	// are located in one of the Imports directories. This prevents self
+	// tests of vendored libraries from being listed or run, when Sources
+	// and Imports overlap.
+	ExcludeImports bool `json:"exclude_imports"`
+
 	// BeforeBuild is a list of shell commands to be executed before
 	// building. An exit code unequal to 0 will cancel any further
 	// execution.
@@ -149,6 +162,24 @@ type Manifest struct {
 	//
 	// ${NTT_SOURCE_DIR}/ntt-lint.yml
 	LintFile string `json:"lint_file"`
+
+	// Redact is a list of regular expressions matching sensitive strings,
+	// like credentials echoed by the system under test. Matches in failure
+	// reasons and test output are replaced by *** before they are written
+	// to results files or reported.
+	Redact []string `json:"redact"`
+
+	// K3Includes is a list of TTCN-3 include directories of the k3
+	// installation (stdlib, builtins, ...). Usually these directories are
+	// discovered automatically. K3Includes replaces the discovered
+	// directories, which is required for non-standard k3 installations.
+	// The environment variable NTT_K3_INCLUDES takes precedence over this
+	// field.
+	K3Includes []string `json:"k3_includes"`
+
+	// K3IncludesAppend appends K3Includes to the discovered directories,
+	// instead of replacing them.
+	K3IncludesAppend bool `json:"k3_includes_append"`
 }
 
 // The Parameters file provide runtime configuration for a project (e.g. parameters files)
@@ -180,6 +211,10 @@ type TestConfig struct {
 	// Module parameters
 	Parameters map[string]string `json:",omitempty"`
 
+	// After lists tests, which must have passed before the test is
+	// started.
+	After []string `json:",omitempty"`
+
 	// Rules describe when a configuration should be used.
 	Rules `json:",inline"`
 }
@@ -223,22 +258,212 @@ type Suite struct {
 
 var (
 	ManifestFile = "package.yml"
-	IndexFile    = "ttcn3_suites.json"
+
+	// ManifestJSONFile is a manifest in JSON format, like manifests
+	// generated by other tools. It is read like ManifestFile. The name
+	// differs from npm's package.json, which is often found next to test
+	// suites.
+	ManifestJSONFile = "ttcn3-package.json"
+
+	IndexFile = "ttcn3_suites.json"
 )
 
+// findManifest returns the path of the manifest file in dir, or an empty
+// string if there is none. ManifestFile takes precedence over
+// ManifestJSONFile.
+func findManifest(dir string) string {
+	for _, name := range []string{ManifestFile, ManifestJSONFile} {
+		if file := fs.JoinPath(dir, name); fs.IsRegular(file) {
+			return file
+		}
+	}
+	return ""
+}
+
+// isManifest returns true if file is named like a manifest file.
+func isManifest(file string) bool {
+	base := filepath.Base(file)
+	return base == ManifestFile || base == ManifestJSONFile
+}
+
+// readManifest decodes the manifest file. JSON manifests are decoded by the
+// YAML decoder, too, because JSON is a subset of YAML. Unknown keys are an
+// error.
+func readManifest(file string, m *Manifest) error {
+	b, err := fs.Content(file)
+	if err != nil {
+		return err
+	}
+	if len(b) > 0 {
+		if err := yaml.Unmarshal(b, m); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+	}
+	return nil
+}
+
+// checkManifests returns an error if there is a YAML and a JSON manifest next
+// to file with different content.
+func checkManifests(file string) error {
+	var other string
+	switch filepath.Base(file) {
+	case ManifestFile:
+		other = ManifestJSONFile
+	case ManifestJSONFile:
+		other = ManifestFile
+	default:
+		return nil
+	}
+	other = fs.JoinPath(filepath.Dir(file), other)
+	if !fs.IsRegular(other) {
+		return nil
+	}
+	var m1, m2 Manifest
+	if err := readManifest(file, &m1); err != nil {
+		return err
+	}
+	if err := readManifest(other, &m2); err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(m1, m2) {
+		return fmt.Errorf("conflicting manifests %s and %s: remove one of them", file, other)
+	}
+	return nil
+}
+
+// SuiteSource describes how a test suite was discovered.
+type SuiteSource int
+
+const (
+	// ManifestSource suites were found by a manifest file (package.yml).
+	ManifestSource SuiteSource = iota + 1
+
+	// LayoutSource suites were guessed from a known directory layout,
+	// like a testcases folder.
+	LayoutSource
+
+	// IndexSource suites were found in an index file (ttcn3_suites.json)
+	// next to the sources.
+	IndexSource
+
+	// BuildDirSource suites were found in an index file of a build
+	// directory. They are usually generated by the build system.
+	BuildDirSource
+)
+
+func (s SuiteSource) String() string {
+	switch s {
+	case ManifestSource:
+		return "manifest"
+	case LayoutSource:
+		return "layout"
+	case IndexSource:
+		return "index"
+	case BuildDirSource:
+		return "build dir"
+	default:
+		return fmt.Sprintf("SuiteSource(%d)", int(s))
+	}
+}
+
+// DiscoveredSuite is a test suite found by DiscoverSuites.
+type DiscoveredSuite struct {
+	Suite
+
+	// Source describes how the suite was discovered.
+	Source SuiteSource
+
+	// Manifest is the path of the manifest or index file the suite was
+	// found by. It is empty for suites guessed from the directory layout.
+	Manifest string
+}
+
 // Discover walks towards the file system root and collects
 // known test suite layouts.
 //
-// Discover returns a list of potential test suite root directories.
+// Discover returns a list of potential test suite root directories. The list
+// is sorted deterministically, with suites found by manifest files first. Use
+// DiscoverSuites to learn how each suite was discovered.
 func Discover(path string) []Suite {
+	list, _ := DiscoverContext(context.Background(), path)
+	return list
+}
+
+// DiscoverOptions limit the directories Discover walks through.
+type DiscoverOptions struct {
+	// MaxDepth is the maximum number of parent directories checked above
+	// the start directory. Zero means no limit.
+	MaxDepth int
+
+	// StopAt is a directory at which the walk stops, like the root of a
+	// git repository. StopAt itself is still checked. An empty StopAt or a
+	// StopAt, which is not a parent of the start directory, has no effect.
+	StopAt string
+
+	// BuildGlobs are glob patterns of build directories, relative to each
+	// directory checked, which may contain index files. Nil means
+	// DefaultBuildGlobs. Invalid patterns are ignored.
+	BuildGlobs []string
+}
+
+// DefaultBuildGlobs are the glob patterns of build directories checked for
+// index files by default.
+var DefaultBuildGlobs = []string{"*build*", "build/native/*/sct"}
+
+// DiscoverWithOptions is like Discover, but does not walk beyond the limits
+// given by opts. This avoids scanning irrelevant directories of deep
+// checkouts and matches outside the project.
+func DiscoverWithOptions(path string, opts DiscoverOptions) []Suite {
+	found, _ := discoverSuites(context.Background(), path, opts)
+	var list []Suite
+	for _, s := range found {
+		list = append(list, s.Suite)
+	}
+	return list
+}
+
+// DiscoverContext is like Discover, but stops walking when ctx is done. The
+// checks of each directory are run concurrently, a few at a time, which helps
+// on slow network file systems. On cancellation DiscoverContext returns the
+// suites found so far and the error of the context.
+func DiscoverContext(ctx context.Context, path string) ([]Suite, error) {
+	found, err := DiscoverSuitesContext(ctx, path)
+	var list []Suite
+	for _, s := range found {
+		list = append(list, s.Suite)
+	}
+	return list, err
+}
+
+// DiscoverSuites is like Discover, but also returns how each suite was
+// discovered. This distinguishes source manifests from suites generated into
+// build directories.
+func DiscoverSuites(path string) []DiscoveredSuite {
+	list, _ := DiscoverSuitesContext(context.Background(), path)
+	return list
+}
+
+// DiscoverSuitesContext is like DiscoverSuites, but stops walking when ctx is
+// done. See DiscoverContext.
+func DiscoverSuitesContext(ctx context.Context, path string) ([]DiscoveredSuite, error) {
+	return discoverSuites(ctx, path, DiscoverOptions{})
+}
+
+func discoverSuites(ctx context.Context, path string, opts DiscoverOptions) ([]DiscoveredSuite, error) {
 
 	// Convert possible URIs to proper file system paths.
 	path = fs.Path(path)
 
-	var list []Suite
+	var list []DiscoveredSuite
+
+	buildGlobs := opts.BuildGlobs
+	if buildGlobs == nil {
+		buildGlobs = DefaultBuildGlobs
+	}
+	buildGlobs = validGlobs(buildGlobs)
 
 	// Return index, ignoring errors.
-	readIndices := func(file string) []Suite {
+	readIndices := func(file string, source SuiteSource) []DiscoveredSuite {
 		b, err := fs.Content(file)
 		if err != nil {
 			log.Debugf("Failed to read %s: %s", file, err.Error())
@@ -249,48 +474,153 @@ func Discover(path string) []Suite {
 			log.Debugf("%s: %s", file, err.Error())
 		}
 
-		var list []Suite
+		var list []DiscoveredSuite
 		for _, s := range idx.Suites {
 			if s.RootDir != "" {
-				root := fs.Real(filepath.Dir(file), s.RootDir)
-				log.Debugf("using root_dir: %q\n", root)
-				list = append(list, s)
+				s.RootDir = canonicalPath(filepath.Dir(file), s.RootDir)
+				s.SourceDir = canonicalPath(filepath.Dir(file), s.SourceDir)
+				log.Debugf("using root_dir: %q\n", s.RootDir)
+				list = append(list, DiscoveredSuite{Suite: s, Source: source, Manifest: file})
 			}
 		}
 		return list
 	}
 
-	fs.WalkUp(path, func(path string) bool {
-		// Check source directories
-		if file := fs.JoinPath(path, ManifestFile); fs.IsRegular(file) {
-			log.Debugf("discovered manifest: %q\n", file)
-			list = append(list, Suite{RootDir: path, SourceDir: path})
+	walkUp(path, opts, func(path string) bool {
+		checks := []func(context.Context) []DiscoveredSuite{
+			// Check source directories
+			func(context.Context) []DiscoveredSuite {
+				if file := findManifest(path); file != "" {
+					log.Debugf("discovered manifest: %q\n", file)
+					root := canonicalPath("", path)
+					return []DiscoveredSuite{{
+						Suite:    Suite{RootDir: root, SourceDir: root},
+						Source:   ManifestSource,
+						Manifest: file,
+					}}
+				}
+				return nil
+			},
+			func(context.Context) []DiscoveredSuite {
+				return readIndices(fs.JoinPath(path, IndexFile), IndexSource)
+			},
 		}
-		list = append(list, readIndices(fs.JoinPath(path, IndexFile))...)
 
 		// Check build directories
-		for _, file := range fs.Glob(path + "/*build*/" + IndexFile) {
-			list = append(list, readIndices(file)...)
-		}
-		for _, file := range fs.Glob(path + "/build/native/*/sct/" + IndexFile) {
-			list = append(list, readIndices(file)...)
+		for _, glob := range buildGlobs {
+			glob := glob
+			checks = append(checks, func(ctx context.Context) []DiscoveredSuite {
+				var list []DiscoveredSuite
+				for _, file := range fs.Glob(path + "/" + glob + "/" + IndexFile) {
+					if ctx.Err() != nil {
+						return nil
+					}
+					list = append(list, readIndices(file, BuildDirSource)...)
+				}
+				return list
+			})
 		}
-		return true
+		found, ok := runChecks(ctx, checks)
+		list = append(list, found...)
+		return ok
 	})
 
 	// If we could not find any manifest, try guess a root directory based on known naming schemes.
-	if len(list) == 0 {
-		fs.WalkUp(path, func(path string) bool {
+	if len(list) == 0 && ctx.Err() == nil {
+		walkUp(path, opts, func(path string) bool {
+			if ctx.Err() != nil {
+				return false
+			}
 			if tests := fs.Glob(path + "/testcases/*"); len(tests) > 0 {
 				log.Debugf("discovered testcases folder in %q\n", path)
-				list = append(list, Suite{RootDir: path, SourceDir: path})
+				root := canonicalPath("", path)
+				list = append(list, DiscoveredSuite{
+					Suite:  Suite{RootDir: root, SourceDir: root},
+					Source: LayoutSource,
+				})
 				return false
 			}
 			return true
 		})
 	}
 
-	// Remove duplicate entries
+	// A suite found several times is reported with the most specific
+	// source, manifests first.
+	var (
+		suites    []Suite
+		manifests = make(map[Suite]bool)
+		sources   = make(map[Suite]DiscoveredSuite)
+	)
+	for _, d := range list {
+		suites = append(suites, d.Suite)
+		if d.Source == ManifestSource || d.Source == LayoutSource {
+			manifests[d.Suite] = true
+		}
+		if prev, ok := sources[d.Suite]; !ok || d.Source < prev.Source {
+			sources[d.Suite] = d
+		}
+	}
+
+	var result []DiscoveredSuite
+	for _, s := range sortSuites(suites, manifests) {
+		result = append(result, sources[s])
+	}
+	return result, ctx.Err()
+}
+
+// canonicalPath makes path, which is relative to base, absolute and resolves
+// symbolic links, so that logically identical suites compare equal. URIs and
+// paths starting with environment variables are returned unchanged.
+func canonicalPath(base, path string) string {
+	path = fs.Real(base, path)
+	if path == "" || fs.IsURI(path) || path[0] == '$' {
+		return path
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	if real, err := filepath.EvalSymlinks(path); err == nil {
+		path = real
+	}
+	return path
+}
+
+// validGlobs returns the syntactically valid glob patterns of globs.
+func validGlobs(globs []string) []string {
+	var valid []string
+	for _, glob := range globs {
+		if _, err := filepath.Match(glob, ""); err != nil {
+			log.Debugf("ignoring build directory pattern %q: %s\n", glob, err.Error())
+			continue
+		}
+		valid = append(valid, glob)
+	}
+	return valid
+}
+
+// walkUp is like fs.WalkUp, but stops at the limits given by opts.
+func walkUp(path string, opts DiscoverOptions, f func(path string) bool) {
+	stop := ""
+	if opts.StopAt != "" {
+		stop, _ = filepath.Abs(fs.Path(opts.StopAt))
+	}
+	depth := 0
+	fs.WalkUp(path, func(path string) bool {
+		if !f(path) {
+			return false
+		}
+		if depth++; opts.MaxDepth > 0 && depth > opts.MaxDepth {
+			return false
+		}
+		abs, _ := filepath.Abs(path)
+		return stop == "" || abs != stop
+	})
+}
+
+// sortSuites removes duplicate suites and sorts them deterministically:
+// Suites found by manifest files come first, followed by those found in index
+// files. Each group is sorted by root directory, source directory and target.
+func sortSuites(list []Suite, manifests map[Suite]bool) []Suite {
 	result := make([]Suite, 0, len(list))
 	visited := make(map[Suite]bool)
 	for _, v := range list {
@@ -299,9 +629,112 @@ func Discover(path string) []Suite {
 			result = append(result, v)
 		}
 	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if manifests[a] != manifests[b] {
+			return manifests[a]
+		}
+		if a.RootDir != b.RootDir {
+			return a.RootDir < b.RootDir
+		}
+		if a.SourceDir != b.SourceDir {
+			return a.SourceDir < b.SourceDir
+		}
+		return a.Target < b.Target
+	})
 	return result
 }
 
+// maxChecks is the maximum number of checks runChecks runs concurrently.
+const maxChecks = 4
+
+// runChecks runs the given checks concurrently, at most maxChecks at a time,
+// and returns their results in order. Once ctx is done, no further checks are
+// started and runChecks returns false after the running checks returned.
+// Checks are passed ctx to return early.
+func runChecks(ctx context.Context, checks []func(context.Context) []DiscoveredSuite) ([]DiscoveredSuite, bool) {
+	results := make([][]DiscoveredSuite, len(checks))
+	sem := make(chan struct{}, maxChecks)
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(i int, check func(context.Context) []DiscoveredSuite) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			results[i] = check(ctx)
+		}(i, check)
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		return nil, false
+	}
+
+	var list []DiscoveredSuite
+	for _, r := range results {
+		list = append(list, r...)
+	}
+	return list, true
+}
+
+// ReadIndexFile reads the given index file.
+func ReadIndexFile(file string) (Index, error) {
+	var idx Index
+	b, err := fs.Content(file)
+	if err != nil {
+		return idx, err
+	}
+	if err := json.Unmarshal(b, &idx); err != nil {
+		return idx, fmt.Errorf("%s: %w", file, err)
+	}
+	return idx, nil
+}
+
+// WriteIndexFile writes the given index to file.
+func WriteIndexFile(file string, idx Index) error {
+	b, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(file, append(b, '\n'), 0644); err != nil {
+		return err
+	}
+
+	// Drop cached content, so readers see the new index.
+	fs.Open(file).Reset()
+	return nil
+}
+
+// AddSuite registers the test suite s in the given index file, so Discover
+// will find it. The index file is created if it does not exist. A relative
+// RootDir is relative to the directory of the index file, like with existing
+// entries. An existing entry with the same root directory is replaced.
+func AddSuite(file string, s Suite) error {
+	idx, err := ReadIndexFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	dir := filepath.Dir(file)
+	root := fs.Real(dir, s.RootDir)
+	suites := idx.Suites[:0]
+	for _, old := range idx.Suites {
+		if fs.Real(dir, old.RootDir) != root {
+			suites = append(suites, old)
+		}
+	}
+	idx.Suites = append(suites, s)
+	return WriteIndexFile(file, idx)
+}
+
 // Task is a build task.
 type Task interface {
 	// Inputs returns the list of input files.
@@ -367,7 +800,7 @@ func BuildTasks(c *Config) ([]Task, error) {
 		}
 	}
 
-	for _, t := range k3.NewT3XF(c.Variables, c.K3.T3XF, srcs, imports...) {
+	for _, t := range k3.NewT3XF(c.Variables, c.K3.T3XF, c.K3.Includes, srcs, imports...) {
 		ret = append(ret, t)
 	}
 
@@ -477,6 +910,203 @@ func Files(c *Config) ([]string, error) {
 	return fs.TTCN3Files(files...)
 }
 
+// Override sets the configuration value at the given dotted key path, for
+// example "timeout" or "k3.runtime". Key names are the same as used by the
+// manifest and by ntt show. Everything after "parameters." or "variables." is
+// the name of a module parameter or variable. The value is parsed as YAML and
+// must match the type of the field. Unknown keys and type mismatches are
+// errors.
+func Override(c *Config, key string, value string) error {
+	b, err := yaml.MarshalJSON(c)
+	if err != nil {
+		return err
+	}
+	conf := make(map[string]interface{})
+	if err := json.Unmarshal(b, &conf); err != nil {
+		return err
+	}
+
+	var v interface{}
+	if err := yaml.Unmarshal([]byte(value), &v); err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+
+	// Keys of parameters and variables may contain dots themselves (e.g.
+	// "parameters.M.PX_A").
+	m := conf
+	keys := strings.Split(key, ".")
+	for len(keys) > 1 {
+		k := keys[0]
+		switch next := m[k].(type) {
+		case map[string]interface{}:
+			m = next
+		case nil:
+			n := make(map[string]interface{})
+			m[k] = n
+			m = n
+		default:
+			return fmt.Errorf("%s: %q is not an object", key, k)
+		}
+		keys = keys[1:]
+		if k == "parameters" || k == "variables" {
+			keys = []string{strings.Join(keys, ".")}
+		}
+	}
+	m[keys[0]] = v
+
+	if b, err = json.Marshal(conf); err != nil {
+		return err
+	}
+	nc := Config{toolchain: c.toolchain}
+	if err := yaml.Unmarshal(b, &nc); err != nil {
+		// The YAML error contains a dump of the whole configuration,
+		// we only want the message.
+		msg := strings.SplitN(err.Error(), "\n", 2)[0]
+		if i := strings.Index(msg, "] "); strings.HasPrefix(msg, "[") && i > 0 {
+			msg = msg[i+2:]
+		}
+		return fmt.Errorf("%s: %s", key, msg)
+	}
+	*c = nc
+	return nil
+}
+
+// TestFiles returns the TTCN-3 source files test cases and control parts are
+// enumerated from.
+//
+// These are all files of the Sources list. If ExcludeImports is set, files
+// located in an import directory (or listed as import themselves) are
+// omitted, leaving only the test suite's own sources.
+//
+// Files not existing are no error, because they might vanish any time, for
+// example in a watched workspace. They are still returned, and callers skip
+// them with a warning, when they cannot be parsed.
+func TestFiles(c *Config) ([]string, error) {
+	srcs, err := fs.TTCN3Files(c.Sources...)
+	err = withoutNotExist(err)
+	if !c.ExcludeImports || len(c.Imports) == 0 {
+		return srcs, err
+	}
+
+	var imports []string
+	for _, imp := range c.Imports {
+		if abs, err := filepath.Abs(imp); err == nil {
+			imports = append(imports, abs)
+		}
+	}
+
+	var ret []string
+next:
+	for _, src := range srcs {
+		abs, err := filepath.Abs(src)
+		if err != nil {
+			ret = append(ret, src)
+			continue
+		}
+		for _, imp := range imports {
+			if abs == imp || strings.HasPrefix(abs, imp+string(filepath.Separator)) {
+				log.Debugf("project: excluding %s: part of import %s\n", src, imp)
+				continue next
+			}
+		}
+		ret = append(ret, src)
+	}
+	return ret, err
+}
+
+// withoutNotExist returns err without the errors about files not existing.
+func withoutNotExist(err error) error {
+	var merr *multierror.Error
+	if !errors.As(err, &merr) {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	var ret *multierror.Error
+	for _, err := range merr.Errors {
+		if !errors.Is(err, os.ErrNotExist) {
+			ret = multierror.Append(ret, err)
+		}
+	}
+	return ret.ErrorOrNil()
+}
+
+// OrderedSources returns the TTCN-3 source files (see Files) ordered by
+// their import graph: files defining imported modules come before the files
+// importing them. Files not depending on each other are ordered
+// lexicographically, hence the order is deterministic. Imports of modules not
+// defined by any of the files are ignored.
+//
+// Files which are part of an import cycle are appended in lexicographic order
+// and a warning is logged.
+func OrderedSources(c *Config) ([]string, error) {
+	files, err := Files(c)
+	if err != nil {
+		return nil, err
+	}
+	files = append([]string(nil), files...)
+	sort.Strings(files)
+
+	trees := ttcn3.ParseFiles(context.TODO(), files...)
+	defs := make(map[string]string)
+	for i, tree := range trees {
+		for _, m := range tree.Modules() {
+			defs[m.Ident.String()] = files[i]
+		}
+	}
+
+	// deps[f] is the number of files f imports, users[f] the files importing f.
+	deps := make(map[string]int)
+	users := make(map[string][]string)
+	for i, tree := range trees {
+		seen := make(map[string]bool)
+		for _, imp := range tree.Imports() {
+			dep, ok := defs[syntax.Name(imp.Node.(*syntax.ImportDecl).Module)]
+			if !ok || dep == files[i] || seen[dep] {
+				continue
+			}
+			seen[dep] = true
+			deps[files[i]]++
+			users[dep] = append(users[dep], files[i])
+		}
+	}
+
+	var ready []string
+	for _, f := range files {
+		if deps[f] == 0 {
+			ready = append(ready, f)
+		}
+	}
+
+	ret := make([]string, 0, len(files))
+	done := make(map[string]bool)
+	for len(ready) > 0 {
+		sort.Strings(ready)
+		f := ready[0]
+		ready = ready[1:]
+		ret = append(ret, f)
+		done[f] = true
+		for _, u := range users[f] {
+			if deps[u]--; deps[u] == 0 {
+				ready = append(ready, u)
+			}
+		}
+	}
+
+	if len(ret) < len(files) {
+		var cycle []string
+		for _, f := range files {
+			if !done[f] {
+				cycle = append(cycle, f)
+			}
+		}
+		log.Printf("warning: import cycle between %s: using lexicographic order\n", strings.Join(cycle, ", "))
+		ret = append(ret, cycle...)
+	}
+	return ret, nil
+}
+
 // GlobalCOnfig returns the global test configuration with applied presets
 func (p *Parameters) GlobalConfig(presets ...string) (TestConfig, error) {
 	gc := p.TestConfig
@@ -601,6 +1231,10 @@ func MergeTestConfig(a, b TestConfig) TestConfig {
 	if len(result.Parameters) == 0 {
 		result.Parameters = nil
 	}
+	result.After = append(append([]string(nil), a.After...), b.After...)
+	if len(result.After) == 0 {
+		result.After = nil
+	}
 	// Should we return an error if a and b have conflicting execute conditions?
 	result.Only = b.Only
 	result.Except = b.Except
@@ -630,8 +1264,15 @@ func split(name string) (string, string) {
 // build.sh, testcases-folder, ...). Open will also recursively load TTCN-3
 // source files from typical import-directories (i.e ../common).
 func Open(args ...string) (*Config, error) {
+	return OpenWith(nil, args...)
+}
+
+// OpenWith is like Open, but applies opts after the manifest and environment
+// variables have been read. Command line options use it to take precedence.
+func OpenWith(opts []ConfigOption, args ...string) (*Config, error) {
 	defaults := configOptions(
 		AutomaticEnv(),
+		configOptions(opts...),
 		WithIndex(cache.Lookup(IndexFile)),
 		WithDefaults(),
 		WithK3(),
@@ -658,7 +1299,7 @@ func Open(args ...string) (*Config, error) {
 	// Treat a single file argument as source, unless it is a directory or
 	// manifest file.
 	if file := args[0]; fs.IsRegular(file) {
-		if filepath.Base(file) == ManifestFile {
+		if isManifest(file) {
 			return NewConfig(WithManifest(file), defaults)
 		}
 		return NewConfig(WithSources(file), defaults)
@@ -742,7 +1383,8 @@ func WithIndex(file string) ConfigOption {
 	}
 }
 
-// WithManifest reads a manifest file (package.yml).
+// WithManifest reads a manifest file (package.yml or ttcn3-package.json). It is an
+// error if both manifest files exist and their content differs.
 //
 // expand variable references recursively. Environment variables overwrite
 // variables defined in environment files. Variables defined in environment
@@ -752,14 +1394,11 @@ func WithManifest(file string) ConfigOption {
 	return func(c *Config) error {
 		c.ManifestFile = file
 		c.Root = filepath.Dir(file)
-		b, err := fs.Content(file)
-		if err != nil {
+		if err := readManifest(file, &c.Manifest); err != nil {
 			return err
 		}
-		if len(b) > 0 {
-			if err := yaml.Unmarshal(b, &c.Manifest); err != nil {
-				return fmt.Errorf("%s: %w", file, err)
-			}
+		if err := checkManifests(file); err != nil {
+			return err
 		}
 		c.updateVariables()
 		if err := c.Variables.Expand(); err != nil {
@@ -772,10 +1411,35 @@ func WithManifest(file string) ConfigOption {
 	}
 }
 
+// AppendK3Includes appends k3_includes to the discovered k3 include
+// directories instead of replacing them, like k3_includes_append does. It must
+// precede WithK3.
+func AppendK3Includes() ConfigOption {
+	return func(c *Config) error {
+		c.K3IncludesAppend = true
+		return nil
+	}
+}
+
+// WithOverride sets the configuration value at the given dotted key path, like
+// Override. Given to OpenWith, it is applied before defaults and k3 settings
+// are derived from the configuration, hence overriding k3_includes changes the
+// k3 include directories, too. Relative paths are relative to the current
+// working directory, like those of environment variables.
+func WithOverride(key string, value string) ConfigOption {
+	return func(c *Config) error {
+		if err := Override(c, key, value); err != nil {
+			return fmt.Errorf("config override: %w", err)
+		}
+		return nil
+	}
+}
+
 func WithK3() ConfigOption {
 	return func(c *Config) error {
 		c.toolchain = "k3"
 		c.K3.Instance = k3.Find()
+		c.K3.Includes = k3Includes(c.K3.Includes, c.K3Includes, c.K3IncludesAppend)
 		c.K3.T3XF = cache.Lookup(fmt.Sprintf("%s.t3xf", c.Name))
 		log.Debugf("project: k3 compiler : %v\n", c.K3.Compiler)
 		log.Debugf("project: k3 runtime  : %v\n", c.K3.Runtime)
@@ -786,6 +1450,27 @@ func WithK3() ConfigOption {
 	}
 }
 
+// k3Includes returns the k3 include directories: dirs replace the discovered
+// directories, or are appended to them if add is set. Directories not
+// existing are skipped with a warning.
+func k3Includes(discovered []string, dirs []string, add bool) []string {
+	if len(dirs) == 0 {
+		return discovered
+	}
+	var ret []string
+	if add {
+		ret = append(ret, discovered...)
+	}
+	for _, dir := range dirs {
+		if !fs.IsDir(dir) {
+			log.Printf("warning: k3 include directory %s does not exist\n", dir)
+			continue
+		}
+		ret = append(ret, dir)
+	}
+	return ret
+}
+
 // WithRoot sets the root directory of the project.
 func WithRoot(root string) ConfigOption {
 	return func(c *Config) error {
@@ -835,7 +1520,7 @@ func AutomaticRoot(root string) ConfigOption {
 	return func(c *Config) error {
 		c.Root = root
 		log.Debugf("project: root %s\n", root)
-		if manifest := fs.JoinPath(root, ManifestFile); fs.IsRegular(manifest) {
+		if manifest := findManifest(root); manifest != "" {
 			return WithManifest(manifest)(c)
 		}
 
@@ -879,7 +1564,8 @@ func AutomaticRoot(root string) ConfigOption {
 
 // AutomaticEnv let environment variables with NTT_ prefix overwrite
 // configuration. Currently supported are: NTT_NAME, NTT_SOURCES, NTT_IMPORTS,
-// NTT_PARAMETERS_FILE, NTT_HOOKS_FILE, NTT_LINT_FILE, NTT_TIMEOUT.
+// NTT_PARAMETERS_FILE, NTT_HOOKS_FILE, NTT_LINT_FILE, NTT_TIMEOUT,
+// NTT_K3_INCLUDES, NTT_K3_INCLUDES_APPEND.
 func AutomaticEnv() ConfigOption {
 	return func(c *Config) error {
 		for k, v := range env.EnvironMap() {
@@ -901,6 +1587,14 @@ func AutomaticEnv() ConfigOption {
 				if err := c.Timeout.UnmarshalText([]byte(v)); err != nil {
 					return fmt.Errorf("environment variable %s: %w", k, err)
 				}
+			case "NTT_K3_INCLUDES":
+				c.K3Includes = strings.Split(v, string(os.PathListSeparator))
+			case "NTT_K3_INCLUDES_APPEND":
+				b, err := strconv.ParseBool(v)
+				if err != nil {
+					return fmt.Errorf("environment variable %s: %w", k, err)
+				}
+				c.K3IncludesAppend = b
 			default:
 				used = false
 			}
@@ -912,6 +1606,50 @@ func AutomaticEnv() ConfigOption {
 	}
 }
 
+// Validate checks the configuration for problems, which would otherwise fail
+// much later at runtime: an empty name, no sources and hooks or parameters
+// files which do not exist. The returned error lists every problem, prefixed
+// by the file the configuration originates from. Unknown manifest keys, like
+// typos, need no validation: they are rejected already when the manifest is
+// decoded (see readManifest).
+func (c *Config) Validate() error {
+	origin := c.ManifestFile
+	if origin == "" {
+		origin = c.Root
+	}
+	var merr *multierror.Error
+	errorf := func(format string, args ...interface{}) {
+		merr = multierror.Append(merr, fmt.Errorf("%s: %s", origin, fmt.Sprintf(format, args...)))
+	}
+	if c.Name == "" {
+		errorf("name is empty")
+	}
+	if len(c.Sources) == 0 {
+		errorf("%s", ErrNoSources.Error())
+	}
+	for _, f := range []struct{ name, path string }{
+		{"hooks_file", c.HooksFile},
+		{"parameters_file", c.ParametersFile},
+	} {
+		// Unexpanded variables and URIs cannot be checked.
+		if f.path == "" || strings.Contains(f.path, "$") || fs.IsURI(f.path) {
+			continue
+		}
+		if !fs.IsRegular(f.path) {
+			errorf("%s %q does not exist", f.name, f.path)
+		}
+	}
+	return merr.ErrorOrNil()
+}
+
+// WithValidation validates the configuration built by the preceding options
+// (see Config.Validate). Tooling may append it to opt into strict checking.
+func WithValidation() ConfigOption {
+	return func(c *Config) error {
+		return c.Validate()
+	}
+}
+
 // WithDefaults initializes Root, SourceDir, Name, ParametersFile and HooksFile.
 func WithDefaults() ConfigOption {
 	return func(c *Config) error {
@@ -997,7 +1735,7 @@ func WithDefaults() ConfigOption {
 
 // isRoot returns true if the given path contains typical project root files.
 func isRoot(root string) bool {
-	return fs.IsRegular(fs.JoinPath(root, ManifestFile)) ||
+	return findManifest(root) != "" ||
 		fs.IsRegular(fs.JoinPath(root, "build.sh")) ||
 		fs.IsRegular(fs.JoinPath(root, "project.xml")) ||
 		fs.IsDir(fs.JoinPath(root, "testcases")) ||
@@ -1005,6 +1743,35 @@ func isRoot(root string) bool {
 		len(fs.Glob(fs.JoinPath(root, "*.parameters"))) > 0
 }
 
+// Resolved returns all variables of the configuration after expansion, like
+// they are used for building and running the test suite. Variables are
+// resolved in this order, later sources taking precedence over earlier ones:
+//
+//  1. k3 default variables (like CC or K3CFLAGS)
+//  2. the variables section of the manifest
+//  3. environment files (ntt.env and k3.env)
+//  4. environment variables redefining variables of environment files and
+//     all environment variables prefixed with NTT_, K3_ or SCT_
+//
+// Variable references are then expanded recursively, with environment
+// variables taking precedence. Module parameters of the manifest and the
+// parameters file are included with their values as used, with keys of the
+// form parameters.<name>.
+//
+// Unknown or cyclic variable references are reported as error, together with
+// the partially resolved variables.
+func (c *Config) Resolved() (map[string]string, error) {
+	vars := make(env.Env, len(c.Variables))
+	for k, v := range c.Variables {
+		vars[k] = v
+	}
+	err := vars.Expand()
+	for k, v := range c.Parameters.Parameters {
+		vars["parameters."+k] = v
+	}
+	return vars, err
+}
+
 // updateVariables updates the given variable with the variables from
 // environment files. Environment variables override environment files.
 // Environment files overwrite manifest variables.
@@ -1044,4 +1811,7 @@ func (m *Manifest) expandPaths(base string) {
 	m.HooksFile = fs.Real(base, m.HooksFile)
 	m.ParametersFile = fs.Real(base, m.ParametersFile)
 	m.LintFile = fs.Real(base, m.LintFile)
+	for i, dir := range m.K3Includes {
+		m.K3Includes[i] = fs.Real(base, dir)
+	}
 }
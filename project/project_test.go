@@ -1,17 +1,23 @@
 package project
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"math/rand"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"syscall"
 	"testing"
 	"time"
 
+	"github.com/hashicorp/go-multierror"
+	"github.com/nokia/ntt/internal/env"
 	"github.com/nokia/ntt/internal/fs"
 	"github.com/nokia/ntt/internal/yaml"
+	"github.com/nokia/ntt/project/internal/k3"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -472,3 +478,446 @@ func equal(a, b []string) bool {
 	}
 	return true
 }
+
+func TestTestFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range []string{"a.ttcn3", "lib/b.ttcn3", "lib2/c.ttcn3"} {
+		path := filepath.Join(dir, f)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, nil, 0644)
+	}
+
+	c := &Config{}
+	c.Sources = []string{filepath.Join(dir, "a.ttcn3"), filepath.Join(dir, "lib"), filepath.Join(dir, "lib2")}
+	c.Imports = []string{filepath.Join(dir, "lib")}
+
+	files, err := TestFiles(c)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(files))
+
+	c.ExcludeImports = true
+	files, err = TestFiles(c)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "a.ttcn3"), filepath.Join(dir, "lib2/c.ttcn3")}, files)
+}
+
+func TestK3Includes(t *testing.T) {
+	dir := t.TempDir()
+	a, b, missing := filepath.Join(dir, "a"), filepath.Join(dir, "b"), filepath.Join(dir, "missing")
+	os.Mkdir(a, 0755)
+	os.Mkdir(b, 0755)
+	discovered := []string{"/opt/k3/include"}
+
+	assert.Equal(t, discovered, k3Includes(discovered, nil, false))
+	assert.Equal(t, []string{a, b}, k3Includes(discovered, []string{a, missing, b}, false))
+	assert.Equal(t, []string{"/opt/k3/include", a}, k3Includes(discovered, []string{a}, true))
+
+	os.Setenv("NTT_K3_INCLUDES", a+string(os.PathListSeparator)+b)
+	defer os.Unsetenv("NTT_K3_INCLUDES")
+	c, err := NewConfig(AutomaticEnv(), WithK3())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{a, b}, c.K3.Includes)
+
+	c, err = NewConfig(AutomaticEnv(), AppendK3Includes(), WithK3())
+	assert.Nil(t, err)
+	assert.Equal(t, append(k3.Find().Includes, a, b), c.K3.Includes)
+}
+
+func TestOrderedSources(t *testing.T) {
+	write := func(dir string, files map[string]string) []string {
+		var srcs []string
+		for name, content := range files {
+			path := filepath.Join(dir, name)
+			os.WriteFile(path, []byte(content), 0644)
+			srcs = append(srcs, path)
+		}
+		return srcs
+	}
+
+	dir := t.TempDir()
+	c := &Config{}
+	c.Sources = write(dir, map[string]string{
+		"a.ttcn3":      "module A { import from Common all; import from Types all; }",
+		"b.ttcn3":      "module B { import from A all; import from External all; }",
+		"common.ttcn3": "module Common { import from Types all; }",
+		"types.ttcn3":  "module Types {}",
+		"z.ttcn3":      "module Z {}",
+	})
+
+	for i := 0; i < 3; i++ {
+		files, err := OrderedSources(c)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{
+			filepath.Join(dir, "types.ttcn3"),
+			filepath.Join(dir, "common.ttcn3"),
+			filepath.Join(dir, "a.ttcn3"),
+			filepath.Join(dir, "b.ttcn3"),
+			filepath.Join(dir, "z.ttcn3"),
+		}, files)
+		c.Sources[0], c.Sources[len(c.Sources)-1] = c.Sources[len(c.Sources)-1], c.Sources[0]
+	}
+
+	// Import cycles fall back to lexicographic order.
+	dir = t.TempDir()
+	c.Sources = write(dir, map[string]string{
+		"x.ttcn3": "module X { import from Y all; }",
+		"y.ttcn3": "module Y { import from X all; }",
+		"a.ttcn3": "module A { import from X all; }",
+		"b.ttcn3": "module B {}",
+	})
+	files, err := OrderedSources(c)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "b.ttcn3"),
+		filepath.Join(dir, "a.ttcn3"),
+		filepath.Join(dir, "x.ttcn3"),
+		filepath.Join(dir, "y.ttcn3"),
+	}, files)
+}
+
+func TestAddSuite(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, IndexFile)
+
+	assert.Nil(t, AddSuite(file, Suite{RootDir: "a", SourceDir: "a"}))
+	assert.Nil(t, AddSuite(file, Suite{RootDir: "b", SourceDir: "b"}))
+	assert.Nil(t, AddSuite(file, Suite{RootDir: filepath.Join(dir, "a"), SourceDir: "a2"}))
+
+	idx, err := ReadIndexFile(file)
+	assert.Nil(t, err)
+	assert.Equal(t, []Suite{
+		{RootDir: "b", SourceDir: "b"},
+		{RootDir: filepath.Join(dir, "a"), SourceDir: "a2"},
+	}, idx.Suites)
+
+	// Discover resolves paths relative to the index file.
+	assert.Equal(t, []Suite{
+		{RootDir: filepath.Join(dir, "a"), SourceDir: filepath.Join(dir, "a2")},
+		{RootDir: filepath.Join(dir, "b"), SourceDir: filepath.Join(dir, "b")},
+	}, Discover(dir))
+}
+
+func TestOverride(t *testing.T) {
+	c := &Config{}
+	c.Name = "suite"
+	c.Sources = []string{"a.ttcn3"}
+
+	assert.Nil(t, Override(c, "timeout", "5.5"))
+	assert.Equal(t, 5500*time.Millisecond, c.Timeout.Duration)
+	assert.Nil(t, Override(c, "k3.runtime", "/opt/k3r"))
+	assert.Equal(t, "/opt/k3r", c.K3.Runtime)
+	assert.Nil(t, Override(c, "hooks_file", "hooks.sh"))
+	assert.Equal(t, "hooks.sh", c.HooksFile)
+	assert.Nil(t, Override(c, "parameters.M.PX_A", "23"))
+	assert.Equal(t, "23", c.Parameters.Parameters["M.PX_A"])
+	assert.Nil(t, Override(c, "exclude_imports", "true"))
+	assert.True(t, c.ExcludeImports)
+
+	// Other fields are untouched.
+	assert.Equal(t, "suite", c.Name)
+	assert.Equal(t, []string{"a.ttcn3"}, c.Sources)
+
+	assert.NotNil(t, Override(c, "no_such_field", "1"))
+	assert.NotNil(t, Override(c, "timeout", "soon"))
+	assert.NotNil(t, Override(c, "name.foo", "1"))
+	assert.Equal(t, 5500*time.Millisecond, c.Timeout.Duration)
+}
+
+func TestOpenWithOverride(t *testing.T) {
+	dir := t.TempDir()
+	inc := filepath.Join(dir, "include")
+	os.Mkdir(inc, 0755)
+	os.WriteFile(filepath.Join(dir, ManifestFile), []byte("name: suite\ntimeout: 5\n"), 0644)
+
+	// k3_includes is derived into the k3 include directories.
+	c, err := OpenWith([]ConfigOption{
+		WithOverride("k3_includes", "["+inc+"]"),
+		WithOverride("timeout", "10"),
+	}, dir)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{inc}, c.K3Includes)
+	assert.Equal(t, []string{inc}, c.K3.Includes)
+	assert.Equal(t, 10*time.Second, c.Timeout.Duration)
+	assert.Equal(t, "suite", c.Name)
+
+	_, err = OpenWith([]ConfigOption{WithOverride("no_such_field", "1")}, dir)
+	assert.NotNil(t, err)
+}
+
+func TestJSONManifest(t *testing.T) {
+	dir := t.TempDir()
+	json := filepath.Join(dir, ManifestJSONFile)
+	os.WriteFile(json, []byte(`{"name": "suite", "sources": ["a.ttcn3"], "timeout": 5.5}`), 0644)
+
+	c, err := NewConfig(AutomaticRoot(dir))
+	assert.Nil(t, err)
+	assert.Equal(t, json, c.ManifestFile)
+	assert.Equal(t, "suite", c.Name)
+	assert.Equal(t, []string{filepath.Join(dir, "a.ttcn3")}, c.Sources)
+	assert.Equal(t, 5500*time.Millisecond, c.Timeout.Duration)
+	assert.Equal(t, []Suite{{RootDir: dir, SourceDir: dir}}, DiscoverWithOptions(dir, DiscoverOptions{MaxDepth: 1}))
+
+	// Identical manifests are fine, the YAML manifest takes precedence.
+	yml := filepath.Join(dir, ManifestFile)
+	os.WriteFile(yml, []byte("name: suite\nsources: [a.ttcn3]\ntimeout: 5.5\n"), 0644)
+	c, err = NewConfig(AutomaticRoot(dir))
+	assert.Nil(t, err)
+	assert.Equal(t, yml, c.ManifestFile)
+
+	dir = t.TempDir()
+	json = filepath.Join(dir, ManifestJSONFile)
+	os.WriteFile(json, []byte(`{"name": "suite"}`), 0644)
+	os.WriteFile(filepath.Join(dir, ManifestFile), []byte("name: other\n"), 0644)
+	_, err = NewConfig(AutomaticRoot(dir))
+	assert.ErrorContains(t, err, "conflicting manifests")
+	_, err = NewConfig(WithManifest(json))
+	assert.ErrorContains(t, err, "conflicting manifests")
+
+	// npm manifests are no test suite manifests.
+	dir = t.TempDir()
+	os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{"name": "web-ui", "version": "1.0.0"}`), 0644)
+	assert.Equal(t, "", findManifest(dir))
+	assert.Nil(t, DiscoverWithOptions(dir, DiscoverOptions{MaxDepth: 1}))
+	os.WriteFile(filepath.Join(dir, ManifestFile), []byte("name: suite\n"), 0644)
+	c, err = NewConfig(AutomaticRoot(dir))
+	assert.Nil(t, err)
+	assert.Equal(t, "suite", c.Name)
+}
+
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+	hooks := filepath.Join(dir, "suite.hooks")
+	os.WriteFile(hooks, nil, 0644)
+
+	c := &Config{ManifestFile: filepath.Join(dir, ManifestFile)}
+	c.Name = "suite"
+	c.Sources = []string{"a.ttcn3"}
+	c.HooksFile = hooks
+	c.ParametersFile = "${NTT_SOURCE_DIR}/suite.parameters"
+	assert.Nil(t, c.Validate())
+
+	c = &Config{Root: dir}
+	c.ParametersFile = filepath.Join(dir, "missing.parameters")
+	err := c.Validate()
+	assert.ErrorContains(t, err, dir+": name is empty")
+	assert.ErrorContains(t, err, dir+": no sources")
+	assert.ErrorContains(t, err, "parameters_file")
+	assert.Len(t, err.(*multierror.Error).Errors, 3)
+
+	_, err = NewConfig(WithSources("a.ttcn3"), WithValidation())
+	assert.ErrorContains(t, err, "name is empty")
+	_, err = NewConfig(WithSources("a.ttcn3"), WithDefaults(), WithValidation())
+	assert.Nil(t, err)
+
+	// Manifests with misspelled or unknown keys are rejected.
+	dir = t.TempDir()
+	os.WriteFile(filepath.Join(dir, ManifestFile), []byte("name: suite\nsources: [a.ttcn3]\nsoruces: [b.ttcn3]\n"), 0644)
+	_, err = NewConfig(AutomaticRoot(dir))
+	assert.ErrorContains(t, err, `unknown field "soruces"`)
+
+	dir = t.TempDir()
+	os.WriteFile(filepath.Join(dir, ManifestJSONFile), []byte(`{"name": "suite", "hook_file": "x.hooks"}`), 0644)
+	_, err = NewConfig(AutomaticRoot(dir))
+	assert.ErrorContains(t, err, `unknown field "hook_file"`)
+}
+
+func TestResolved(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("NTT_CACHE", dir)
+	t.Setenv("D", "environ")
+	t.Setenv("NTT_X", "${C}")
+	os.WriteFile(filepath.Join(dir, "ntt.env"), []byte("B=envfile\nD=envfile\n"), 0644)
+	file := filepath.Join(dir, ManifestFile)
+	os.WriteFile(file, []byte(`
+variables:
+  A: ${B}/${CC}
+  B: manifest
+  C: manifest
+  CC: clang
+parameters:
+  M.PX: ${C}
+`), 0644)
+
+	c, err := NewConfig(WithManifest(file))
+	assert.Nil(t, err)
+	vars, err := c.Resolved()
+	assert.Nil(t, err)
+	for k, v := range map[string]string{
+		"A":               "envfile/clang", // references are expanded
+		"B":               "envfile",       // environment files overwrite the manifest
+		"C":               "manifest",
+		"D":               "environ", // the environment overwrites environment files
+		"CC":              "clang",   // the manifest overwrites k3 defaults
+		"CXX":             "g++",
+		"NTT_X":           "manifest",
+		"parameters.M.PX": "manifest",
+	} {
+		assert.Equal(t, v, vars[k], k)
+	}
+
+	c.Variables["E"] = "${UNKNOWN}"
+	vars, err = c.Resolved()
+	assert.ErrorIs(t, err, env.ErrUnknownVariable)
+	assert.Equal(t, "${UNKNOWN}", vars["E"])
+	assert.Equal(t, "envfile", vars["B"])
+}
+
+func TestDiscoverContext(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, ManifestFile), nil, 0644)
+
+	list, err := DiscoverContext(context.Background(), dir)
+	assert.Nil(t, err)
+	assert.Contains(t, list, Suite{RootDir: dir, SourceDir: dir})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	list, err = DiscoverContext(ctx, dir)
+	assert.Equal(t, context.Canceled, err)
+	assert.Empty(t, list)
+}
+
+func TestRunChecksCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Slow checks block until ctx is done.
+	var running, started int32
+	slow := func(ctx context.Context) []DiscoveredSuite {
+		atomic.AddInt32(&started, 1)
+		atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		<-ctx.Done()
+		return nil
+	}
+	checks := make([]func(context.Context) []DiscoveredSuite, 3*maxChecks)
+	for i := range checks {
+		checks[i] = slow
+	}
+
+	time.AfterFunc(50*time.Millisecond, cancel)
+	list, ok := runChecks(ctx, checks)
+	assert.False(t, ok)
+	assert.Nil(t, list)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&running), "checks still running")
+	assert.Equal(t, int32(maxChecks), atomic.LoadInt32(&started), "checks not limited")
+}
+
+func TestDiscoverSuites(t *testing.T) {
+	dir := t.TempDir()
+	gen := filepath.Join(dir, "gen")
+	os.WriteFile(filepath.Join(dir, ManifestFile), nil, 0644)
+	os.MkdirAll(filepath.Join(dir, "build"), 0755)
+	build := filepath.Join(dir, "build", IndexFile)
+	assert.Nil(t, AddSuite(build, Suite{RootDir: gen, SourceDir: gen, Target: "sct"}))
+	index := filepath.Join(dir, IndexFile)
+	assert.Nil(t, AddSuite(index, Suite{RootDir: dir, SourceDir: dir}))
+
+	// The suite of the source index is also found by its manifest.
+	assert.Equal(t, []DiscoveredSuite{
+		{Suite: Suite{RootDir: dir, SourceDir: dir}, Source: ManifestSource, Manifest: filepath.Join(dir, ManifestFile)},
+		{Suite: Suite{RootDir: gen, SourceDir: gen, Target: "sct"}, Source: BuildDirSource, Manifest: build},
+	}, DiscoverSuites(dir))
+
+	assert.Equal(t, []Suite{
+		{RootDir: dir, SourceDir: dir},
+		{RootDir: gen, SourceDir: gen, Target: "sct"},
+	}, Discover(dir))
+}
+
+func TestDiscoverWithOptions(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, ManifestFile), nil, 0644)
+	start := filepath.Join(dir, "a", "b")
+	os.MkdirAll(start, 0755)
+
+	want := []Suite{{RootDir: dir, SourceDir: dir}}
+	assert.Equal(t, want, DiscoverWithOptions(start, DiscoverOptions{}))
+	assert.Equal(t, want, DiscoverWithOptions(start, DiscoverOptions{MaxDepth: 2}))
+	assert.Empty(t, DiscoverWithOptions(start, DiscoverOptions{MaxDepth: 1}))
+	assert.Equal(t, want, DiscoverWithOptions(start, DiscoverOptions{StopAt: dir}))
+	assert.Empty(t, DiscoverWithOptions(start, DiscoverOptions{StopAt: filepath.Join(dir, "a")}))
+}
+
+func TestDiscoverBuildGlobs(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	assert.Nil(t, err)
+	for _, build := range []string{"cmake-build-debug/ttcn3", "out/x86/sct"} {
+		assert.Nil(t, os.MkdirAll(filepath.Join(dir, build), 0755))
+		assert.Nil(t, AddSuite(filepath.Join(dir, build, IndexFile), Suite{RootDir: dir, SourceDir: dir, Target: build}))
+	}
+
+	// The default patterns only match the cmake build directory itself.
+	assert.Empty(t, DiscoverWithOptions(dir, DiscoverOptions{MaxDepth: 1}))
+
+	assert.Equal(t, []Suite{
+		{RootDir: dir, SourceDir: dir, Target: "cmake-build-debug/ttcn3"},
+		{RootDir: dir, SourceDir: dir, Target: "out/x86/sct"},
+	}, DiscoverWithOptions(dir, DiscoverOptions{
+		MaxDepth:   1,
+		BuildGlobs: []string{"cmake-build-*/ttcn3", "[", "out/*/sct"},
+	}))
+}
+
+func TestDiscoverSymlinks(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	assert.Nil(t, err)
+	suite := filepath.Join(dir, "suite")
+	os.MkdirAll(suite, 0755)
+	os.WriteFile(filepath.Join(suite, ManifestFile), nil, 0644)
+	if err := os.Symlink(suite, filepath.Join(dir, "link")); err != nil {
+		t.Skip(err)
+	}
+
+	// The index refers to the suite by a relative path, a symlink and the
+	// real path.
+	os.MkdirAll(filepath.Join(dir, "build"), 0755)
+	assert.Nil(t, WriteIndexFile(filepath.Join(dir, "build", IndexFile), Index{Suites: []Suite{
+		{RootDir: "../suite", SourceDir: "../suite"},
+		{RootDir: filepath.Join(dir, "link"), SourceDir: "../link"},
+		{RootDir: suite, SourceDir: suite},
+	}}))
+
+	want := []DiscoveredSuite{{
+		Suite:    Suite{RootDir: suite, SourceDir: suite},
+		Source:   ManifestSource,
+		Manifest: filepath.Join(dir, "link", ManifestFile),
+	}}
+	assert.Equal(t, want, DiscoverSuites(filepath.Join(dir, "link")))
+
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	os.Chdir(dir)
+	assert.Equal(t, []Suite{{RootDir: suite, SourceDir: suite}}, Discover("./link"))
+}
+
+func TestSortSuites(t *testing.T) {
+	input := []Suite{
+		{RootDir: "/b", SourceDir: "/b"},
+		{RootDir: "/a", SourceDir: "/a/src", Target: "y"},
+		{RootDir: "/z", SourceDir: "/z"},
+		{RootDir: "/a", SourceDir: "/a/src", Target: "x"},
+		{RootDir: "/a", SourceDir: "/a"},
+		{RootDir: "/b", SourceDir: "/b"},
+		{RootDir: "/y", SourceDir: "/y"},
+	}
+	manifests := map[Suite]bool{
+		{RootDir: "/z", SourceDir: "/z"}: true,
+		{RootDir: "/y", SourceDir: "/y"}: true,
+	}
+	want := []Suite{
+		{RootDir: "/y", SourceDir: "/y"},
+		{RootDir: "/z", SourceDir: "/z"},
+		{RootDir: "/a", SourceDir: "/a"},
+		{RootDir: "/a", SourceDir: "/a/src", Target: "x"},
+		{RootDir: "/a", SourceDir: "/a/src", Target: "y"},
+		{RootDir: "/b", SourceDir: "/b"},
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 20; i++ {
+		list := append([]Suite(nil), input...)
+		r.Shuffle(len(list), func(i, j int) { list[i], list[j] = list[j], list[i] })
+		assert.Equal(t, want, sortSuites(list, manifests))
+	}
+}
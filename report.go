@@ -56,6 +56,7 @@ Available Objects
   .Report.MaxJobs:    maximum number of parallel test jobs
   .Report.MaxLoad:    maximum allowed CPU load
   .Report.Modules:    a list of collection sorted by module
+  .Report.ModuleSummary: pass/fail counts per module, most failures first
   .Report.Name:       name of the collection
   .Report.Runs:       list of test runs
   .Report.Tests:      list of tests (with final verdict)
@@ -201,6 +202,7 @@ const (
       "dev"   : {{.Tests.Deviation.Milliseconds}}
     }
   },
+  "modules": {{ .ModuleSummary | json }},
   "env": {{ .Environ | json }}
 }
 `
@@ -511,6 +513,13 @@ func (c Collection) Modules() []*Collection {
 	return ret
 }
 
+func (c Collection) ModuleSummary() []results.ModuleSummary {
+	if m := results.SummarizeModules(c.Tests().asResultsRun()); m != nil {
+		return m
+	}
+	return []results.ModuleSummary{}
+}
+
 type RunSlice []Run
 
 func NewRunSlice(runs []results.Run) RunSlice {
@@ -595,7 +604,7 @@ func (rs RunSlice) Result() string {
 func (rs RunSlice) filter(f func(s string) bool) []Run {
 	ret := make([]Run, 0, len(rs))
 	for _, r := range rs {
-		if f(r.Verdict) {
+		if f(string(r.Verdict)) {
 			ret = append(ret, r)
 		}
 	}
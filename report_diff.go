@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/nokia/ntt/internal/results"
+	"github.com/spf13/cobra"
+)
+
+var DiffCommand = &cobra.Command{
+	Use:   "diff",
+	Short: "Compare test results with a baseline",
+	Long: `Compare test results with a baseline.
+
+The diff command compares the final verdicts of two test results files, usually
+a known-good baseline and the results of the current run:
+
+	ntt report diff baseline.json test_results.json
+
+Each test is listed with one of these changes:
+
+	regression  passed in the baseline, but not now
+	fixed       did not pass in the baseline, but passes now
+	failing     did not pass in both
+	added       not part of the baseline
+	removed     only part of the baseline
+
+Tests with verdict skipped are not compared. The diff command exits with
+non-zero status if there are regressions. Added tests are not regressions,
+even if they fail.
+`,
+	Args: cobra.ExactArgs(2),
+	RunE: diffResults,
+}
+
+func init() {
+	ReportCommand.AddCommand(DiffCommand)
+}
+
+func diffResults(cmd *cobra.Command, args []string) error {
+	var dbs []*results.DB
+	for _, file := range args {
+		if _, err := os.Stat(file); err != nil {
+			return err
+		}
+		db, err := results.Load(file)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		dbs = append(dbs, db)
+	}
+
+	d := results.Diff(dbs[0], dbs[1])
+	if useJSON {
+		b, err := json.MarshalIndent(d, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+	} else {
+		for _, c := range []struct {
+			change string
+			names  []string
+		}{
+			{"regression", d.NewFailures},
+			{"fixed", d.NewPasses},
+			{"failing", d.StillFailing},
+			{"added", d.Added},
+			{"removed", d.Removed},
+		} {
+			for _, name := range c.names {
+				fmt.Printf("%s\t%s\n", c.change, name)
+			}
+		}
+	}
+
+	if n := len(d.NewFailures); n > 0 {
+		return fmt.Errorf("%w: %d regression(s) compared to baseline", ErrCommandFailed, n)
+	}
+	return nil
+}
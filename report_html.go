@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/nokia/ntt/internal/results"
+	"github.com/spf13/cobra"
+)
+
+var (
+	HTMLCommand = &cobra.Command{
+		Use:   "html [<results file>]",
+		Short: "Render test results as HTML page",
+		Long: `Render test results as HTML page.
+
+The html command renders a test results file as a self-contained HTML page,
+which can be opened offline: verdict counts, a sortable table of all test runs
+and a timeline of the test session. Without argument the results file of the
+test suite is used.
+
+Without --output the page is written to standard output.
+`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: reportHTML,
+	}
+
+	htmlOutput string
+)
+
+func init() {
+	HTMLCommand.Flags().StringVarP(&htmlOutput, "output", "o", "", "write HTML page to file")
+	ReportCommand.AddCommand(HTMLCommand)
+}
+
+func reportHTML(cmd *cobra.Command, args []string) error {
+	file := results.Filename
+	if Project != nil && Project.ResultsFile != "" {
+		file = Project.ResultsFile
+	}
+	if len(args) > 0 {
+		file = args[0]
+	}
+	db, err := results.Load(file)
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+
+	title := "Test Report"
+	if Project != nil && Project.Name != "" {
+		title = Project.Name + " " + title
+	}
+
+	w := io.Writer(os.Stdout)
+	if htmlOutput != "" {
+		f, err := os.Create(htmlOutput)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	return renderHTML(w, title, db.Runs())
+}
+
+// htmlRun is a test run prepared for the HTML report. Offset and Width
+// position the run on the timeline in percent of the session duration.
+type htmlRun struct {
+	results.Run
+	Duration time.Duration
+	Offset   float64
+	Width    float64
+}
+
+// htmlVerdictCount is the number of runs with a verdict.
+type htmlVerdictCount struct {
+	Verdict string
+	Count   int
+}
+
+// renderHTML writes the given runs as self-contained HTML page to w.
+func renderHTML(w io.Writer, title string, runs []results.Run) error {
+	s := results.Summarize(runs)
+	data := struct {
+		Title    string
+		Summary  results.Summary
+		Verdicts []htmlVerdictCount
+		Runs     []htmlRun
+	}{
+		Title:   title,
+		Summary: s,
+	}
+
+	for v, n := range s.Verdicts {
+		data.Verdicts = append(data.Verdicts, htmlVerdictCount{Verdict: v, Count: n})
+	}
+	sort.Slice(data.Verdicts, func(i, j int) bool {
+		return data.Verdicts[i].Verdict < data.Verdicts[j].Verdict
+	})
+
+	begin, total := s.Begin.Time, s.End.Sub(s.Begin.Time)
+	for _, r := range runs {
+		hr := htmlRun{Run: r, Duration: r.Duration().Round(time.Millisecond)}
+		if total > 0 {
+			hr.Offset = 100 * float64(r.Begin.Sub(begin)) / float64(total)
+			hr.Width = 100 * float64(r.Duration()) / float64(total)
+		}
+		data.Runs = append(data.Runs, hr)
+	}
+	sort.SliceStable(data.Runs, func(i, j int) bool {
+		return data.Runs[i].Begin.Before(data.Runs[j].Begin.Time)
+	})
+
+	return htmlTemplate.Execute(w, data)
+}
+
+var htmlTemplate = template.Must(template.New("ntt-report-html").Funcs(template.FuncMap{
+	// verdictClass returns the CSS class of a verdict, given as string or
+	// results.Verdict.
+	"verdictClass": func(v interface{}) string {
+		s := fmt.Sprint(v)
+		switch results.Verdict(s) {
+		case results.PassVerdict, results.FailVerdict, results.InconcVerdict, results.NoneVerdict, results.UnstableVerdict:
+			return s
+		case results.ErrorVerdict, results.UnknownVerdict:
+			return "fail"
+		default:
+			return "other"
+		}
+	},
+	"seconds": func(d time.Duration) string {
+		return fmt.Sprintf("%.3f", d.Seconds())
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border-bottom: 1px solid #ddd; padding: 4px 8px; text-align: left; vertical-align: top; }
+th { cursor: pointer; background: #f4f4f4; user-select: none; }
+td.num { text-align: right; font-variant-numeric: tabular-nums; }
+.counts span { display: inline-block; margin-right: 1em; padding: 4px 8px; border-radius: 4px; }
+.pass { background: #c8f0c8; }
+.fail { background: #f6c4c4; }
+.inconc, .none { background: #f0c8f0; }
+.unstable { background: #fbdcb4; }
+.other { background: #e4e4e4; }
+.timeline { position: relative; border: 1px solid #ddd; margin-bottom: 2em; }
+.timeline div { position: relative; height: 6px; min-width: 1px; margin: 1px 0; }
+.reason { white-space: pre-wrap; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{.Summary.Total}} runs, worst verdict: <b>{{.Summary.Worst}}</b>, {{printf "%.1f" .Summary.Duration}}s</p>
+<p class="counts">{{range .Verdicts}}<span class="{{verdictClass .Verdict}}">{{.Verdict}}: {{.Count}}</span>{{end}}</p>
+
+<h2>Timeline</h2>
+<div class="timeline">{{range .Runs}}
+<div class="{{verdictClass .Verdict}}" style="left: {{printf "%.3f" .Offset}}%; width: {{printf "%.3f" .Width}}%" title="{{.ID}} {{.Verdict}} {{.Duration}}"></div>{{end}}
+</div>
+
+<h2>Runs</h2>
+<table id="runs">
+<thead><tr><th>Test</th><th>Instance</th><th>Verdict</th><th>Duration (s)</th><th>Reason</th></tr></thead>
+<tbody>{{range .Runs}}
+<tr><td>{{.Name}}</td><td class="num">{{.Instance}}</td><td class="{{verdictClass .Verdict}}">{{.Verdict}}</td><td class="num">{{seconds .Duration}}</td><td class="reason">{{.Reason}}</td></tr>{{end}}
+</tbody>
+</table>
+
+<script>
+document.querySelectorAll("#runs th").forEach(function(th, col) {
+	var asc = true;
+	th.addEventListener("click", function() {
+		var tbody = document.querySelector("#runs tbody");
+		var rows = Array.prototype.slice.call(tbody.rows);
+		rows.sort(function(a, b) {
+			var x = a.cells[col].textContent, y = b.cells[col].textContent;
+			var n = parseFloat(x) - parseFloat(y);
+			var c = isNaN(n) ? x.localeCompare(y) : n;
+			return asc ? c : -c;
+		});
+		asc = !asc;
+		rows.forEach(function(r) { tbody.appendChild(r); });
+	});
+});
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/nokia/ntt/internal/fs"
+	"github.com/nokia/ntt/internal/results"
+	"github.com/spf13/cobra"
+)
+
+var (
+	MergeCommand = &cobra.Command{
+		Use:   "merge",
+		Short: "Merge test results files",
+		Long: `Merge test results files.
+
+The merge command merges the given test results files (` + results.Filename + `),
+like those of sharded CI runs, into a single file. The test sessions of all
+files are preserved. Files of different versions cannot be merged.
+
+Without --output the merged results are written to standard output.
+
+Example:
+
+	ntt report merge shard1/test_results.json shard2/test_results.json -o test_results.json
+`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: merge,
+	}
+
+	mergeOutput string
+)
+
+func init() {
+	MergeCommand.Flags().StringVarP(&mergeOutput, "output", "o", "", "write merged results to file")
+	ReportCommand.AddCommand(MergeCommand)
+}
+
+func merge(cmd *cobra.Command, args []string) error {
+	var dbs []*results.DB
+	for _, file := range args {
+		// Load treats missing files as empty results.
+		if !fs.IsRegular(file) {
+			return fmt.Errorf("%s: no such file", file)
+		}
+		db, err := results.Load(file)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		dbs = append(dbs, db)
+	}
+
+	db, err := results.Merge(dbs...)
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(db, "", "  ")
+	if err != nil {
+		return err
+	}
+	if mergeOutput == "" {
+		fmt.Println(string(b))
+		return nil
+	}
+	return os.WriteFile(mergeOutput, b, 0644)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/nokia/ntt/internal/results"
+	"github.com/spf13/cobra"
+)
+
+var StatsCommand = &cobra.Command{
+	Use:   "stats [<results file>]",
+	Short: "Show aggregate statistics of test results",
+	Long: `Show aggregate statistics of test results.
+
+The stats command shows the number of runs per verdict, total and mean run
+duration, and the slowest and flakiest tests of a test results file. Flaky
+tests are tests with passing and failing runs. Without argument the results
+file of the test suite is used.
+
+With --json the statistics are written in JSON format.
+`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: stats,
+}
+
+func init() {
+	ReportCommand.AddCommand(StatsCommand)
+}
+
+func stats(cmd *cobra.Command, args []string) error {
+	file := results.Filename
+	if Project != nil && Project.ResultsFile != "" {
+		file = Project.ResultsFile
+	}
+	if len(args) > 0 {
+		file = args[0]
+	}
+	db, err := results.Load(file)
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+
+	s := db.Stats()
+	if useJSON {
+		b, err := json.MarshalIndent(s, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+		return nil
+	}
+
+	fmt.Printf("runs:\t%d\n", s.Total)
+	var verdicts []string
+	for v := range s.Verdicts {
+		verdicts = append(verdicts, v)
+	}
+	sort.Strings(verdicts)
+	for _, v := range verdicts {
+		fmt.Printf("%s:\t%d\n", v, s.Verdicts[v])
+	}
+	fmt.Printf("total duration:\t%.1fs\n", s.TotalDuration)
+	fmt.Printf("mean duration:\t%.1fs\n", s.MeanDuration)
+	if len(s.Slowest) > 0 {
+		fmt.Println("\nslowest:")
+		for _, r := range s.Slowest {
+			fmt.Printf("  %s\t%.1fs\n", r.ID(), r.Duration().Seconds())
+		}
+	}
+	if len(s.Flakiest) > 0 {
+		fmt.Println("\nflakiest:")
+		for _, t := range s.Flakiest {
+			fmt.Printf("  %s\t%d/%d passed\n", t.Name, t.Passed, t.Runs)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nokia/ntt/internal/results"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderHTML(t *testing.T) {
+	begin := time.Unix(1000, 0)
+	ts := func(d time.Duration) results.Timestamp { return results.Timestamp{Time: begin.Add(d)} }
+	runs := []results.Run{
+		{Name: "M.b", Verdict: "fail", Reason: "<unexpected>", Begin: ts(time.Second), End: ts(4 * time.Second)},
+		{Name: "M.a", Verdict: "pass", Begin: ts(0), End: ts(time.Second)},
+	}
+
+	var b bytes.Buffer
+	assert.Nil(t, renderHTML(&b, "suite Test Report", runs))
+	html := b.String()
+
+	assert.Contains(t, html, "<title>suite Test Report</title>")
+	assert.Contains(t, html, `<span class="fail">fail: 1</span><span class="pass">pass: 1</span>`)
+	assert.Contains(t, html, `<td class="num">3.000</td><td class="reason">&lt;unexpected&gt;</td>`)
+	assert.Contains(t, html, `style="left: 25.000%; width: 75.000%"`)
+	assert.Less(t, strings.Index(html, "<td>M.a</td>"), strings.Index(html, "<td>M.b</td>"), "runs are sorted by begin")
+}
@@ -1,31 +1,41 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
 	"io"
 	"io/ioutil"
+	"math/rand"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"text/tabwriter"
 	"time"
 
 	"github.com/fatih/color"
 	"github.com/nokia/ntt/control"
 	"github.com/nokia/ntt/control/k3r"
 	"github.com/nokia/ntt/control/printer"
+	"github.com/nokia/ntt/internal/env"
 	"github.com/nokia/ntt/internal/fs"
 	"github.com/nokia/ntt/internal/log"
+	"github.com/nokia/ntt/internal/proc"
 	"github.com/nokia/ntt/internal/results"
 	"github.com/nokia/ntt/project"
 	"github.com/nokia/ntt/ttcn3"
-	"github.com/nokia/ntt/ttcn3/doc"
 	"github.com/nokia/ntt/ttcn3/syntax"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
+	"golang.org/x/sync/semaphore"
 )
 
 var (
@@ -49,16 +59,309 @@ all tests with @stable-tag:
 
 	NTT_LIST_BASKETS=stable ntt run
 
+
+Tests files (--tests-file) list one test per line. Alternatively a tests file
+may contain a JSON array, which allows passing module parameters to each test
+instance. The same test may be listed multiple times, each entry becomes a
+distinct job:
+
+	[
+	  {"id": "test.A", "parameters": {"test.PX_RATE": "10"}},
+	  {"id": "test.A", "parameters": {"test.PX_RATE": "20"}}
+	]
+
+Parameters override those from the parameters file and are passed to the test
+executable like any other module parameter.
+
+With --fail-log=FILE the names of all tests, which did not pass, are written to
+FILE, one per line. The file is written even when the run is aborted, so the
+next iteration may run just those tests:
+
+	ntt run --fail-log=fails.txt
+	ntt run --tests-file=fails.txt
+
+
+With --include-subtests, testcases with a @subtests tag are run once for each
+value of the tag, see "ntt help list" for details. Subtests, like
+test.A[PX_RATE=10], may also be given explicitly on the command line or in a
+tests file. They are reported by their subtest identifier.
+
+
+Jobs may carry labels, which are arbitrary key-value pairs. Labels are
+attached with @label tags or with a "labels" object in JSON tests files:
+
+	// @label: tier=1, area=ran
+	testcase TC_attach() runs on C { ... }
+
+	[{"id": "test.TC_attach", "labels": {"shard": "3"}}]
+
+With --select=KEY=VALUE only jobs with that label are run, --select=KEY
+selects jobs having label KEY with any value. Multiple selectors must all
+match. Baskets filter tests by their source (names and documentation tags)
+and are the right choice for interactive use. Selectors filter the jobs
+themselves and also see labels injected by tools, which generate tests files
+for orchestration, like sharding.
+
+To split a suite across several machines, use --shard=I/N. The jobs are
+distributed over N shards by a hash of their test identifier and only shard I
+(starting at 1) is run. The distribution is the same on every machine, hence
+running shards 1/N to N/N runs every job exactly once. Sharding applies after
+baskets and selectors and also to tests given explicitly:
+
+	ntt run --shard=3/8
+
+
+Tests may depend on other tests. A test with ordering constraints is only
+started after all listed tests passed. Constraints are given with an @after
+tag or with an "after" list in the execute section of the parameters file:
+
+	// @after: test.A, test.B
+	testcase C() runs on C { ... }
+
+	execute:
+	  - test: test.C
+	    after: [test.A, test.B]
+
+Independent tests still run in parallel. Tests whose prerequisites did not pass
+are skipped. Prerequisites not part of the run are ignored. Cyclic constraints
+are reported as error before any test is started.
+
+
+Tests, which only apply under certain conditions, may be skipped with a
+@skip-if tag. The condition compares an environment or project variable with a
+value, in the form VAR==value or VAR!=value. Undefined variables are empty.
+Skipped tests are not run, but recorded as skipped with the condition as
+reason. Tests with several @skip-if tags are skipped if any condition holds:
+
+	// @skip-if: TARGET_OS==windows
+	// @skip-if: FEATURE_X!=on
+	testcase TC_feature_x() runs on C { ... }
+
+
+Tests should not depend on the order in which they run. To surface hidden
+dependencies, --shuffle runs the jobs in random order. Ordering constraints are
+still honored. The seed of the random order is printed at the start of the run.
+To reproduce an order, pass it with --seed:
+
+	ntt run --shuffle --seed=1700000000
+
+
+Some tests need more resources (memory, CPU, ...) than others. Such tests may
+be weighted with a @weight tag:
+
+	// @weight: 4
+	testcase TC_heavy() runs on C { ... }
+
+With --max-weight=N tests are admitted as long as the summed weight of all
+running tests does not exceed N. Tests without @weight tag weigh 1, tests
+heavier than N run alone. The number of parallel tests is still bound by
+--jobs, which remains the upper limit.
+
+Starting many parallel tests at once may overwhelm a shared system under test.
+With --ramp-up=DURATION the parallel jobs are started one after the other,
+spread evenly over DURATION. For example, with -j 8 --ramp-up=40s another
+parallel job is started every 5 seconds. --ramp-up only affects the start of
+the run; --jobs still sets the number of parallel jobs once all have started.
+
+To diagnose problems of parallel execution, like hanging tests, use
+--worker-log-dir=DIR. Each parallel job (worker) then writes a log to
+DIR/worker-N.log, recording when it starts and stops, which tests it runs, the
+commands it spawns and errors. Unlike test logs, these logs are about the
+runner itself. Logs of the previous run are kept as DIR/worker-N.log.1.
+
+With --output-dir=DIR the artifacts of each test are stored in DIR/ID. When a
+test runs more than once, for example with --retry, later attempts overwrite
+the artifacts of earlier ones. --output-layout=LAYOUT chooses the directory
+below DIR instead. LAYOUT is a Go text/template with the fields .ID, .Name,
+.Attempt (starting at 1), .Iteration (of --repeat-until, starting at 1),
+.Verdict and .Timestamp (start of the attempt). For example:
+
+	ntt run -o logs --retry=2 --output-layout='{{.ID}}/{{.Attempt}}-{{.Verdict}}'
+
+While the test runs, .Verdict is "running". The directory is renamed when the
+test has finished; tests without verdict have verdict "error". An existing
+directory of the same name is replaced. With --repeat-until include .Iteration
+in LAYOUT to keep the artifacts of every iteration.
+
+For soak testing, --repeat-until=fail runs the selected tests again and again,
+until an iteration has a failure. --max-iterations=N and --repeat-for=DURATION
+limit the repetitions. The results of all iterations are collected, each run
+is tagged with its iteration number. The artifacts in the output directory are
+those of the last, possibly failing, iteration:
+
+	ntt run --repeat-until=fail --max-iterations=100 -- test.A test.B
+
+--repeat=N runs each selected test N times within a single iteration. The
+repetitions are numbered, like test.A#1, test.A#2, etc., to tell their results
+apart. Unlike --repeat-until, repetitions may run in parallel.
+
+Failure reasons and test output may contain sensitive data echoed by the
+system under test. Use --redact=REGEXP or the manifest's redact list to replace
+all matches by *** in every output format, the results file and the reporter
+plugin:
+
+	ntt run --redact='password=\S+' --redact='\b\d{3}-\d{2}-\d{4}\b'
+
+To watch a long run from a browser, use --status-server=ADDR. While the tests
+run, http://ADDR/ serves a JSON document with the active jobs, the number of
+completed tests per verdict, the elapsed time and the most recent results.
+Addresses without host, like :8080, are bound to localhost. The server stops
+when the run ends.
+
+Known flaky tests may be put into quarantine with --quarantine=FILE. FILE lists
+qualified test names, in the format of --tests-file. Quarantined tests are run
+and their results are recorded (marked as quarantined), but their failures do
+not count: not for the exit code, nor for --max-fail, --fail-under,
+--on-failure or --repeat-until. They are listed in a separate quarantined
+section at the end of the run instead.
+
+To be alerted while a long run is still going on, use --on-fail=PATTERN=ACTION.
+ACTION is invoked as soon as a test matching PATTERN fails, instead of waiting
+for the end of the run. PATTERN uses shell-like wildcards (* and ?), matching
+the qualified test name. ACTION is either a webhook URL, which receives the
+name, verdict and reason as JSON object via POST, or a command, which gets
+them in K3_TEST_NAME, K3_TEST_VERDICT and K3_TEST_REASON. Actions run in the
+background; failing actions are reported as warnings, but do not fail the run:
+
+	ntt run --on-fail='test.Critical*=page-oncall --urgent'
+	ntt run --on-fail='*=https://alerts.example.com/ntt'
+
+As merge gate, --compare-baseline=FILE compares the verdict of every test
+with the verdict in a previous results file, as soon as the test completes.
+Regressions, tests which passed in the baseline but fail now, are reported
+immediately and fail the run. New tests, and tests of the baseline which did
+not run, are listed at the end of the run. They only fail the run with
+--strict-baseline:
+
+	ntt run --compare-baseline=main/test_results.json --strict-baseline
+
+With --affected=FILE only tests are run, whose fingerprint differs from the one
+in results FILE, or which are not part of it. A fingerprint is a hash of the
+definition of a test and of all definitions it depends on, directly or
+transitively. Formatting and comments do not change fingerprints. Changes
+outside of TTCN-3 sources, like module parameters, C plugins or the stdlib, are
+not detected. Fingerprints are recorded in the results file of runs with
+--affected only. If FILE has no fingerprints, all tests are run:
+
+	ntt run --affected=main/test_results.json
+
+Tests slowly growing towards their timeout may be spotted early with
+--warn-slow=DURATION. Tests running longer than DURATION are not stopped, but
+marked as slow in the results file and listed at the end of the run, slowest
+first. The summary file counts them.
+
+Tests, which fail now and then, for example because of network problems, may
+be retried with --retry=N. A job, which does not pass, is run up to N more
+times. Only the last attempt counts: it is recorded in the results file, with
+the number of attempts, and counts towards --max-fail and the exit code.
+Earlier attempts are not reported, they are only logged at debug level
+(NTT_DEBUG=1). Retries are queued like any other job: they run in parallel
+with the remaining jobs, within the limits of --max-workers and --max-weight.
+
+The --on-failure option controls what happens when a test fails: "continue"
+(the default) runs the remaining tests, "stop" stops after the first failure,
+like --max-fail=1. "pause" asks whether to continue, abort the run or retry
+the failed test. Tests already running continue while the question is open,
+but their output is held back. When ntt is not connected to a terminal,
+"pause" does not ask and continues.
+
+
+With --symbols=ascii or --symbols=unicode the results in text and plain output
+are prefixed with a status symbol, like PASS, FAIL, WARN (inconc, none) and
+SKIP, or ✓, ✗ and ~. This eases scanning long logs. Symbols do not depend on
+colors, ASCII symbols are also useful when colors are disabled.
+
+
+For CI systems like Jenkins, --junit writes the results as JUnit XML document
+at the end of the run, with one test suite per module. With --output-dir the
+document is written to DIR/junit.xml and nothing is printed to stdout.
+
+
+A reporter plugin (--reporter-plugin) is an external program receiving test
+events and results as JSON objects, one per line, on its standard input. It is
+started once, in addition to the regular output, and runs until all tests
+finished:
+
+	{"event": "begin", "time": 1600000000}
+	{"event": "start", "time": 1600000000, "job_id": "test.A-1", "name": "test.A"}
+	{"event": "run", "time": 1600000001, "run": {"name": "test.A", "verdict": "pass", ...}}
+	{"event": "finish", "time": 1600000001}
+
+Failures of the plugin are reported as warnings, unless --reporter-strict is
+given.
+
+
+The first interrupt (^C) stops dispatching new tests and waits for the running
+ones to finish, the second exits immediately without writing results. With
+--shutdown-timeout=DURATION tests still running DURATION after the first
+interrupt are abandoned and the results collected so far are written. The k3
+runtime of a test and all processes it spawned are killed already at the first
+interrupt. Hooks still running are killed, when ntt exits (on Linux); processes
+spawned by hooks may keep running.
+
+For very long runs, --results-stream=FILE writes every completed test run to
+FILE as it happens, as JSON object per line. Unlike the results file, which is
+written at the end of the run, FILE keeps the results of runs, which are
+aborted or killed.
+
 `,
 
 		RunE: runTests,
 	}
 
-	RunAllTests bool
-	MaxWorkers  int
-	MaxFail     int
-	errorCount  uint64
-	OutputDir   string
+	RunAllTests  bool
+	Retry        int
+	Shuffle      bool
+	Seed         int64
+	MaxWorkers   int
+	MaxFail      int
+	errorCount   uint64
+	OutputDir    string
+	OutputLayout string
+	JSONPretty   bool
+	SummaryFile  string
+	ErrorOnNone  bool
+	IsolateTmp   bool
+	KeepWorkdir  bool
+	FailUnder    float64
+	NoGitMeta    bool
+	WriteRetries int
+	MaxWeight    int
+	DryRun       bool
+	PrintEnv     bool
+	SecretVars   []string
+	GroupSummary bool
+	RampUp       time.Duration
+	OnFailure    string
+	FailLog      string
+	WorkerLogDir string
+
+	IncludeSubtests bool
+	Symbols         string
+	Selectors       []string
+	Shard           string
+	RepeatUntil     string
+	MaxIterations   int
+	RepeatFor       time.Duration
+	Repeat          int
+	WarnSlow        time.Duration
+	Slowest         int
+	RedactPatterns  []string
+	StatusAddr      string
+	QuarantineFile  string
+	OnFailHooks     []string
+	BaselineFile    string
+	StrictBaseline  bool
+	AffectedFile    string
+
+	ReporterPlugin string
+	ReporterStrict bool
+	ResultsStream  string
+
+	ShutdownTimeout time.Duration
+
+	// secretPatterns match the names of variables with secret values.
+	secretPatterns = []string{`(?i)passw(or)?d|secret|token|credential|api_?key`}
 
 	ColorFatal   = color.New(color.FgRed, color.Bold)
 	ColorFailure = color.New(color.FgRed, color.Bold)
@@ -89,24 +392,237 @@ func init() {
 	flags.AddFlagSet(BasketFlags())
 	flags.IntVarP(&MaxWorkers, "jobs", "j", runtime.NumCPU(), "Allow N test in parallel (default: number of CPU cores")
 	flags.IntVar(&MaxFail, "max-fail", 0, "Stop after N failures")
+	flags.IntVar(&Retry, "retry", 0, "run jobs, which do not pass, up to N more times. Only the last attempt counts")
 	flags.StringVarP(&OutputDir, "output-dir", "o", "", "store test artefacts in DIR/ID")
+	flags.StringVar(&OutputLayout, "output-layout", "", "store test artefacts in DIR/LAYOUT, LAYOUT being a template like {{.ID}}/{{.Attempt}}")
 	flags.BoolVarP(&outputProgress, "progress", "P", false, "show progress")
 	flags.BoolVarP(&RunAllTests, "all-tests", "a", false, "run all tests instead of control parts")
+	flags.BoolVar(&JSONPretty, "json-pretty", false, "indent JSON output for human readers (use with --json)")
+	flags.StringVar(&SummaryFile, "summary-file", "", "write aggregate verdict counts to FILE")
+	flags.StringVar(&FailLog, "fail-log", "", "write the names of all tests, which did not pass, to FILE (usable with --tests-file)")
+	flags.BoolVar(&ErrorOnNone, "error-on-none", false, "treat tests finishing with verdict none as failure")
+	flags.BoolVar(&IsolateTmp, "isolate-tmp", false, "give each test a private TMPDIR below its working directory")
+	flags.BoolVar(&KeepWorkdir, "keep-workdir", false, "do not remove private temporary directories after tests")
+	flags.Float64Var(&FailUnder, "fail-under", 0, "fail if less than PCT percent of the tests pass. Failing this gate or --max-fail fails the run")
+	flags.BoolVar(&NoGitMeta, "no-git-meta", false, "do not record the git commit of the test suite in the results file")
+	flags.BoolVar(&DryRun, "dry-run", false, "list the jobs which would be run and their tags, without building or running anything")
+	flags.BoolVar(&PrintEnv, "print-env", false, "print the environment of each job (requires --dry-run)")
+	flags.StringSliceVar(&SecretVars, "secret-pattern", secretPatterns, "redact values of environment variables with names matching regular expression")
+	flags.IntVar(&MaxWeight, "max-weight", 0, "limit the summed weight of parallel tests to N. Tests are weighted by their @weight tag (default 1)")
+	flags.BoolVar(&GroupSummary, "group-summary", false, "print pass/fail counts per module at the end of the run and add them to the results and summary file")
+	flags.StringVar(&Symbols, "symbols", "none", "prefix test results in text and plain output with status symbols: none, ascii or unicode")
+	flags.StringVar(&OnFailure, "on-failure", "continue", "what to do when a test fails: continue, stop or pause")
+	flags.StringVar(&WorkerLogDir, "worker-log-dir", "", "write a diagnostic log per parallel job to DIR/worker-N.log")
+	flags.DurationVar(&RampUp, "ramp-up", 0, "spread the start of the parallel jobs over DURATION, for example 30s")
+	flags.StringVar(&ReporterPlugin, "reporter-plugin", "", "stream test events and results as NDJSON to the standard input of `command`")
+	flags.BoolVar(&ReporterStrict, "reporter-strict", false, "fail the run when the reporter plugin fails")
+	flags.DurationVar(&ShutdownTimeout, "shutdown-timeout", 0, "after the first interrupt, stop tests still running after DURATION and write partial results")
+	flags.StringVar(&ResultsStream, "results-stream", "", "append each completed test run to FILE as JSON line, keeping partial results of aborted runs")
+	flags.BoolVar(&excludeImports, "exclude-imports", false, "do not run tests located in import directories")
+	flags.StringVar(&RepeatUntil, "repeat-until", "", "repeat the tests until an iteration fails (\"fail\")")
+	flags.IntVar(&MaxIterations, "max-iterations", 0, "stop --repeat-until after N iterations")
+	flags.IntVar(&Repeat, "repeat", 1, "run each test N times")
+	flags.DurationVar(&RepeatFor, "repeat-for", 0, "stop --repeat-until after DURATION. The running iteration is completed")
+	flags.StringArrayVar(&OnFailHooks, "on-fail", nil, "invoke ACTION (command or webhook URL) as soon as a test matching PATTERN fails. Format: PATTERN=ACTION")
+	flags.StringVar(&BaselineFile, "compare-baseline", "", "compare verdicts with results FILE as tests complete and fail the run on regressions")
+	flags.BoolVar(&StrictBaseline, "strict-baseline", false, "also fail the run when tests were added or removed compared to the baseline")
+	flags.StringVar(&AffectedFile, "affected", "", "run only tests whose definitions changed compared to results FILE")
+	flags.StringVar(&QuarantineFile, "quarantine", "", "run the known flaky tests listed in FILE, but do not fail the run when they fail")
+	flags.StringVar(&StatusAddr, "status-server", "", "serve the live status of the run as JSON via HTTP on ADDR, for example :8080 (bound to localhost)")
+	flags.StringArrayVar(&RedactPatterns, "redact", nil, "replace matches of regular expression in failure reasons and test output with *** (in addition to the manifest's redact list)")
+	flags.DurationVar(&WarnSlow, "warn-slow", 0, "flag tests running longer than DURATION as slow, without stopping them")
+	flags.IntVar(&Slowest, "slowest", 0, "print the N slowest tests and their durations to stderr at the end of the run")
+	flags.BoolVar(&Shuffle, "shuffle", false, "run the jobs in random order")
+	flags.Int64Var(&Seed, "seed", 0, "seed for the random order of --shuffle (default: random)")
+	seedFlag = flags.Lookup("seed")
+	flags.StringVar(&Shard, "shard", "", "run only shard I of N of the jobs, given as I/N, for example 1/8")
+	flags.StringArrayVar(&Selectors, "select", nil, "run only jobs with label KEY=VALUE, or with label KEY. Multiple selectors must all match")
+	flags.BoolVar(&IncludeSubtests, "include-subtests", false, "run each value of a @subtests tag as a separate test")
+	flags.IntVar(&WriteRetries, "results-retries", 3, "retry writing the results file N times before falling back to the temporary directory")
 	flags.StringSliceVarP(&testsFiles, "tests-file", "t", nil, "read tests from FILE. If this option is used multiple times all contained tests will be executed in that order. When FILE is '-', read standard input")
 }
 
+// seedFlag tells whether --seed was given. RunCommand cannot be used by
+// runFlagConflicts without an initialization cycle.
+var seedFlag *pflag.Flag
+
+// runFlagConflicts lists combinations of ntt run options, which contradict
+// each other. Without this check some options would be ignored silently.
+var runFlagConflicts = []struct {
+	flags    string
+	conflict func() bool
+	reason   string
+}{
+	{
+		flags: "--quiet, --plain, --json, --progress, --tap, --junit",
+		conflict: func() bool {
+			n := 0
+			for _, b := range []bool{outputQuiet, outputPlain, outputJSON, outputProgress, outputTAP, outputJUnit} {
+				if b {
+					n++
+				}
+			}
+			return n > 1
+		},
+		reason: "only one output format may be given",
+	},
+	{
+		flags:    "--symbols with --json or --tap",
+		conflict: func() bool { return Symbols != "none" && (outputJSON || outputTAP) },
+		reason:   "status symbols are only shown in text and plain output",
+	},
+	{
+		flags:    "--symbols with --junit",
+		conflict: func() bool { return Symbols != "none" && outputJUnit },
+		reason:   "status symbols are only shown in text and plain output",
+	},
+	{
+		flags:    "--seed without --shuffle",
+		conflict: func() bool { return seedFlag.Changed && !Shuffle },
+		reason:   "there is no random order to seed",
+	},
+	{
+		flags:    "--json-pretty without --json",
+		conflict: func() bool { return JSONPretty && !outputJSON },
+		reason:   "only JSON output can be indented",
+	},
+	{
+		flags:    "--print-env without --dry-run",
+		conflict: func() bool { return PrintEnv && !DryRun },
+		reason:   "the environment is only printed for a dry run",
+	},
+	{
+		flags:    "--reporter-strict without --reporter-plugin",
+		conflict: func() bool { return ReporterStrict && ReporterPlugin == "" },
+		reason:   "there is no reporter plugin which could fail",
+	},
+	{
+		flags:    "--max-iterations or --repeat-for without --repeat-until",
+		conflict: func() bool { return (MaxIterations > 0 || RepeatFor > 0) && RepeatUntil == "" },
+		reason:   "there is nothing to repeat",
+	},
+	{
+		flags:    "--output-layout without --output-dir",
+		conflict: func() bool { return OutputLayout != "" && OutputDir == "" },
+		reason:   "the layout applies to the output directory",
+	},
+	{
+		flags:    "--keep-workdir without --isolate-tmp",
+		conflict: func() bool { return KeepWorkdir && !IsolateTmp },
+		reason:   "only private temporary directories are removed",
+	},
+	{
+		flags:    "--status-server with --dry-run",
+		conflict: func() bool { return StatusAddr != "" && DryRun },
+		reason:   "there is no run to report on",
+	},
+	{
+		flags:    "--on-fail with --dry-run",
+		conflict: func() bool { return len(OnFailHooks) > 0 && DryRun },
+		reason:   "no test is run, which could fail",
+	},
+	{
+		flags:    "--strict-baseline without --compare-baseline",
+		conflict: func() bool { return StrictBaseline && BaselineFile == "" },
+		reason:   "there is no baseline to compare with",
+	},
+	{
+		flags:    "--results-stream with --dry-run",
+		conflict: func() bool { return ResultsStream != "" && DryRun },
+		reason:   "there are no results to stream",
+	},
+	{
+		flags:    "--compare-baseline with --dry-run",
+		conflict: func() bool { return BaselineFile != "" && DryRun },
+		reason:   "there are no verdicts to compare",
+	},
+}
+
+// checkRunFlags returns an error listing all conflicting options.
+func checkRunFlags() error {
+	var msgs []string
+	for _, c := range runFlagConflicts {
+		if c.conflict() {
+			msgs = append(msgs, fmt.Sprintf("%s: %s", c.flags, c.reason))
+		}
+	}
+	switch len(msgs) {
+	case 0:
+		return nil
+	case 1:
+		return fmt.Errorf("conflicting options %s", msgs[0])
+	default:
+		return fmt.Errorf("conflicting options:\n\t%s", strings.Join(msgs, "\n\t"))
+	}
+}
+
 // Run runs the given jobs in parallel.
 func runTests(cmd *cobra.Command, args []string) error {
 	ctx, cancel := WithSignalHandler(context.Background())
 	defer cancel()
 
+	if err := checkRunFlags(); err != nil {
+		return err
+	}
+	switch OnFailure {
+	case "continue", "stop", "pause":
+	default:
+		return fmt.Errorf("invalid --on-failure value %q: must be continue, stop or pause", OnFailure)
+	}
+	switch RepeatUntil {
+	case "", "fail":
+	default:
+		return fmt.Errorf("invalid --repeat-until value %q: must be fail", RepeatUntil)
+	}
+	if Repeat < 1 {
+		return fmt.Errorf("invalid --repeat value %d: must be at least 1", Repeat)
+	}
+	switch Symbols {
+	case "none", "ascii", "unicode":
+		printer.Symbols = Symbols
+	default:
+		return fmt.Errorf("invalid --symbols value %q: must be none, ascii or unicode", Symbols)
+	}
+	quarantine, err := readQuarantine(QuarantineFile)
+	if err != nil {
+		return err
+	}
+	redactor, err := results.NewRedactor(append(append([]string(nil), Project.Redact...), RedactPatterns...)...)
+	if err != nil {
+		return err
+	}
+	baseline, err := loadBaseline(BaselineFile)
+	if err != nil {
+		return err
+	}
+
+	// Hooks do not use ctx, because aborting the run, for example with
+	// --on-failure=stop, would cancel the alert of the failure causing it.
+	var hooks control.VerdictHooks
+	hookCtx := context.Background()
+	for _, s := range OnFailHooks {
+		pattern, action, ok := strings.Cut(s, "=")
+		if !ok {
+			return fmt.Errorf("invalid --on-fail value %q: must be PATTERN=ACTION", s)
+		}
+		if err := hooks.Register(pattern, action); err != nil {
+			return err
+		}
+	}
+
 	// Assure that that project binaries are up-to-date, before we execute the tests.
-	if err := project.Build(Project); err != nil {
-		return fmt.Errorf("building test suite failed: %w", err)
+	if !DryRun {
+		if err := project.Build(Project); err != nil {
+			return fmt.Errorf("building test suite failed: %w", err)
+		}
 	}
 
 	_, ids := splitArgs(args, cmd.ArgsLenAtDash())
 
+	if excludeImports {
+		Project.ExcludeImports = true
+	}
+
 	plan, err := control.NewTestPlan(Project)
 	if err != nil {
 		return err
@@ -116,76 +632,630 @@ func runTests(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	var runs []results.Run
+	// Ordering constraints need to know all jobs in advance. Jobs skipped
+	// by @skip-if are not queued, but reported as skipped.
+	var all, conditional []*control.Job
+	for job := range jobs {
+		if job.SkipReason != "" {
+			conditional = append(conditional, job)
+			continue
+		}
+		all = append(all, job)
+	}
+	if AffectedFile != "" {
+		all = selectAffected(all, AffectedFile)
+	}
+	var shuffleSeed *int64
+	if Shuffle {
+		seed := Seed
+		if !seedFlag.Changed {
+			seed = time.Now().UnixNano()
+		}
+		fmt.Fprintf(os.Stderr, "shuffling jobs with --seed=%d\n", seed)
+		shuffleJobs(all, seed)
+		shuffleSeed = &seed
+	}
+	queue, err := control.NewOrderedQueue(all)
+	if err != nil {
+		return err
+	}
+	if len(conditional) > 0 {
+		fmt.Fprintf(os.Stderr, "%d test(s) skipped by @skip-if:\n", len(conditional))
+		for _, job := range conditional {
+			fmt.Fprintf(os.Stderr, "  %s (%s)\n", job.ID, job.SkipReason)
+		}
+	}
+	if DryRun {
+		sorted, _ := control.SortJobs(all)
+		return dryRun(sorted)
+	}
+
+	var (
+		runs        []results.Run
+		noneTests   []string
+		slowTests   []results.Run
+		quarantined []results.Run
+		regressions []results.Run
+		newTests    []string
+	)
 	os.Remove(Project.ResultsFile)
+	session := results.Session{
+		Id:              "1",
+		MaxJobs:         MaxWorkers,
+		ExpectedVerdict: "pass",
+	}
+	if !NoGitMeta {
+		session.Commit, session.Dirty = gitMeta(Project.Root)
+	}
+
+	// addRun records a completed test run and appends it to the results
+	// stream, if any. Streaming stops at the first write error.
+	var stream *results.Stream
+	if ResultsStream != "" {
+		if stream, err = results.CreateStream(ResultsStream); err != nil {
+			return err
+		}
+		defer func() {
+			if stream != nil {
+				if err := stream.Close(); err != nil {
+					ColorWarning.Fprintf(os.Stderr, "warning: writing results stream failed: %s\n", err.Error())
+				}
+			}
+		}()
+	}
+	addRun := func(r results.Run) {
+		runs = append(runs, r)
+		if stream == nil {
+			return
+		}
+		if err := stream.Write(r); err != nil {
+			ColorWarning.Fprintf(os.Stderr, "warning: writing results stream failed: %s\n", err.Error())
+			stream.Close()
+			stream = nil
+		}
+	}
 	defer func() {
+		session.Runs = runs
+		if GroupSummary {
+			session.Modules = results.SummarizeModules(runs)
+		}
 		db := &results.DB{
-			Version: "1",
-			Sessions: []results.Session{
-				{
-					Id:              "1",
-					MaxJobs:         MaxWorkers,
-					ExpectedVerdict: "pass",
-					Runs:            runs,
-				},
-			},
+			Version:  "1",
+			Sessions: []results.Session{session},
 		}
 		b, err := json.MarshalIndent(db, "", "  ")
 		if err != nil {
 			return
 		}
-		err = ioutil.WriteFile(Project.ResultsFile, b, 0644)
+		if _, err := results.WriteFile(Project.ResultsFile, b, WriteRetries); err != nil {
+			ColorWarning.Fprintf(os.Stderr, "warning: writing results file failed: %s\n", err.Error())
+		}
+
+		if FailLog != "" {
+			var b bytes.Buffer
+			for _, name := range failedTests(runs) {
+				fmt.Fprintln(&b, name)
+			}
+			if _, err := results.WriteFile(FailLog, b.Bytes(), WriteRetries); err != nil {
+				ColorWarning.Fprintf(os.Stderr, "warning: writing fail log failed: %s\n", err.Error())
+			}
+		}
+
+		if SummaryFile != "" {
+			b, err := json.MarshalIndent(runSummary(runs, shuffleSeed), "", "  ")
+			if err != nil {
+				return
+			}
+			if _, err := results.WriteFile(SummaryFile, b, WriteRetries); err != nil {
+				ColorWarning.Fprintf(os.Stderr, "warning: writing summary file failed: %s\n", err.Error())
+			}
+		}
 	}()
 
-	runner, err := control.New(
-		control.MaxWorkers(MaxWorkers),
-		control.WithFactory(k3r.Factory(jobs)),
-	)
-	if err != nil {
+	if err := control.RunHook(ctx, Project, control.BeforeAll); err != nil {
 		return err
 	}
 
+	// after_all is called with a fresh context, because we want to tear
+	// down the suite even when the run was interrupted.
+	defer func() {
+		if err := control.RunHook(context.Background(), Project, control.AfterAll); err != nil {
+			ColorWarning.Fprintf(os.Stderr, "warning: %s\n", err.Error())
+		}
+	}()
+
+	var opts []k3r.Option
+	if MaxWeight > 0 {
+		opts = append(opts, k3r.WithSemaphore(semaphore.NewWeighted(int64(MaxWeight))))
+	}
+
 	var p printer.Printer
 	switch Format() {
 	case "plain":
 		p = printer.NewPlainPrinter()
 	case "json":
-		p = printer.NewJSONPrinter()
+		jp := printer.NewJSONPrinter()
+		jp.Pretty = JSONPretty
+		p = jp
 	case "tap":
 		p = printer.NewTAPPrinter()
+	case "junit":
+		// With output directory the document is written there,
+		// otherwise to stdout.
+		file := ""
+		if OutputDir != "" {
+			file = filepath.Join(OutputDir, "junit.xml")
+		}
+		p = printer.NewJUnitPrinter(file)
 	default:
 		p = printer.NewConsolePrinter()
 	}
 
-	for e := range runner.Run(ctx) {
-		p.Print(e)
+	// Some formats, like JUnit, need the final results of the tests.
+	reporter, _ := p.(printer.Reporter)
+
+	var plugin *printer.PluginPrinter
+	if ReporterPlugin != "" {
+		plugin, err = printer.NewPluginPrinter(ReporterPlugin)
+		if err != nil {
+			if ReporterStrict {
+				return err
+			}
+			ColorWarning.Fprintf(os.Stderr, "warning: %s\n", err.Error())
+		}
+	}
+
+	var status *printer.StatusServer
+	if StatusAddr != "" {
+		status, err = printer.NewStatusServer(StatusAddr)
+		if err != nil {
+			return fmt.Errorf("status server: %w", err)
+		}
+		defer status.Close()
+		fmt.Fprintf(os.Stderr, "status server listening on http://%s/\n", status.Addr())
+	}
+
+	// iteration counts the repetitions of --repeat-until. It is zero
+	// for regular runs.
+	iteration := 0
+	if RepeatUntil != "" {
+		iteration = 1
+	}
+	for _, job := range all {
+		job.Iteration = iteration
+	}
+
+	// compareBaseline reports regressions as they happen. Runs, which do
+	// not fail the run, like quarantined tests, are no regressions.
+	compareBaseline := func(r results.Run, failed bool) {
+		if baseline == nil {
+			return
+		}
+		switch baseline.Compare(r) {
+		case results.Regression:
+			if failed {
+				was, _ := baseline.Verdict(r.Name)
+				ColorFailure.Fprintf(os.Stderr, "regression: %s: %s in baseline, now %s\n", r.Name, was, r.Verdict)
+				regressions = append(regressions, r)
+			}
+		case results.NewTest:
+			newTests = append(newTests, r.Name)
+		}
+	}
+
+	// attempts counts the retries of jobs (--retry).
+	attempts := make(map[*control.Job]int)
+
+	// started records when jobs started, for runs ending with an error.
+	started := make(map[*control.Job]time.Time)
+
+	// retrying holds the jobs to be run again, once the runner is done with
+	// their failed attempt. Until then, the old attempt might still run
+	// hooks or remove its temporary directory.
+	retrying := make(map[*control.Job]bool)
+
+	// stopped holds the jobs, whose current attempt got a verdict already.
+	// k3r might still exit with an error afterwards, which must not count
+	// as another run.
+	stopped := make(map[*control.Job]bool)
+
+	// retry schedules job to run once more, if it has retries left. It
+	// returns false, if the failed attempt counts.
+	retry := func(job *control.Job, verdict string) bool {
+		if job == nil || attempts[job] >= Retry {
+			return false
+		}
+		if !retrying[job] {
+			retrying[job] = true
+			attempts[job]++
+			log.Debugf("%s: verdict %s, retrying (%d/%d)\n", job.ID, verdict, attempts[job], Retry)
+		}
+		return true
+	}
+
+	handle := func(e control.Event) bool {
+		abort := false
+		if len(redactor) > 0 {
+			e = control.RedactEvent(e, redactor.Redact)
+		}
+
+		// Retried jobs are queued again, when the runner is done with
+		// them. Further events of a failed attempt, like the error of
+		// a k3r exiting after its verdict, are discarded like the
+		// attempt itself.
+		if e, ok := e.(control.DoneEvent); ok {
+			delete(stopped, e.Job)
+			if retrying[e.Job] {
+				delete(retrying, e.Job)
+				queue.Retry(e.Job)
+			}
+			return true
+		}
+		if job := control.UnwrapJob(e); job != nil && retrying[job] {
+			log.Debugf("%s: discarding event of failed attempt\n", job.ID)
+			return true
+		}
+		if e, ok := e.(control.ErrorEvent); ok {
+			if job := control.UnwrapJob(e); job != nil && stopped[job] {
+				log.Printf("warning: %s: %s\n", displayName(job), e.Err.Error())
+				return true
+			}
+		}
+
+		// Retries are decided before anything is reported, because
+		// only the last attempt counts. Earlier attempts are only
+		// logged at debug level (see retry).
 		switch e := e.(type) {
 		case control.ErrorEvent:
-			errorCount++
+			if retry(control.UnwrapJob(e), "error") {
+				return true
+			}
 		case control.StopEvent:
-			if e.Verdict != "pass" && e.Verdict != "done" {
+			verdict := results.NormalizeVerdict(e.Verdict)
+			if !isFailure(verdict) {
+				break
+			}
+			if retry(e.Job, string(verdict)) {
+				return true
+			}
+
+			// With --on-failure=pause the user decides, before the
+			// attempt is reported. Quarantined tests do not pause.
+			if OnFailure == "pause" && !isQuarantined(quarantine, e.Job) && canPrompt() {
+				switch promptFailure(displayName(e.Job), verdict) {
+				case "abort":
+					abort = true
+				case "retry":
+					// The failed attempt is discarded. Only the
+					// verdict of the retry counts.
+					log.Debugf("%s: verdict %s, retrying on request\n", e.Job.ID, verdict)
+					retrying[e.Job] = true
+					return true
+				}
+			}
+		}
+
+		p.Print(e)
+		if plugin != nil {
+			plugin.Print(e)
+		}
+		if status != nil {
+			status.Print(e)
+		}
+		switch e := e.(type) {
+		case control.StartEvent:
+			started[e.Job] = e.Time()
+		case control.ErrorEvent:
+			job := control.UnwrapJob(e)
+			// Timeouts and crashes of quarantined tests do not
+			// count either.
+			inQuarantine := isQuarantined(quarantine, job)
+			if !inQuarantine {
 				errorCount++
 			}
+			if job != nil {
+				// Timeouts and crashes are runs, too, with
+				// verdict error. The printers got the error
+				// event already.
+				r := results.Run{
+					Name:        displayName(job),
+					Verdict:     results.ErrorVerdict,
+					Reason:      e.Err.Error(),
+					Begin:       results.Timestamp{Time: started[job]},
+					End:         results.Timestamp{Time: e.Time()},
+					Iteration:   iteration,
+					Fingerprint: job.Fingerprint,
+					Quarantined: inQuarantine,
+				}
+				if r.Begin.IsZero() {
+					r.Begin = r.End
+				}
+				if n := attempts[job]; n > 0 {
+					r.Attempts = n + 1
+				}
+				r = redactor.RedactRun(r)
+				if inQuarantine {
+					quarantined = append(quarantined, r)
+				}
+				hooks.Fire(hookCtx, job.Name, string(results.ErrorVerdict), e.Err.Error())
+				compareBaseline(r, !inQuarantine)
+				addRun(r)
+			}
+			queue.Done(job, false)
+		case control.StopEvent:
+			stopped[e.Job] = true
+			verdict := results.NormalizeVerdict(e.Verdict)
+			name := displayName(e.Job)
 			r := results.Run{
-				Name:       e.Name,
-				Verdict:    e.Verdict,
-				Begin:      results.Timestamp{Time: e.Begin},
-				End:        results.Timestamp{Time: e.Time()},
-				WorkingDir: e.Job.Dir,
+				Name:        name,
+				Verdict:     verdict,
+				Begin:       results.Timestamp{Time: e.Begin},
+				End:         results.Timestamp{Time: e.Time()},
+				WorkingDir:  e.WorkDir,
+				Iteration:   iteration,
+				Fingerprint: e.Job.Fingerprint,
+			}
+			if WarnSlow > 0 && r.Duration() > WarnSlow {
+				r.Slow = true
+			}
+			r.Quarantined = isQuarantined(quarantine, e.Job)
+			failed := isFailure(verdict)
+			if verdict == results.UnknownVerdict {
+				// We cannot tell whether the test passed.
+				ColorWarning.Fprintf(os.Stderr, "warning: %s: unknown verdict %q\n", name, e.Verdict)
+				r.Reason = fmt.Sprintf("unknown verdict %q", e.Verdict)
+			}
+			if n := attempts[e.Job]; n > 0 {
+				r.Attempts = n + 1
+			}
+			r = redactor.RedactRun(r)
+			if reporter != nil {
+				reporter.Report(r)
+			}
+			if plugin != nil {
+				plugin.Report(r)
+			}
+
+			// Failures of quarantined tests are reported, but
+			// do not count. Tests depending on them are still
+			// skipped, though.
+			passed := !failed
+			if failed {
+				hooks.Fire(hookCtx, name, string(verdict), r.Reason)
+			}
+			if failed && r.Quarantined {
+				quarantined = append(quarantined, r)
+				failed = false
+			}
+
+			if abort {
+				errorCount++
+				addRun(r)
+				return false
 			}
-			runs = append(runs, r)
 
+			if verdict == results.NoneVerdict {
+				noneTests = append(noneTests, name)
+			}
+			if r.Slow {
+				slowTests = append(slowTests, r)
+			}
+			if failed {
+				errorCount++
+			}
+			compareBaseline(r, failed)
+			addRun(r)
+			queue.Done(e.Job, passed)
 		}
 
+		if OnFailure == "stop" && errorCount > 0 {
+			p.Print(control.NewErrorEvent(fmt.Errorf("stopping after first failure.")))
+			return false
+		}
 		if MaxFail > 0 && errorCount >= uint64(MaxFail) {
 			p.Print(control.NewErrorEvent(fmt.Errorf("too many errors. Exiting.")))
-			cancel()
+			return false
+		}
+		return true
+	}
+
+	// Tests skipped by @skip-if do not apply to this environment.
+	for _, job := range conditional {
+		name := job.Name
+		if job.Subtest != "" {
+			name = job.Subtest
+		}
+		r := redactor.RedactRun(results.Run{
+			Name:      name,
+			Verdict:   results.SkippedVerdict,
+			Reason:    job.SkipReason,
+			Iteration: iteration,
+		})
+		compareBaseline(r, false)
+		if reporter != nil {
+			reporter.Report(r)
+		}
+		addRun(r)
+	}
+
+	// Retry rounds and iterations append to the worker logs of this run.
+	if WorkerLogDir != "" {
+		if err := control.RotateWorkerLogs(WorkerLogDir); err != nil {
+			return err
+		}
+	}
+
+	// Only the start of the run is ramped up, not retry rounds or
+	// iterations.
+	rampUp := RampUp
+
+	repeatStart := time.Now()
+	for {
+		errorsBefore := errorCount
+		aborted := false
+		for {
+			runner, err := control.New(
+				control.MaxWorkers(MaxWorkers),
+				control.RampUp(rampUp),
+				control.WorkerLogDir(WorkerLogDir),
+				control.WithFactory(k3r.Factory(queue.Jobs(ctx), opts...)),
+			)
+			if err != nil {
+				return err
+			}
+			rampUp = 0
+			events := runner.Run(ctx)
+			deadline := shutdownDeadline(ctx, ShutdownTimeout)
+		drain:
+			for {
+				select {
+				case e, ok := <-events:
+					if !ok {
+						break drain
+					}
+					if !handle(e) {
+						cancel()
+						aborted = true
+						break drain
+					}
+				case <-deadline:
+					ColorWarning.Fprintf(os.Stderr, "warning: running tests did not finish within %s after interrupt. Stopping.\n", ShutdownTimeout)
+					errorCount++
+					aborted = true
+					break drain
+				}
+			}
+
+			// Jobs retried after the last job was dispatched
+			// need another round.
+			if aborted || ctx.Err() != nil || queue.Len() == 0 {
+				break
+			}
+		}
+
+		// Tests with failed prerequisites were not started.
+		if skipped := queue.Skipped(); len(skipped) > 0 {
+			ColorWarning.Fprintf(os.Stderr, "warning: %d test(s) skipped, because prerequisites did not pass:\n", len(skipped))
+			for _, job := range skipped {
+				ColorWarning.Fprintf(os.Stderr, "  %s (after %s)\n", job.ID, strings.Join(job.After, ", "))
+				name := job.Name
+				if job.Subtest != "" {
+					name = job.Subtest
+				}
+				r := redactor.RedactRun(results.Run{
+					Name:      name,
+					Verdict:   results.SkippedVerdict,
+					Reason:    "prerequisites did not pass: " + strings.Join(job.After, ", "),
+					Iteration: iteration,
+				})
+				compareBaseline(r, false)
+				if reporter != nil {
+					reporter.Report(r)
+				}
+				addRun(r)
+			}
+		}
+
+		if RepeatUntil == "" || aborted || ctx.Err() != nil {
+			break
+		}
+		if errorCount > errorsBefore {
+			ColorFailure.Fprintf(os.Stderr, "iteration %d failed\n", iteration)
+			break
+		}
+		if MaxIterations > 0 && iteration >= MaxIterations || RepeatFor > 0 && time.Since(repeatStart) >= RepeatFor {
+			fmt.Fprintf(os.Stderr, "all %d iterations passed\n", iteration)
 			break
 		}
+
+		// Dispatch the same jobs again. The queue tracks jobs by
+		// identity, hence we need fresh copies. Each iteration starts
+		// with the first attempt again.
+		iteration++
+		for i, job := range all {
+			clone := *job
+			clone.Attempt = 0
+			clone.Iteration = iteration
+			all[i] = &clone
+		}
+		if queue, err = control.NewOrderedQueue(all); err != nil {
+			return err
+		}
 	}
 
 	if c, ok := p.(io.Closer); ok {
-		c.Close()
+		if err := c.Close(); err != nil {
+			ColorWarning.Fprintf(os.Stderr, "warning: %s\n", err.Error())
+		}
+	}
+
+	if plugin != nil {
+		if err := plugin.Close(); err != nil {
+			ColorWarning.Fprintf(os.Stderr, "warning: %s\n", err.Error())
+			if ReporterStrict {
+				errorCount++
+			}
+		}
+	}
+
+	// Alerts should not get lost when ntt exits.
+	hooks.Wait()
+
+	switch Format() {
+	case "text", "plain":
+		if GroupSummary {
+			printModuleSummary(os.Stdout, results.SummarizeModules(runs))
+		}
+		printRunSummary(os.Stdout, runs)
+	}
+
+	// Tests without verdict usually do nothing at all.
+	if len(noneTests) > 0 {
+		ColorWarning.Fprintf(os.Stderr, "warning: %d test(s) finished with verdict none:\n", len(noneTests))
+		for _, name := range noneTests {
+			ColorWarning.Fprintf(os.Stderr, "  %s\n", name)
+		}
+	}
+
+	// Slow tests are likely to hit the timeout sooner or later.
+	if len(slowTests) > 0 {
+		sort.SliceStable(slowTests, func(i, j int) bool {
+			return slowTests[i].Duration() > slowTests[j].Duration()
+		})
+		ColorWarning.Fprintf(os.Stderr, "warning: %d test(s) took longer than %s:\n", len(slowTests), WarnSlow)
+		for _, r := range slowTests {
+			ColorWarning.Fprintf(os.Stderr, "  %s (%s)\n", r.Name, r.Duration().Round(time.Millisecond))
+		}
+	}
+
+	if Slowest > 0 && len(runs) > 0 {
+		slowest := slowestRuns(runs, Slowest)
+		fmt.Fprintf(os.Stderr, "%d slowest test(s):\n", len(slowest))
+		for _, r := range slowest {
+			fmt.Fprintf(os.Stderr, "  %s (%s)\n", r.Name, r.Duration().Round(time.Millisecond))
+		}
+	}
+
+	// Quarantined tests are listed separately, because they are
+	// expected to fail now and then.
+	if len(quarantined) > 0 {
+		ColorWarning.Fprintf(os.Stderr, "quarantined: %d test(s) failed, but do not fail the run:\n", len(quarantined))
+		for _, r := range quarantined {
+			ColorWarning.Fprintf(os.Stderr, "  %s\t%s\n", r.Verdict, r.Name)
+		}
+	}
+
+	if baseline != nil {
+		printBaselineChanges(regressions, newTests, baseline.Removed())
+	}
+
+	if err := checkFailUnder(runs, FailUnder); err != nil {
+		return err
+	}
+	if baseline != nil {
+		if err := checkBaseline(len(regressions), len(newTests), len(baseline.Removed()), StrictBaseline); err != nil {
+			return err
+		}
 	}
 
 	if errorCount > 0 {
@@ -206,7 +1276,22 @@ func JobQueue(ctx context.Context, plan *control.TestPlan, flags *pflag.FlagSet,
 		return nil, fmt.Errorf("loading baskets failed: %w", err)
 	}
 
-	var tsts []string
+	sel, err := control.ParseLabels(strings.Join(Selectors, " "))
+	if err != nil {
+		return nil, fmt.Errorf("invalid --select: %w", err)
+	}
+	shard, shards, err := parseShard(Shard)
+	if err != nil {
+		return nil, err
+	}
+	var layout *control.Layout
+	if OutputLayout != "" {
+		if layout, err = control.ParseLayout(OutputLayout); err != nil {
+			return nil, fmt.Errorf("invalid --output-layout: %w", err)
+		}
+	}
+
+	var tsts []testEntry
 	for _, f := range testsFiles {
 		t, err := readTestsFromFile(f)
 		if err != nil {
@@ -214,7 +1299,7 @@ func JobQueue(ctx context.Context, plan *control.TestPlan, flags *pflag.FlagSet,
 		}
 		tsts = append(tsts, t...)
 	}
-	srcs, err := fs.TTCN3Files(conf.Sources...)
+	srcs, err := project.TestFiles(conf)
 	if err != nil {
 		return nil, err
 	}
@@ -232,6 +1317,17 @@ func JobQueue(ctx context.Context, plan *control.TestPlan, flags *pflag.FlagSet,
 				modLvl, lvl int
 			)
 			root := ttcn3.ParseFile(src)
+
+			// Source files may vanish while we enumerate them (e.g. in
+			// watched workspaces). Skip them instead of failing the
+			// whole run.
+			if root.Root == nil {
+				log.Printf("warning: skipping %s: %s\n", src, root.Err)
+				return
+			}
+			if root.Err != nil {
+				log.Printf("warning: %s\n", ttcn3.FormatError(root.Err))
+			}
 			root.Inspect(func(n syntax.Node) bool {
 				if n == nil {
 					if lvl == modLvl {
@@ -252,7 +1348,7 @@ func JobQueue(ctx context.Context, plan *control.TestPlan, flags *pflag.FlagSet,
 						return false
 					}
 					name := ttcn3.JoinNames(mod, n.Name.String())
-					m.Store(name, n)
+					m.Store(name, &ttcn3.Node{Node: n, Tree: root})
 					if needTests {
 						if n.IsTest() && allTests || n.IsControl() && !allTests {
 							t[i] = append(t[i], name)
@@ -261,7 +1357,7 @@ func JobQueue(ctx context.Context, plan *control.TestPlan, flags *pflag.FlagSet,
 					return false
 				case *syntax.ControlPart:
 					name := ttcn3.JoinNames(mod, n.Name.String())
-					m.Store(name, n)
+					m.Store(name, &ttcn3.Node{Node: n, Tree: root})
 					if needTests && !allTests {
 						t[i] = append(t[i], name)
 					}
@@ -276,25 +1372,96 @@ func JobQueue(ctx context.Context, plan *control.TestPlan, flags *pflag.FlagSet,
 	wg.Wait()
 	log.Debugf("Scanned all tests in %s.\n", time.Since(start))
 
-	testPlan := append(tsts, tests...)
+	testPlan := tsts
+	for _, name := range tests {
+		testPlan = append(testPlan, testEntry{Name: name})
+	}
 	if needTests {
 		for _, tests := range t {
-			testPlan = append(testPlan, tests...)
+			for _, name := range tests {
+				testPlan = append(testPlan, testEntry{Name: name})
+			}
 		}
 	}
 
 	out := make(chan *control.Job)
 	go func() {
 		defer close(out)
+		// Fingerprints need all sources and imports indexed, hence
+		// they are only computed when needed.
+		var fingerprints map[string]string
+		if AffectedFile != "" {
+			fingerprints = fingerprintTests(conf, &m, testPlan)
+		}
 		names := make(map[string]int)
-		for _, name := range testPlan {
+		for _, entry := range testPlan {
+			name := entry.Name
+			sub, isSubtest := ttcn3.ParseSubtest(name)
+			if isSubtest {
+				name = sub.Name
+			}
 			var tags [][]string
 			if def, ok := m.Load(name); ok {
-				tags = doc.FindAllTags(syntax.Doc(def.(syntax.Node)))
+				def := def.(*ttcn3.Node)
+				tags = def.Tree.DocTags(def.Node)
 			}
 			if !basket.Match(name, tags) {
 				continue
 			}
+			weight := 1
+			var after, tagNames []string
+			var skipReason string
+			labels := make(map[string]string)
+			seen := make(map[string]bool)
+			for _, tag := range tags {
+				if !seen[tag[0]] {
+					seen[tag[0]] = true
+					tagNames = append(tagNames, tag[0])
+				}
+				switch tag[0] {
+				case "@label":
+					l, err := control.ParseLabels(tag[1])
+					if err != nil {
+						log.Printf("warning: %s: %s\n", name, err.Error())
+					}
+					for k, v := range l {
+						labels[k] = v
+					}
+				case "@weight":
+					if w, err := strconv.Atoi(strings.TrimSpace(tag[1])); err == nil && w > 0 {
+						// Tests heavier than --max-weight run alone.
+						if MaxWeight > 0 && w > MaxWeight {
+							w = MaxWeight
+						}
+						weight = w
+					} else {
+						log.Printf("warning: %s: invalid weight %q\n", name, tag[1])
+					}
+				case "@after":
+					after = append(after, strings.Fields(strings.ReplaceAll(tag[1], ",", " "))...)
+				case "@skip-if":
+					cond := strings.TrimSpace(tag[1])
+					skip, err := control.EvalSkipIf(cond, func(k string) (string, bool) {
+						if v, ok := env.LookupEnv(k); ok {
+							return v, ok
+						}
+						v, ok := conf.Variables[k]
+						return v, ok
+					})
+					if err != nil {
+						log.Printf("warning: %s: %s\n", name, err.Error())
+					}
+					if skip && skipReason == "" {
+						skipReason = skipIfReason + cond
+					}
+				}
+			}
+			for k, v := range entry.Labels {
+				labels[k] = v
+			}
+			if !control.MatchLabels(labels, sel) {
+				continue
+			}
 			configs, err := conf.TestConfigs(name)
 			if err != nil {
 				log.Verbose(err.Error())
@@ -305,23 +1472,88 @@ func JobQueue(ctx context.Context, plan *control.TestPlan, flags *pflag.FlagSet,
 				continue
 			}
 
-			for _, tc := range configs {
-				id := fmt.Sprintf("%s-%d", name, names[name])
-				names[name]++
-
-				job := &control.Job{
-					ID:         id,
-					Name:       name,
-					Config:     conf,
-					Dir:        OutputDir,
-					Timeout:    tc.Timeout.Duration,
-					ModulePars: tc.Parameters,
+			// A nil subtest is the testcase itself.
+			subtests := []*ttcn3.Subtest{nil}
+			switch {
+			case isSubtest:
+				subtests = []*ttcn3.Subtest{&sub}
+			case IncludeSubtests:
+				list, err := ttcn3.Subtests(name, tags)
+				if err != nil {
+					log.Printf("warning: %s\n", err.Error())
 				}
+				if len(list) > 0 {
+					subtests = subtests[:0]
+					for i := range list {
+						subtests = append(subtests, &list[i])
+					}
+				}
+			}
 
-				select {
-				case out <- job:
-				case <-ctx.Done():
-					return
+			for _, tc := range configs {
+				for _, sub := range subtests {
+					base := name
+					if sub != nil {
+						base = sub.ID()
+					}
+					if !inShard(base, shard, shards) {
+						continue
+					}
+					for rep := 1; rep <= Repeat; rep++ {
+						id := fmt.Sprintf("%s-%d", base, names[base])
+						names[base]++
+
+						pars := tc.Parameters
+						if len(entry.Parameters) > 0 || sub != nil {
+							pars = make(map[string]string)
+							for k, v := range tc.Parameters {
+								pars[k] = v
+							}
+							for k, v := range entry.Parameters {
+								pars[k] = v
+							}
+							if sub != nil {
+								pars[sub.ModulePar()] = sub.Value
+							}
+						}
+
+						job := &control.Job{
+							ID:         id,
+							Name:       name,
+							Config:     conf,
+							Dir:        OutputDir,
+							Layout:     layout,
+							Timeout:    tc.Timeout.Duration,
+							ModulePars: pars,
+							Weight:     weight,
+							After:      append(append([]string(nil), after...), tc.After...),
+							Labels:     labels,
+							Tags:       tagNames,
+							SkipReason: skipReason,
+
+							Fingerprint: fingerprints[name],
+						}
+						if IsolateTmp {
+							job.TempDir = filepath.Join(OutputDir, id, "tmp")
+							if OutputDir == "" {
+								job.TempDir = filepath.Join(".tmp", id)
+							}
+							job.KeepTempDir = KeepWorkdir
+						}
+
+						if sub != nil {
+							job.Subtest = sub.ID()
+						}
+						if Repeat > 1 {
+							job.Subtest = fmt.Sprintf("%s#%d", base, rep)
+						}
+
+						select {
+						case out <- job:
+						case <-ctx.Done():
+							return
+						}
+					}
 				}
 			}
 		}
@@ -329,6 +1561,204 @@ func JobQueue(ctx context.Context, plan *control.TestPlan, flags *pflag.FlagSet,
 	return out, nil
 }
 
+// dryRun prints the names of the given jobs with their tags and, if
+// requested, their environment with secret values redacted. The names can be
+// passed to ntt run again. Tests appearing more than once are printed with the
+// job ID instead, to tell the instances apart.
+func dryRun(jobs []*control.Job) error {
+	secrets, err := results.NewRedactor(SecretVars...)
+	if err != nil {
+		return err
+	}
+
+	count := make(map[string]int)
+	for _, job := range jobs {
+		count[job.Name]++
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+	for _, job := range jobs {
+		name := job.Name
+		if count[name] > 1 {
+			name = job.ID
+		}
+		if len(job.Tags) > 0 {
+			fmt.Fprintf(w, "%s\t%s\n", name, strings.Join(job.Tags, " "))
+		} else {
+			fmt.Fprintln(w, name)
+		}
+		if !PrintEnv {
+			continue
+		}
+		vars, err := k3r.Environ(job)
+		if err != nil {
+			return fmt.Errorf("%s: %w", job.ID, err)
+		}
+
+		// Later definitions overwrite earlier ones.
+		m := make(map[string]string)
+		for _, kv := range vars {
+			f := strings.SplitN(kv, "=", 2)
+			if len(f) == 2 {
+				m[f[0]] = f[1]
+			}
+		}
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(w, "\t%s=%s\n", k, redact(k, m[k], secrets))
+		}
+	}
+	return nil
+}
+
+// runSummary returns the content of the summary file, including the run
+// metadata seed and shard, if set.
+func runSummary(runs []results.Run, seed *int64) results.Summary {
+	summary := results.Summarize(runs)
+	if GroupSummary {
+		summary.Modules = results.SummarizeModules(runs)
+	}
+	summary.Seed = seed
+	summary.Shard = Shard
+	return summary
+}
+
+// printModuleSummary prints a table with the aggregate counts of each module.
+func printModuleSummary(w io.Writer, modules []results.ModuleSummary) {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "MODULE\tTOTAL\tPASS\tFAIL\tINCONC\tUNKNOWN\tDURATION")
+	for _, m := range modules {
+		d := time.Duration(m.Duration * float64(time.Second)).Round(time.Millisecond)
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%d\t%s\n", m.Module, m.Total, m.Pass, m.Fail, m.Inconc, m.Unknown, d)
+	}
+	tw.Flush()
+}
+
+// printRunSummary prints a single line with the verdict counts of all runs
+// and the wall-clock duration of the test run. Runs with unknown verdict are
+// only mentioned if there are any.
+func printRunSummary(w io.Writer, runs []results.Run) {
+	var pass, fail, inconc, unknown int
+	for _, r := range runs {
+		switch results.NormalizeVerdict(string(r.Verdict)) {
+		case results.PassVerdict:
+			pass++
+		case results.InconcVerdict:
+			inconc++
+		case results.FailVerdict, results.ErrorVerdict:
+			fail++
+		case results.UnknownVerdict:
+			unknown++
+		}
+	}
+	counts := fmt.Sprintf("%d passed, %d failed, %d inconc", pass, fail, inconc)
+	if unknown > 0 {
+		counts += fmt.Sprintf(", %d unknown", unknown)
+	}
+	fmt.Fprintf(w, "=== %s in %.1fs (%d tests)\n", counts, results.Duration(runs).Seconds(), len(runs))
+}
+
+// slowestRuns returns the n runs with the longest duration, longest first.
+// Ties are broken by name.
+func slowestRuns(runs []results.Run, n int) []results.Run {
+	return results.Slowest(runs, n)
+}
+
+// skipIfReason prefixes the reason of tests skipped by @skip-if.
+const skipIfReason = "skip-if: "
+
+// failedTests returns the names of all tests with at least one run not
+// passing, in order of their first failure. Control parts finishing with
+// verdict done count as passed. Tests skipped by @skip-if do not apply to the
+// environment, unlike tests skipped because their prerequisites failed.
+func failedTests(runs []results.Run) []string {
+	var (
+		names []string
+		seen  = make(map[string]bool)
+	)
+	for _, r := range runs {
+		switch {
+		case r.Verdict == results.PassVerdict, r.Verdict == results.DoneVerdict:
+			continue
+		case r.Verdict == results.SkippedVerdict && strings.HasPrefix(r.Reason, skipIfReason):
+			continue
+		}
+		if !seen[r.Name] {
+			seen[r.Name] = true
+			names = append(names, r.Name)
+		}
+	}
+	return names
+}
+
+// isTerminal returns true if f is connected to a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+var promptInput = bufio.NewReader(os.Stdin)
+
+// canPrompt returns true if the user can be asked how to proceed.
+var canPrompt = func() bool {
+	return isTerminal(os.Stdin) && isTerminal(os.Stderr)
+}
+
+// promptFailure asks the user how to proceed after a failed test. It returns
+// "continue", "abort" or "retry". Empty input or read errors continue.
+func promptFailure(name string, verdict results.Verdict) string {
+	for {
+		ColorFailure.Fprintf(os.Stderr, "%s finished with verdict %s. ", name, verdict)
+		fmt.Fprintf(os.Stderr, "[c]ontinue, [a]bort or [r]etry? ")
+		line, err := promptInput.ReadString('\n')
+		switch strings.ToLower(strings.TrimSpace(line)) {
+		case "", "c", "continue":
+			return "continue"
+		case "a", "abort":
+			return "abort"
+		case "r", "retry":
+			return "retry"
+		}
+		if err != nil {
+			return "continue"
+		}
+	}
+}
+
+// redact returns a placeholder instead of v, if the variable name k matches
+// one of the secret patterns.
+func redact(k, v string, secrets results.Redactor) string {
+	for _, re := range secrets {
+		if re.MatchString(k) && v != "" {
+			return "<redacted>"
+		}
+	}
+	return v
+}
+
+// gitMeta returns the commit hash of the git repository containing dir and
+// whether the working tree has uncommitted changes. gitMeta returns an empty
+// commit if git is not available or dir is not inside a repository.
+func gitMeta(dir string) (string, bool) {
+	if dir == "" {
+		dir = "."
+	}
+	out, err := proc.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return "", false
+	}
+	status, err := proc.Command("git", "-C", dir, "status", "--porcelain", "--untracked-files=no").Output()
+	if err != nil {
+		return strings.TrimSpace(string(out)), false
+	}
+	return strings.TrimSpace(string(out)), len(bytes.TrimSpace(status)) > 0
+}
+
 // EntryPoints returns controls parts of the given TTCN-3 source file. When tests is true, it returns all testcases instead.
 func EntryPoints(file string, tests bool) []*ttcn3.Node {
 	tree := ttcn3.ParseFile(file)
@@ -359,7 +1789,291 @@ func WithSignalHandler(ctx context.Context) (context.Context, context.CancelFunc
 	}
 }
 
-func readTestsFromFile(path string) ([]string, error) {
+// shutdownDeadline returns a channel, which delivers the time d after ctx is
+// done. If d is not positive, the channel never delivers.
+func shutdownDeadline(ctx context.Context, d time.Duration) <-chan time.Time {
+	if d <= 0 {
+		return nil
+	}
+	ch := make(chan time.Time, 1)
+	go func() {
+		<-ctx.Done()
+		ch <- <-time.After(d)
+	}()
+	return ch
+}
+
+// isFailure returns true if a test with the given verdict fails the run,
+// unless it is quarantined. Verdict none fails only with --error-on-none.
+func isFailure(v results.Verdict) bool {
+	switch v {
+	case results.PassVerdict, results.DoneVerdict:
+		return false
+	case results.NoneVerdict:
+		return ErrorOnNone
+	default:
+		return true
+	}
+}
+
+// readQuarantine reads the qualified names of quarantined tests from file.
+// The format is that of --tests-file. An empty file name is an empty list.
+func readQuarantine(file string) (map[string]bool, error) {
+	if file == "" {
+		return nil, nil
+	}
+	tests, err := readTestsFromFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("reading quarantine file %s failed: %w", file, err)
+	}
+	ret := make(map[string]bool, len(tests))
+	for _, t := range tests {
+		ret[t.Name] = true
+	}
+	return ret, nil
+}
+
+// isQuarantined returns true, if the test of job or, for subtests and
+// repetitions, the instance is listed in quarantine.
+func isQuarantined(quarantine map[string]bool, job *control.Job) bool {
+	return job != nil && (quarantine[job.Name] || job.Subtest != "" && quarantine[job.Subtest])
+}
+
+// displayName returns the name of the test instance run by job.
+func displayName(job *control.Job) string {
+	if job.Subtest != "" {
+		return job.Subtest
+	}
+	return job.Name
+}
+
+// checkFailUnder returns an error if less than pct percent of the runs
+// passed. Quarantined runs are not counted.
+func checkFailUnder(runs []results.Run, pct float64) error {
+	if pct <= 0 {
+		return nil
+	}
+	var counted []results.Run
+	for _, r := range runs {
+		if !r.Quarantined {
+			counted = append(counted, r)
+		}
+	}
+	if rate := results.Summarize(counted).PassRate(); rate < pct {
+		return fmt.Errorf("%w: pass rate %.1f%% is below %.1f%%", ErrCommandFailed, rate, pct)
+	}
+	return nil
+}
+
+// loadBaseline loads the final verdicts of results file for
+// --compare-baseline. Unlike results.Load, a missing file is an error. An
+// empty file name is no baseline.
+func loadBaseline(file string) (*results.Baseline, error) {
+	if file == "" {
+		return nil, nil
+	}
+	if _, err := os.Stat(file); err != nil {
+		return nil, fmt.Errorf("loading baseline failed: %w", err)
+	}
+	db, err := results.Load(file)
+	if err != nil {
+		return nil, fmt.Errorf("loading baseline %s failed: %w", file, err)
+	}
+	return results.NewBaseline(db.Runs()), nil
+}
+
+// printBaselineChanges lists the changes of the run compared to the baseline.
+func printBaselineChanges(regressions []results.Run, added []string, removed []string) {
+	if len(added) > 0 {
+		ColorWarning.Fprintf(os.Stderr, "baseline: %d new test(s):\n", len(added))
+		for _, name := range added {
+			ColorWarning.Fprintf(os.Stderr, "  %s\n", name)
+		}
+	}
+	if len(removed) > 0 {
+		ColorWarning.Fprintf(os.Stderr, "baseline: %d test(s) did not run:\n", len(removed))
+		for _, name := range removed {
+			ColorWarning.Fprintf(os.Stderr, "  %s\n", name)
+		}
+	}
+	if len(regressions) > 0 {
+		ColorFailure.Fprintf(os.Stderr, "baseline: %d regression(s):\n", len(regressions))
+		for _, r := range regressions {
+			ColorFailure.Fprintf(os.Stderr, "  %s\t%s\n", r.Verdict, r.Name)
+		}
+	}
+}
+
+// checkBaseline returns an error if there are regressions. With strict, added
+// and removed tests are an error, too.
+func checkBaseline(regressions, added, removed int, strict bool) error {
+	if regressions > 0 {
+		return fmt.Errorf("%w: %d regression(s) compared to baseline", ErrCommandFailed, regressions)
+	}
+	if strict && added+removed > 0 {
+		return fmt.Errorf("%w: %d new and %d missing test(s) compared to baseline", ErrCommandFailed, added, removed)
+	}
+	return nil
+}
+
+// selectAffected returns the jobs of tests, which changed compared to results
+// file: their fingerprint differs, they are not part of file or their
+// definition is unknown. If file has no fingerprints, all jobs are returned.
+func selectAffected(jobs []*control.Job, file string) []*control.Job {
+	baseline, err := loadFingerprints(file)
+	if err != nil {
+		ColorWarning.Fprintf(os.Stderr, "warning: %s. Running all tests.\n", err.Error())
+		return jobs
+	}
+
+	var (
+		selected                []*control.Job
+		changed, added, unknown int
+	)
+	for _, job := range jobs {
+		name := job.Name
+		if job.Subtest != "" {
+			name = job.Subtest
+		}
+		var reason string
+		switch old, ok := baseline[name]; {
+		case job.Fingerprint == "":
+			unknown++
+			reason = "definition not found"
+		case !ok:
+			added++
+			reason = "not in baseline"
+		case old != job.Fingerprint:
+			changed++
+			reason = "changed"
+		default:
+			continue
+		}
+		log.Verbosef("affected: %s (%s)\n", job.ID, reason)
+		selected = append(selected, job)
+	}
+	fmt.Fprintf(os.Stderr, "affected: %d of %d test(s) selected (%d changed, %d not in baseline, %d unknown)\n",
+		len(selected), len(jobs), changed, added, unknown)
+	return selected
+}
+
+// loadFingerprints returns the fingerprints of the tests in results file,
+// indexed by test name. Later runs overwrite earlier ones. It is an error if
+// file has no fingerprints, for example because it was written by an older
+// version of ntt.
+func loadFingerprints(file string) (map[string]string, error) {
+	if _, err := os.Stat(file); err != nil {
+		return nil, fmt.Errorf("loading baseline failed: %w", err)
+	}
+	db, err := results.Load(file)
+	if err != nil {
+		return nil, fmt.Errorf("loading baseline %s failed: %w", file, err)
+	}
+	ret := make(map[string]string)
+	for _, r := range db.Runs() {
+		if r.Fingerprint != "" {
+			ret[r.Name] = r.Fingerprint
+		}
+	}
+	if len(ret) == 0 {
+		return nil, fmt.Errorf("baseline %s has no fingerprints", file)
+	}
+	return ret, nil
+}
+
+// fingerprintTests returns the fingerprints of the tests in plan, indexed by
+// name. Definitions are looked up in defs, references are resolved within the
+// sources and imports of the test suite.
+func fingerprintTests(conf *project.Config, defs *sync.Map, plan []testEntry) map[string]string {
+	var (
+		names []string
+		nodes []*ttcn3.Node
+		seen  = make(map[string]bool)
+	)
+	for _, entry := range plan {
+		name := entry.Name
+		if sub, ok := ttcn3.ParseSubtest(name); ok {
+			name = sub.Name
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		if def, ok := defs.Load(name); ok {
+			names = append(names, name)
+			nodes = append(nodes, def.(*ttcn3.Node))
+		}
+	}
+	if len(nodes) == 0 {
+		return nil
+	}
+
+	files, err := fs.TTCN3Files(append(append([]string(nil), conf.Sources...), conf.Imports...)...)
+	if err != nil {
+		log.Verbosef("fingerprints: %s\n", err.Error())
+	}
+	db := &ttcn3.DB{}
+	db.Index(files...)
+
+	// Each test is fingerprinted on its own, hence its fingerprint does
+	// not depend on the other tests of the plan.
+	ret := make(map[string]string, len(names))
+	for i, def := range nodes {
+		ret[names[i]] = def.FingerprintWithDB(db)
+	}
+	return ret
+}
+
+// shuffleJobs puts jobs in a random order, which only depends on seed.
+func shuffleJobs(jobs []*control.Job, seed int64) {
+	r := rand.New(rand.NewSource(seed))
+	r.Shuffle(len(jobs), func(i, j int) { jobs[i], jobs[j] = jobs[j], jobs[i] })
+}
+
+// parseShard parses a --shard value I/N. An empty value is the single shard
+// 1/1.
+func parseShard(s string) (int, int, error) {
+	if s == "" {
+		return 1, 1, nil
+	}
+	i, n, ok := strings.Cut(s, "/")
+	shard, err1 := strconv.Atoi(i)
+	shards, err2 := strconv.Atoi(n)
+	if !ok || err1 != nil || err2 != nil || shards < 1 || shard < 1 || shard > shards {
+		return 0, 0, fmt.Errorf("invalid --shard value %q: must be I/N with 1 <= I <= N", s)
+	}
+	return shard, shards, nil
+}
+
+// inShard returns true if test id belongs to shard i of n. The shard is
+// selected by a FNV hash of id, which is the same on every machine.
+func inShard(id string, i, n int) bool {
+	if n <= 1 {
+		return true
+	}
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	return int(h.Sum32()%uint32(n)) == i-1
+}
+
+// A testEntry is a single test read from a tests file.
+type testEntry struct {
+	// Name is the fully qualified test name.
+	Name string `json:"id"`
+
+	// Parameters are module parameters passed to this test instance. They
+	// override parameters from the parameters file.
+	Parameters map[string]string `json:"parameters,omitempty"`
+
+	// Labels are attached to the job of this test instance, in addition
+	// to those of @label tags.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// readTestsFromFile reads tests from a file. The file either contains one
+// test per line, or a JSON array of objects with fields "id" and
+// "parameters".
+func readTestsFromFile(path string) ([]testEntry, error) {
 	var (
 		lines []byte
 		err   error
@@ -367,23 +2081,26 @@ func readTestsFromFile(path string) ([]string, error) {
 	if path == "-" {
 		lines, err = ioutil.ReadAll(os.Stdin)
 	} else {
-		f, err := os.Open(path)
-		if err != nil {
-			return nil, err
-		}
-		lines, err = ioutil.ReadAll(f)
-		if err != nil {
+		lines, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var tests []testEntry
+	if s := strings.TrimSpace(string(lines)); strings.HasPrefix(s, "[") {
+		if err := json.Unmarshal([]byte(s), &tests); err != nil {
 			return nil, err
 		}
-
+		return tests, nil
 	}
-	var tests []string
+
 	for _, line := range strings.Split(string(lines), "\n") {
 		line = strings.TrimSpace(line)
 		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
 			continue
 		}
-		tests = append(tests, line)
+		tests = append(tests, testEntry{Name: line})
 	}
-	return tests, err
+	return tests, nil
 }
@@ -1,13 +1,23 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/nokia/ntt/control"
 	"github.com/nokia/ntt/internal/fs"
+	"github.com/nokia/ntt/internal/log"
+	"github.com/nokia/ntt/internal/results"
 	"github.com/nokia/ntt/project"
 	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/assert"
@@ -160,6 +170,388 @@ module m2 {
 	})
 }
 
+func TestJobQueueParameters(t *testing.T) {
+	fs.SetContent("test://TestJobQueueParameters.ttcn3", []byte(`module m1 { testcase tc1() {} }`))
+	conf := &project.Config{}
+	conf.Sources = []string{"test://TestJobQueueParameters.ttcn3"}
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.AddFlagSet(BasketFlags())
+	jobs, err := JobQueue(context.Background(), nil, flags, conf, []string{"testdata/TestJobQueue.json"}, nil, false)
+	assert.Nil(t, err)
+
+	var ids, rates []string
+	for job := range jobs {
+		ids = append(ids, job.ID)
+		rates = append(rates, job.ModulePars["m1.PX_RATE"])
+	}
+	assert.Equal(t, []string{"m1.tc1-0", "m1.tc1-1"}, ids)
+	assert.Equal(t, []string{"10", "20"}, rates)
+}
+
+func TestJobQueueVanishedFile(t *testing.T) {
+	logs := captureLog(t)
+
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.ttcn3"), filepath.Join(dir, "b.ttcn3")
+	if err := os.WriteFile(a, []byte(`module m1 { testcase tc1() {} }`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(b, []byte(`module m2 { testcase tc1() {} }`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	conf := &project.Config{}
+	conf.Sources = []string{a, b}
+
+	// b vanishes after the sources were listed.
+	if err := os.Remove(b); err != nil {
+		t.Fatal(err)
+	}
+	got, err := testJobQueue(t, conf, "-a")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"m1.tc1"}, got)
+	assert.Contains(t, logs.String(), "warning: skipping "+b+": ")
+}
+
+func TestJobQueueWeight(t *testing.T) {
+	fs.SetContent("test://TestJobQueueWeight.ttcn3", []byte(`module m1 {
+		testcase tc1() {}
+
+		// @weight: 4
+		testcase tc2() {}
+	}`))
+	conf := &project.Config{}
+	conf.Sources = []string{"test://TestJobQueueWeight.ttcn3"}
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.AddFlagSet(BasketFlags())
+	jobs, err := JobQueue(context.Background(), nil, flags, conf, nil, nil, true)
+	assert.Nil(t, err)
+
+	var weights []int
+	for job := range jobs {
+		weights = append(weights, job.Weight)
+	}
+	assert.Equal(t, []int{1, 4}, weights)
+
+	// Tests heavier than --max-weight run alone.
+	MaxWeight = 2
+	defer func() { MaxWeight = 0 }()
+	jobs, err = JobQueue(context.Background(), nil, flags, conf, nil, nil, true)
+	assert.Nil(t, err)
+	weights = nil
+	for job := range jobs {
+		weights = append(weights, job.Weight)
+	}
+	assert.Equal(t, []int{1, 2}, weights)
+}
+
+func TestJobQueueFingerprints(t *testing.T) {
+	fs.SetContent("test://TestJobQueueFingerprints.ttcn3", []byte(`module m1 {
+		function f() { g() }
+		function g() { f() }
+		testcase tc1() { f() }
+		testcase tc2() { g() }
+	}`))
+	conf := &project.Config{}
+	conf.Sources = []string{"test://TestJobQueueFingerprints.ttcn3"}
+	fingerprints := func(tests ...string) map[string]string {
+		flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+		flags.AddFlagSet(BasketFlags())
+		jobs, err := JobQueue(context.Background(), nil, flags, conf, nil, tests, len(tests) == 0)
+		assert.Nil(t, err)
+		ret := make(map[string]string)
+		for job := range jobs {
+			ret[job.Name] = job.Fingerprint
+		}
+		return ret
+	}
+
+	// Fingerprints are only needed for --affected.
+	assert.Equal(t, map[string]string{"m1.tc1": "", "m1.tc2": ""}, fingerprints())
+
+	AffectedFile = "test_results.json"
+	defer func() { AffectedFile = "" }()
+	all := fingerprints()
+	assert.NotEqual(t, "", all["m1.tc1"])
+	assert.NotEqual(t, all["m1.tc1"], all["m1.tc2"])
+
+	// The fingerprint of a test does not depend on the other tests.
+	assert.Equal(t, all["m1.tc2"], fingerprints("m1.tc2")["m1.tc2"])
+	assert.Equal(t, all["m1.tc1"], fingerprints("m1.tc1")["m1.tc1"])
+}
+
+func TestJobQueueTags(t *testing.T) {
+	fs.SetContent("test://TestJobQueueTags.ttcn3", []byte(`module m1 {
+		testcase tc1() {}
+
+		// @wip
+		// @label: slow
+		// @wip
+		testcase tc2() {}
+	}`))
+	conf := &project.Config{}
+	conf.Sources = []string{"test://TestJobQueueTags.ttcn3"}
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.AddFlagSet(BasketFlags())
+	jobs, err := JobQueue(context.Background(), nil, flags, conf, nil, nil, true)
+	assert.Nil(t, err)
+
+	tags := make(map[string][]string)
+	for job := range jobs {
+		tags[job.Name] = job.Tags
+	}
+	assert.Equal(t, map[string][]string{
+		"m1.tc1": nil,
+		"m1.tc2": {"@wip", "@label"},
+	}, tags)
+}
+
+func TestJobQueueAfter(t *testing.T) {
+	fs.SetContent("test://TestJobQueueAfter.ttcn3", []byte(`module m1 {
+		testcase tc1() {}
+
+		// @after: m1.tc1, m1.tc3
+		testcase tc2() {}
+
+		testcase tc3() {}
+	}`))
+	conf := &project.Config{}
+	conf.Sources = []string{"test://TestJobQueueAfter.ttcn3"}
+	conf.Parameters.Execute = []project.TestConfig{{Test: "m1.tc3", After: []string{"m1.tc1"}}}
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.AddFlagSet(BasketFlags())
+	jobs, err := JobQueue(context.Background(), nil, flags, conf, nil, nil, true)
+	assert.Nil(t, err)
+
+	after := make(map[string][]string)
+	for job := range jobs {
+		after[job.Name] = job.After
+	}
+	assert.Equal(t, map[string][]string{
+		"m1.tc1": nil,
+		"m1.tc2": {"m1.tc1", "m1.tc3"},
+		"m1.tc3": {"m1.tc1"},
+	}, after)
+}
+
+func TestJobQueueSubtests(t *testing.T) {
+	fs.SetContent("test://TestJobQueueSubtests.ttcn3", []byte(`module m1 {
+		// @subtests: PX_RATE = 10, 20
+		testcase tc1() {}
+
+		testcase tc2() {}
+	}`))
+	conf := &project.Config{}
+	conf.Sources = []string{"test://TestJobQueueSubtests.ttcn3"}
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.AddFlagSet(BasketFlags())
+
+	queue := func(tests ...string) map[string]string {
+		jobs, err := JobQueue(context.Background(), nil, flags, conf, nil, tests, true)
+		assert.Nil(t, err)
+		ret := make(map[string]string)
+		for job := range jobs {
+			ret[job.ID] = job.Subtest + "|" + job.ModulePars["m1.PX_RATE"]
+		}
+		return ret
+	}
+
+	defer func() { IncludeSubtests = false }()
+	IncludeSubtests = false
+	assert.Equal(t, map[string]string{
+		"m1.tc1-0": "|",
+		"m1.tc2-0": "|",
+	}, queue())
+	assert.Equal(t, map[string]string{
+		"m1.tc1[PX_RATE=30]-0": "m1.tc1[PX_RATE=30]|30",
+	}, queue("m1.tc1[PX_RATE=30]"))
+
+	IncludeSubtests = true
+	assert.Equal(t, map[string]string{
+		"m1.tc1[PX_RATE=10]-0": "m1.tc1[PX_RATE=10]|10",
+		"m1.tc1[PX_RATE=20]-0": "m1.tc1[PX_RATE=20]|20",
+		"m1.tc2-0":             "|",
+	}, queue())
+}
+
+func TestJobQueueSelect(t *testing.T) {
+	fs.SetContent("test://TestJobQueueSelect.ttcn3", []byte(`module m1 {
+		// @label: tier=1, smoke
+		testcase tc1() {}
+
+		// @label: tier=2
+		testcase tc2() {}
+
+		testcase tc3() {}
+	}`))
+	conf := &project.Config{}
+	conf.Sources = []string{"test://TestJobQueueSelect.ttcn3"}
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.AddFlagSet(BasketFlags())
+
+	queue := func(sel ...string) []string {
+		Selectors = sel
+		defer func() { Selectors = nil }()
+		jobs, err := JobQueue(context.Background(), nil, flags, conf, nil, nil, true)
+		assert.Nil(t, err)
+		var names []string
+		for job := range jobs {
+			names = append(names, job.Name)
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"m1.tc1", "m1.tc2", "m1.tc3"}, queue())
+	assert.Equal(t, []string{"m1.tc1"}, queue("tier=1"))
+	assert.Equal(t, []string{"m1.tc1", "m1.tc2"}, queue("tier"))
+	assert.Equal(t, []string{"m1.tc1"}, queue("tier", "smoke"))
+	assert.Nil(t, queue("tier=3"))
+
+	Selectors = []string{"=1"}
+	defer func() { Selectors = nil }()
+	_, err := JobQueue(context.Background(), nil, flags, conf, nil, nil, true)
+	assert.NotNil(t, err)
+}
+
+func TestCheckRunFlags(t *testing.T) {
+	reset := func() {
+		outputQuiet, outputPlain, outputJSON, outputProgress, outputTAP, outputJUnit = false, false, false, false, false, false
+		Symbols, JSONPretty, GroupSummary, SummaryFile = "none", false, false, ""
+		PrintEnv, DryRun, ReporterStrict, ReporterPlugin = false, false, false, ""
+		KeepWorkdir, IsolateTmp = false, false
+		RepeatUntil, MaxIterations, RepeatFor = "", 0, 0
+		StatusAddr, OnFailHooks = "", nil
+		BaselineFile, StrictBaseline = "", false
+		Shuffle = false
+		seedFlag.Changed = false
+	}
+	defer reset()
+
+	tests := []struct {
+		name string
+		set  func()
+		want string
+	}{
+		{name: "none", set: func() {}},
+		{name: "compatible", set: func() { outputJSON, JSONPretty, Symbols, GroupSummary = true, true, "none", true }},
+		{name: "tap group-summary", set: func() { outputTAP, GroupSummary = true, true }},
+		{name: "quiet progress", set: func() { outputQuiet, outputProgress = true, true }, want: "only one output format"},
+		{name: "json tap", set: func() { outputJSON, outputTAP = true, true }, want: "only one output format"},
+		{name: "junit tap", set: func() { outputJUnit, outputTAP = true, true }, want: "only one output format"},
+		{name: "junit symbols", set: func() { outputJUnit, Symbols = true, "ascii" }, want: "--symbols with --junit"},
+		{name: "json symbols", set: func() { outputJSON, Symbols = true, "unicode" }, want: "--symbols with --json or --tap"},
+		{name: "tap symbols", set: func() { outputTAP, Symbols = true, "ascii" }, want: "--symbols with --json or --tap"},
+		{name: "plain symbols", set: func() { outputPlain, Symbols = true, "ascii" }},
+		{name: "json-pretty", set: func() { JSONPretty = true }, want: "--json-pretty without --json"},
+		{name: "print-env", set: func() { PrintEnv = true }, want: "--print-env without --dry-run"},
+		{name: "reporter-strict", set: func() { ReporterStrict = true }, want: "--reporter-strict without --reporter-plugin"},
+		{name: "keep-workdir", set: func() { KeepWorkdir = true }, want: "--keep-workdir without --isolate-tmp"},
+		{name: "max-iterations", set: func() { MaxIterations = 10 }, want: "without --repeat-until"},
+		{name: "repeat-for", set: func() { RepeatFor = time.Minute }, want: "without --repeat-until"},
+		{name: "repeat", set: func() { RepeatUntil, MaxIterations = "fail", 10 }},
+		{name: "status-server", set: func() { StatusAddr, DryRun = ":8080", true }, want: "--status-server with --dry-run"},
+		{name: "on-fail", set: func() { OnFailHooks, DryRun = []string{"*=true"}, true }, want: "--on-fail with --dry-run"},
+		{name: "seed", set: func() { RunCommand.Flags().Set("seed", "42") }, want: "--seed without --shuffle"},
+		{name: "strict-baseline", set: func() { StrictBaseline = true }, want: "--strict-baseline without --compare-baseline"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reset()
+			tt.set()
+			err := checkRunFlags()
+			if tt.want == "" {
+				assert.Nil(t, err)
+				return
+			}
+			if assert.NotNil(t, err) {
+				assert.Contains(t, err.Error(), tt.want)
+			}
+		})
+	}
+
+	reset()
+	PrintEnv, KeepWorkdir = true, true
+	err := checkRunFlags()
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "--print-env")
+		assert.Contains(t, err.Error(), "--keep-workdir")
+	}
+}
+
+func TestFailedTests(t *testing.T) {
+	runs := []results.Run{
+		{Name: "m.tc1", Verdict: results.PassVerdict},
+		{Name: "m.tc2", Verdict: results.FailVerdict},
+		{Name: "m.control", Verdict: results.DoneVerdict},
+		{Name: "m.tc3", Verdict: results.NoneVerdict},
+		{Name: "m.tc2", Verdict: results.ErrorVerdict},
+		{Name: "m.tc4", Verdict: results.SkippedVerdict},
+		{Name: "m.tc5", Verdict: results.SkippedVerdict, Reason: "skip-if: TARGET_OS==windows"},
+	}
+	assert.Equal(t, []string{"m.tc2", "m.tc3", "m.tc4"}, failedTests(runs))
+	assert.Nil(t, failedTests(nil))
+}
+
+func TestPrintRunSummary(t *testing.T) {
+	begin := time.Unix(1700000000, 0)
+	run := func(v results.Verdict, secs int) results.Run {
+		return results.Run{
+			Name:    "m.tc",
+			Verdict: v,
+			Begin:   results.Timestamp{Time: begin},
+			End:     results.Timestamp{Time: begin.Add(time.Duration(secs) * time.Second)},
+		}
+	}
+
+	var b bytes.Buffer
+	printRunSummary(&b, []results.Run{
+		run(results.PassVerdict, 1),
+		run(results.FailVerdict, 2),
+		run(results.ErrorVerdict, 3),
+		run(results.InconcVerdict, 4),
+		run(results.DoneVerdict, 5),
+		{Name: "m.skipped", Verdict: results.SkippedVerdict},
+	})
+	assert.Equal(t, "=== 1 passed, 2 failed, 1 inconc in 5.0s (6 tests)\n", b.String())
+
+	b.Reset()
+	printRunSummary(&b, []results.Run{run(results.FailVerdict, 1), run("bogus", 1)})
+	assert.Equal(t, "=== 0 passed, 1 failed, 0 inconc, 1 unknown in 1.0s (2 tests)\n", b.String())
+
+	b.Reset()
+	printRunSummary(&b, nil)
+	assert.Equal(t, "=== 0 passed, 0 failed, 0 inconc in 0.0s (0 tests)\n", b.String())
+}
+
+func TestSlowestRuns(t *testing.T) {
+	begin := time.Unix(1700000000, 0)
+	run := func(name string, secs int) results.Run {
+		return results.Run{
+			Name:  name,
+			Begin: results.Timestamp{Time: begin},
+			End:   results.Timestamp{Time: begin.Add(time.Duration(secs) * time.Second)},
+		}
+	}
+	runs := []results.Run{run("m.c", 2), run("m.a", 1), run("m.d", 3), run("m.b", 2)}
+
+	var names []string
+	for _, r := range slowestRuns(runs, 3) {
+		names = append(names, r.Name)
+	}
+	assert.Equal(t, []string{"m.d", "m.b", "m.c"}, names)
+	assert.Equal(t, "m.c", runs[0].Name, "runs are not modified")
+	assert.Len(t, slowestRuns(runs, 10), 4)
+	assert.Nil(t, slowestRuns(nil, 3))
+}
+
+func TestRedact(t *testing.T) {
+	secrets, err := results.NewRedactor(`(?i)passw(or)?d|token`)
+	assert.Nil(t, err)
+	assert.Equal(t, "<redacted>", redact("DB_PASSWORD", "foo", secrets))
+	assert.Equal(t, "<redacted>", redact("api_token", "foo", secrets))
+	assert.Equal(t, "", redact("DB_PASSWD", "", secrets))
+	assert.Equal(t, "foo", redact("USER", "foo", secrets))
+}
+
 func testConfig(modules ...string) *project.Config {
 	conf := &project.Config{}
 	for i, m := range modules {
@@ -193,3 +585,675 @@ func testJobQueue(t *testing.T, conf *project.Config, args ...string) ([]string,
 	}
 	return ret, err
 }
+
+func TestPromptFailure(t *testing.T) {
+	defer func(r *bufio.Reader) { promptInput = r }(promptInput)
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"\n", "continue"},
+		{"c\n", "continue"},
+		{"A\n", "abort"},
+		{"retry\n", "retry"},
+		{"x\nr\n", "retry"},
+		{"", "continue"},
+	}
+	for _, tt := range tests {
+		promptInput = bufio.NewReader(strings.NewReader(tt.input))
+		assert.Equal(t, tt.want, promptFailure("test.A", results.FailVerdict), "input %q", tt.input)
+	}
+}
+
+func TestQuarantine(t *testing.T) {
+	q, err := readQuarantine("")
+	assert.Nil(t, err)
+	assert.Nil(t, q)
+
+	file := filepath.Join(t.TempDir(), "quarantine.txt")
+	os.WriteFile(file, []byte("# flaky since 2026-09\ntest.Flaky\n\ntest.Other\n"), 0644)
+	q, err = readQuarantine(file)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]bool{"test.Flaky": true, "test.Other": true}, q)
+
+	_, err = readQuarantine(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.NotNil(t, err)
+
+	// Errors without verdict, like timeouts, are attributed to the
+	// quarantined test, too.
+	job := &control.Job{ID: "test.Flaky-0", Name: "test.Flaky"}
+	e := control.NewErrorEvent(&control.JobError{Job: job, Err: errors.New("timeout")})
+	assert.True(t, isQuarantined(q, control.UnwrapJob(e)))
+	assert.True(t, isQuarantined(q, &control.Job{Name: "test.Other", Subtest: "test.Other#2"}))
+	assert.False(t, isQuarantined(q, &control.Job{Name: "test.A"}))
+	assert.False(t, isQuarantined(q, nil))
+
+	// A quarantined failure does not affect --fail-under.
+	runs := []results.Run{
+		{Name: "test.A", Verdict: results.PassVerdict},
+		{Name: "test.Flaky", Verdict: results.FailVerdict, Quarantined: true},
+	}
+	assert.Nil(t, checkFailUnder(runs, 100))
+	runs[1].Quarantined = false
+	assert.ErrorIs(t, checkFailUnder(runs, 100), ErrCommandFailed)
+	assert.Nil(t, checkFailUnder(runs, 0))
+
+	// Neither do tests skipped by @skip-if.
+	runs[1] = results.Run{Name: "test.Win", Verdict: results.SkippedVerdict, Reason: "skip-if: OS!=windows"}
+	assert.Nil(t, checkFailUnder(runs, 100))
+}
+
+func TestBaselineGate(t *testing.T) {
+	b, err := loadBaseline("")
+	assert.Nil(t, err)
+	assert.Nil(t, b)
+
+	_, err = loadBaseline(filepath.Join(t.TempDir(), "missing.json"))
+	assert.NotNil(t, err)
+
+	file := filepath.Join(t.TempDir(), "baseline.json")
+	os.WriteFile(file, []byte(`{"sessions": [{"runs": [{"name": "test.A", "verdict": "pass"}]}]}`), 0644)
+	b, err = loadBaseline(file)
+	assert.Nil(t, err)
+	assert.Equal(t, results.Regression, b.Compare(results.Run{Name: "test.A", Verdict: results.FailVerdict}))
+
+	assert.Nil(t, checkBaseline(0, 0, 0, true))
+	assert.Nil(t, checkBaseline(0, 1, 2, false))
+	assert.ErrorIs(t, checkBaseline(0, 1, 0, true), ErrCommandFailed)
+	assert.ErrorIs(t, checkBaseline(1, 0, 0, false), ErrCommandFailed)
+}
+
+func TestSelectAffected(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "baseline.json")
+	os.WriteFile(file, []byte(`{"sessions": [{"runs": [
+		{"name": "test.A", "fingerprint": "a"},
+		{"name": "test.B", "fingerprint": "b"},
+		{"name": "test.C[X=1]", "fingerprint": "c"}
+	]}]}`), 0644)
+
+	jobs := []*control.Job{
+		{ID: "test.A-0", Name: "test.A", Fingerprint: "a"},
+		{ID: "test.B-0", Name: "test.B", Fingerprint: "b2"},
+		{ID: "test.C[X=1]-0", Name: "test.C", Subtest: "test.C[X=1]", Fingerprint: "c"},
+		{ID: "test.D-0", Name: "test.D", Fingerprint: "d"},
+		{ID: "test.E-0", Name: "test.E"},
+	}
+	var ids []string
+	for _, job := range selectAffected(jobs, file) {
+		ids = append(ids, job.ID)
+	}
+	assert.Equal(t, []string{"test.B-0", "test.D-0", "test.E-0"}, ids)
+
+	// Without usable baseline all tests are run.
+	assert.Equal(t, jobs, selectAffected(jobs, filepath.Join(dir, "missing.json")))
+	old := filepath.Join(dir, "old.json")
+	os.WriteFile(old, []byte(`{"sessions": [{"runs": [{"name": "test.A"}]}]}`), 0644)
+	assert.Equal(t, jobs, selectAffected(jobs, old))
+}
+
+func TestShard(t *testing.T) {
+	for _, s := range []string{"0/2", "3/2", "1", "a/2", "1/0", "1/b"} {
+		_, _, err := parseShard(s)
+		assert.NotNil(t, err, s)
+	}
+	i, n, err := parseShard("")
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 1}, []int{i, n})
+
+	fs.SetContent("test://TestShard.ttcn3", []byte(`module m {
+		testcase tc1() {} testcase tc2() {} testcase tc3() {} testcase tc4() {}
+		testcase tc5() {} testcase tc6() {} testcase tc7() {} testcase tc8() {}
+	}`))
+	conf := &project.Config{}
+	conf.Sources = []string{"test://TestShard.ttcn3"}
+	defer func() { Shard = "" }()
+
+	// Every test runs on exactly one shard.
+	all, err := testJobQueue(t, conf, "-a")
+	assert.Nil(t, err)
+	var got []string
+	for i := 1; i <= 3; i++ {
+		Shard = fmt.Sprintf("%d/3", i)
+		shard, err := testJobQueue(t, conf, "-a")
+		assert.Nil(t, err)
+		assert.Less(t, len(shard), len(all))
+		got = append(got, shard...)
+	}
+	assert.ElementsMatch(t, all, got)
+
+	// Sharding applies after baskets and to explicit tests.
+	Shard = "1/3"
+	want, _ := testJobQueue(t, conf, "-a")
+	explicit, err := testJobQueue(t, conf, all...)
+	assert.Nil(t, err)
+	assert.Equal(t, want, explicit)
+	filtered, err := testJobQueue(t, conf, "-a", "-r", "tc1")
+	assert.Nil(t, err)
+	assert.Subset(t, want, filtered)
+
+	Shard = "4/3"
+	_, err = testJobQueue(t, conf, "-a")
+	assert.NotNil(t, err)
+}
+
+func TestShuffleJobs(t *testing.T) {
+	jobs := func() []*control.Job {
+		var ret []*control.Job
+		for i := 0; i < 20; i++ {
+			ret = append(ret, &control.Job{ID: fmt.Sprint(i)})
+		}
+		return ret
+	}
+	ids := func(seed int64) []string {
+		var ret []string
+		j := jobs()
+		shuffleJobs(j, seed)
+		for _, job := range j {
+			ret = append(ret, job.ID)
+		}
+		return ret
+	}
+	assert.Equal(t, ids(42), ids(42), "same seed, same order")
+	assert.NotEqual(t, ids(42), ids(43))
+	assert.ElementsMatch(t, ids(0), ids(42))
+}
+
+func TestRepeat(t *testing.T) {
+	fs.SetContent("test://TestRepeat.ttcn3", []byte(`module m { testcase tc1() {} testcase tc2() {} }`))
+	conf := &project.Config{}
+	conf.Sources = []string{"test://TestRepeat.ttcn3"}
+	Repeat = 3
+	defer func() { Repeat = 1 }()
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.AddFlagSet(BasketFlags())
+	jobs, err := JobQueue(context.Background(), nil, flags, conf, nil, []string{"m.tc1", "m.tc2[PX=1]"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids, names []string
+	for job := range jobs {
+		ids = append(ids, job.ID)
+		names = append(names, job.Subtest)
+	}
+	assert.Equal(t, []string{"m.tc1-0", "m.tc1-1", "m.tc1-2", "m.tc2[PX=1]-0", "m.tc2[PX=1]-1", "m.tc2[PX=1]-2"}, ids)
+	assert.Equal(t, []string{"m.tc1#1", "m.tc1#2", "m.tc1#3", "m.tc2[PX=1]#1", "m.tc2[PX=1]#2", "m.tc2[PX=1]#3"}, names)
+}
+
+func TestRunSummary(t *testing.T) {
+	defer func() { Shard = "" }()
+
+	runs := []results.Run{{Name: "m.tc", Verdict: "pass"}}
+	b, err := json.Marshal(runSummary(runs, nil))
+	assert.Nil(t, err)
+	assert.NotContains(t, string(b), `"seed"`)
+	assert.NotContains(t, string(b), `"shard"`)
+
+	seed := int64(42)
+	Shard = "3/8"
+	s := runSummary(runs, &seed)
+	assert.Equal(t, 1, s.Total)
+	b, err = json.Marshal(s)
+	assert.Nil(t, err)
+	assert.Contains(t, string(b), `"seed":42`)
+	assert.Contains(t, string(b), `"shard":"3/8"`)
+}
+
+func TestShutdownDeadline(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	assert.Nil(t, shutdownDeadline(ctx, 0))
+
+	deadline := shutdownDeadline(ctx, 10*time.Millisecond)
+	select {
+	case <-deadline:
+		t.Fatal("deadline expired before context was done")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	cancel()
+	select {
+	case <-deadline:
+	case <-time.After(5 * time.Second):
+		t.Fatal("deadline did not expire after context was done")
+	}
+}
+
+// fakeK3R writes a shell script into dir, which replaces the k3 runtime. The
+// verdicts of the attempts of a test are read from file dir/NAME.verdicts,
+// one per attempt. Verdict "crash" exits without verdict. A verdict like
+// "fail:3" exits with status 3 after the verdict. Variable FAKE_K3R_DELAY
+// delays the verdict, after which a vanished TMPDIR fails the test.
+func fakeK3R(t *testing.T, dir string, verdicts map[string][]string) string {
+	t.Helper()
+	for name, vs := range verdicts {
+		if err := os.WriteFile(filepath.Join(dir, name+".verdicts"), []byte(strings.Join(vs, "\n")+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	script := filepath.Join(dir, "k3r")
+	err := os.WriteFile(script, []byte(`#!/bin/sh
+while read -r cmd arg rest; do
+	if [ "$cmd" = tciStartTestCase ]; then
+		name=${arg#\"}
+		name=${name%\"}
+	fi
+done
+file="`+dir+`/$name.verdicts"
+verdict=$(head -n 1 "$file")
+sed -i 1d "$file"
+status=0
+case "$verdict" in *:*) status=${verdict#*:}; verdict=${verdict%%:*};; esac
+echo "tciTestCaseStarted \"$name\""
+sleep "${FAKE_K3R_DELAY:-0}"
+[ "$verdict" = crash ] && exit 1
+# A private TMPDIR must not vanish while the test runs.
+[ -n "$TMPDIR" ] && [ ! -d "$TMPDIR" ] && verdict=fail
+echo "tciTestCaseTerminated $verdict"
+exit $status
+`), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return script
+}
+
+// captureLog redirects the log to the returned buffer until the test ends.
+func captureLog(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	log.SetGlobalLogger(&log.ConsoleLogger{Out: &buf})
+	t.Cleanup(func() { log.SetGlobalLogger(&log.ConsoleLogger{Out: os.Stderr}) })
+	return &buf
+}
+
+// runSuite runs all tests of module m with runTests in dir, using a fake k3
+// runtime (see fakeK3R). Artifacts are stored in dir/out. It returns the
+// results file and the error of runTests.
+func runSuite(t *testing.T, dir string, verdicts map[string][]string) (*results.DB, error) {
+	t.Helper()
+	return runSuiteWith(t, dir, verdicts, nil)
+}
+
+// runSuiteWith is like runSuite, but lets setup change the configuration
+// before the tests are run.
+func runSuiteWith(t *testing.T, dir string, verdicts map[string][]string, setup func(*project.Config)) (*results.DB, error) {
+	t.Helper()
+	src := filepath.Join(dir, "m.ttcn3")
+	var b strings.Builder
+	b.WriteString("module m {\n")
+	for name := range verdicts {
+		fmt.Fprintf(&b, "\ttestcase %s() {}\n", strings.TrimPrefix(name, "m."))
+	}
+	b.WriteString("}\n")
+	if err := os.WriteFile(src, []byte(b.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The T3XF is newer than the sources, hence nothing is built.
+	t3xf := filepath.Join(dir, "m.t3xf")
+	if err := os.WriteFile(t3xf, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Hour)
+	os.Chtimes(t3xf, future, future)
+
+	conf := &project.Config{}
+	conf.Name = "m"
+	conf.Sources = []string{src}
+	conf.K3.T3XF = t3xf
+	conf.K3.Runtime = fakeK3R(t, dir, verdicts)
+	conf.ResultsFile = filepath.Join(dir, "test_results.json")
+	if setup != nil {
+		setup(conf)
+	}
+
+	oldProject, oldOutputDir, oldAll := Project, OutputDir, RunAllTests
+	Project, OutputDir, RunAllTests = conf, filepath.Join(dir, "out"), true
+	defer func() { Project, OutputDir, RunAllTests = oldProject, oldOutputDir, oldAll }()
+
+	errorCount = 0
+	err := runTests(RunCommand, nil)
+	db, loadErr := results.Load(conf.ResultsFile)
+	if loadErr != nil {
+		t.Fatal(loadErr)
+	}
+	return db, err
+}
+
+func TestRunRetry(t *testing.T) {
+	Retry = 1
+	outputJUnit = true
+	defer func() { Retry, outputJUnit = 0, false }()
+
+	dir := t.TempDir()
+	db, err := runSuite(t, dir, map[string][]string{
+		"m.tc1": {"fail", "pass"},
+		"m.tc2": {"crash", "pass"},
+		"m.tc3": {"fail", "fail"},
+	})
+	assert.ErrorIs(t, err, ErrCommandFailed)
+
+	verdicts := make(map[string]string)
+	for _, r := range db.Runs() {
+		verdicts[r.Name] = fmt.Sprintf("%s/%d", r.Verdict, r.Attempts)
+	}
+	assert.Equal(t, map[string]string{"m.tc1": "pass/2", "m.tc2": "pass/2", "m.tc3": "fail/2"}, verdicts)
+
+	// Discarded attempts are not reported.
+	b, err := os.ReadFile(filepath.Join(dir, "out", "junit.xml"))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, strings.Count(string(b), "<testcase "), string(b))
+	assert.NotContains(t, string(b), "<error")
+}
+
+func TestRunRetryExitStatus(t *testing.T) {
+	Retry = 1
+	defer func() { Retry = 0 }()
+
+	// k3r exiting with an error after the verdict of a retried attempt
+	// does not count.
+	db, err := runSuite(t, t.TempDir(), map[string][]string{
+		"m.tc1": {"fail:3", "pass"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), errorCount)
+	assert.Equal(t, 1, len(db.Runs()))
+	assert.Equal(t, results.PassVerdict, db.Runs()[0].Verdict)
+	assert.Equal(t, 2, db.Runs()[0].Attempts)
+}
+
+func TestRunStopThenError(t *testing.T) {
+	logs := captureLog(t)
+
+	// k3r exiting with an error after the verdict is only a warning. The
+	// verdict is the only run.
+	db, err := runSuite(t, t.TempDir(), map[string][]string{
+		"m.tc1": {"pass:3"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), errorCount)
+	if assert.Equal(t, 1, len(db.Runs())) {
+		assert.Equal(t, results.PassVerdict, db.Runs()[0].Verdict)
+	}
+	assert.Contains(t, logs.String(), "warning: m.tc1: ")
+}
+
+func TestRunRetryCleanup(t *testing.T) {
+	oldWorkers := MaxWorkers
+	Retry, MaxWorkers, IsolateTmp = 1, 2, true
+	defer func() { Retry, MaxWorkers, IsolateTmp = 0, oldWorkers, false }()
+
+	// The failed attempt removes its TMPDIR after a slow after_each
+	// hook. The retry must not start before, because it uses the same
+	// TMPDIR. m.tc2 waits for m.tc1 and keeps the round open, hence the
+	// idle second worker would pick up the retry at once.
+	dir := t.TempDir()
+	hooks := filepath.Join(dir, "hooks.sh")
+	if err := os.WriteFile(hooks, []byte("#!/bin/sh\n[ \"$1\" = after_each ] && sleep 0.3\nexit 0\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	db, err := runSuiteWith(t, dir, map[string][]string{
+		"m.tc1": {"fail", "pass"},
+		"m.tc2": {"pass"},
+	}, func(c *project.Config) {
+		src := "module m {\n\ttestcase tc1() {}\n\t// @after: m.tc1\n\ttestcase tc2() {}\n}\n"
+		if err := os.WriteFile(c.Sources[0], []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+		c.HooksFile = hooks
+		c.Variables = map[string]string{"FAKE_K3R_DELAY": "0.6"}
+	})
+	assert.Nil(t, err)
+	if assert.Equal(t, 2, len(db.Runs())) {
+		for _, r := range db.Runs() {
+			assert.Equal(t, results.PassVerdict, r.Verdict, r.Name)
+		}
+	}
+	for _, r := range db.Runs() {
+		if r.Name == "m.tc1" {
+			assert.Equal(t, 2, r.Attempts)
+		}
+	}
+}
+
+func TestRunRampUpRetry(t *testing.T) {
+	oldWorkers := MaxWorkers
+	Retry, MaxWorkers, RampUp = 1, 2, time.Second
+	defer func() { Retry, MaxWorkers, RampUp = 0, oldWorkers, 0 }()
+
+	// The second worker starts after half a second. Ramping up the retry
+	// round as well would take another half second.
+	start := time.Now()
+	db, err := runSuite(t, t.TempDir(), map[string][]string{
+		"m.tc1": {"fail", "pass"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, results.PassVerdict, db.Runs()[0].Verdict)
+	assert.Less(t, time.Since(start), 900*time.Millisecond)
+}
+
+func TestDryRun(t *testing.T) {
+	// Only tests listed more than once need the instance suffix.
+	var err error
+	out := stdout(t, func() {
+		err = dryRun([]*control.Job{
+			{ID: "m.tc1-0", Name: "m.tc1", Tags: []string{"@wip"}},
+			{ID: "m.tc2-0", Name: "m.tc2"},
+			{ID: "m.tc2-1", Name: "m.tc2"},
+		})
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "m.tc1\t@wip\nm.tc2-0\nm.tc2-1\n", out)
+}
+
+func TestDryRunPrintEnv(t *testing.T) {
+	PrintEnv = true
+	defer func() { PrintEnv = false }()
+
+	conf := &project.Config{}
+	conf.Variables = map[string]string{"DB_PASSWORD": "hunter2", "DB_USER": "alice"}
+	var err error
+	out := stdout(t, func() {
+		err = dryRun([]*control.Job{{ID: "m.tc1-0", Name: "m.tc1", Config: conf}})
+	})
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(out, "m.tc1\n"), out)
+	assert.Contains(t, out, "\tDB_PASSWORD=<redacted>\n")
+	assert.Contains(t, out, "\tDB_USER=alice\n")
+	assert.NotContains(t, out, "hunter2")
+}
+
+// stdout returns what f writes to standard output.
+func stdout(t *testing.T, f func()) string {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(f *os.File) { os.Stdout = f }(os.Stdout)
+	os.Stdout = w
+
+	out := make(chan string)
+	go func() {
+		b, _ := io.ReadAll(r)
+		out <- string(b)
+	}()
+	f()
+	w.Close()
+	return <-out
+}
+
+func TestRunPause(t *testing.T) {
+	OnFailure, outputJUnit = "pause", true
+	defer func() { OnFailure, outputJUnit = "continue", false }()
+	defer func(f func() bool) { canPrompt = f }(canPrompt)
+	canPrompt = func() bool { return true }
+	defer func(r *bufio.Reader) { promptInput = r }(promptInput)
+	promptInput = bufio.NewReader(strings.NewReader("r\n"))
+
+	dir := t.TempDir()
+	db, err := runSuite(t, dir, map[string][]string{
+		"m.tc1": {"fail", "pass"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(db.Runs()))
+	assert.Equal(t, results.PassVerdict, db.Runs()[0].Verdict)
+
+	// The attempt retried on request is not reported.
+	b, err := os.ReadFile(filepath.Join(dir, "out", "junit.xml"))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, strings.Count(string(b), "<testcase "), string(b))
+	assert.NotContains(t, string(b), "<failure")
+}
+
+func TestRunErrors(t *testing.T) {
+	dir := t.TempDir()
+	FailLog, ResultsStream = filepath.Join(dir, "failed.txt"), filepath.Join(dir, "stream.jsonl")
+	defer func() { FailLog, ResultsStream = "", "" }()
+
+	db, err := runSuite(t, dir, map[string][]string{
+		"m.tc1": {"pass"},
+		"m.tc2": {"crash"},
+	})
+	assert.ErrorIs(t, err, ErrCommandFailed)
+
+	// Crashes are runs with verdict error.
+	verdicts := make(map[string]results.Verdict)
+	for _, r := range db.Runs() {
+		verdicts[r.Name] = r.Verdict
+		assert.False(t, r.Begin.IsZero(), r.Name)
+		assert.Less(t, r.Duration(), time.Minute, r.Name)
+		if r.Verdict == results.ErrorVerdict {
+			assert.Equal(t, "exit status 1", r.Reason)
+			assert.False(t, r.End.IsZero())
+		}
+	}
+	assert.Equal(t, map[string]results.Verdict{"m.tc1": results.PassVerdict, "m.tc2": results.ErrorVerdict}, verdicts)
+
+	b, err := os.ReadFile(FailLog)
+	assert.Nil(t, err)
+	assert.Equal(t, "m.tc2\n", string(b))
+
+	b, err = os.ReadFile(ResultsStream)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, strings.Count(string(b), "\n"))
+	assert.Contains(t, string(b), `"verdict":"error"`)
+}
+
+func TestRunOutputLayout(t *testing.T) {
+	Retry, RepeatUntil, MaxIterations = 1, "fail", 2
+	OutputLayout = "{{.ID}}/{{.Iteration}}-{{.Attempt}}-{{.Verdict}}"
+	defer func() { Retry, RepeatUntil, MaxIterations, OutputLayout = 0, "", 0, "" }()
+
+	dir := t.TempDir()
+	_, err := runSuite(t, dir, map[string][]string{
+		"m.tc1": {"fail", "pass", "crash", "pass"},
+	})
+	assert.Nil(t, err)
+
+	// Every iteration starts with the first attempt and no directory
+	// keeps the name it had while the test was running.
+	entries, err := os.ReadDir(filepath.Join(dir, "out", "m.tc1-0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dirs []string
+	for _, e := range entries {
+		dirs = append(dirs, e.Name())
+	}
+	assert.Equal(t, []string{"1-1-fail", "1-2-pass", "2-1-error", "2-2-pass"}, dirs)
+
+	// Directories of the same name are replaced.
+	OutputLayout = "{{.ID}}/{{.Attempt}}-{{.Verdict}}"
+	dir = t.TempDir()
+	_, err = runSuite(t, dir, map[string][]string{
+		"m.tc1": {"pass", "pass"},
+	})
+	assert.Nil(t, err)
+	entries, err = os.ReadDir(filepath.Join(dir, "out", "m.tc1-0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dirs = nil
+	for _, e := range entries {
+		dirs = append(dirs, e.Name())
+	}
+	assert.Equal(t, []string{"1-pass"}, dirs)
+}
+
+func TestRunQuarantine(t *testing.T) {
+	dir := t.TempDir()
+	QuarantineFile = filepath.Join(dir, "quarantine.txt")
+	defer func() { QuarantineFile = "" }()
+	os.WriteFile(QuarantineFile, []byte("m.flaky\n"), 0644)
+
+	// Quarantined failures and errors do not change the exit status.
+	db, err := runSuite(t, dir, map[string][]string{
+		"m.tc1":   {"pass"},
+		"m.flaky": {"fail"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), errorCount)
+	for _, r := range db.Runs() {
+		assert.Equal(t, r.Name == "m.flaky", r.Quarantined, r.Name)
+	}
+
+	_, err = runSuite(t, t.TempDir(), map[string][]string{"m.flaky": {"crash"}})
+	assert.Nil(t, err)
+
+	// Other failures still do.
+	_, err = runSuite(t, t.TempDir(), map[string][]string{
+		"m.tc1":   {"fail"},
+		"m.flaky": {"fail"},
+	})
+	assert.ErrorIs(t, err, ErrCommandFailed)
+	assert.Equal(t, uint64(1), errorCount)
+}
+
+func TestRunWorkerLog(t *testing.T) {
+	dir := t.TempDir()
+	Retry, WorkerLogDir = 1, filepath.Join(dir, "logs")
+	defer func() { Retry, WorkerLogDir = 0, "" }()
+	os.MkdirAll(WorkerLogDir, 0755)
+	os.WriteFile(filepath.Join(WorkerLogDir, "worker-0.log"), []byte("previous run\n"), 0644)
+
+	// The retry round appends to the log, which is rotated once per run.
+	_, err := runSuite(t, dir, map[string][]string{"m.tc1": {"fail", "pass"}})
+	assert.Nil(t, err)
+	b, err := os.ReadFile(filepath.Join(WorkerLogDir, "worker-0.log"))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, strings.Count(string(b), "worker 0 started"), string(b))
+	b, err = os.ReadFile(filepath.Join(WorkerLogDir, "worker-0.log.1"))
+	assert.Nil(t, err)
+	assert.Equal(t, "previous run\n", string(b))
+}
+
+func TestRunGroupSummary(t *testing.T) {
+	GroupSummary = true
+	defer func() { GroupSummary = false }()
+
+	db, err := runSuite(t, t.TempDir(), map[string][]string{
+		"m.tc1": {"pass"},
+		"m.tc2": {"fail"},
+	})
+	assert.ErrorIs(t, err, ErrCommandFailed)
+	if assert.Equal(t, 1, len(db.Sessions)) && assert.Equal(t, 1, len(db.Sessions[0].Modules)) {
+		m := db.Sessions[0].Modules[0]
+		assert.Equal(t, "m", m.Module)
+		assert.Equal(t, 2, m.Total)
+		assert.Equal(t, 1, m.Pass)
+		assert.Equal(t, 1, m.Fail)
+	}
+}
+
+func TestRunErrorOnNone(t *testing.T) {
+	_, err := runSuite(t, t.TempDir(), map[string][]string{"m.tc1": {"none"}})
+	assert.Nil(t, err)
+
+	ErrorOnNone = true
+	defer func() { ErrorOnNone = false }()
+	_, err = runSuite(t, t.TempDir(), map[string][]string{"m.tc1": {"none"}})
+	assert.ErrorIs(t, err, ErrCommandFailed)
+}
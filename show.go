@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"regexp"
 	"sort"
 	"strings"
 	"text/template"
@@ -12,6 +14,7 @@ import (
 	"github.com/nokia/ntt/internal/env"
 	"github.com/nokia/ntt/internal/fs"
 	"github.com/nokia/ntt/internal/log"
+	"github.com/nokia/ntt/internal/results"
 	"github.com/nokia/ntt/internal/yaml"
 	"github.com/nokia/ntt/project"
 	"github.com/nokia/ntt/ttcn3"
@@ -35,6 +38,8 @@ func show(cmd *cobra.Command, args []string) error {
 	r.Files, r.err = project.Files(Project)
 
 	switch {
+	case showResolved:
+		return printResolved(os.Stdout, Project)
 	case outputJSON:
 		return printJSON(&r, keys)
 	case ShSetup:
@@ -223,6 +228,30 @@ func get(c *project.Config, key string) ([]string, error) {
 	return nil, fmt.Errorf("value of key %q is not of type string or list of strings", key)
 }
 
+// printResolved prints the fully resolved variables of the configuration in
+// lexicographic order. Values of variables with names matching a secret
+// pattern are redacted, also where they are expanded into other variables.
+func printResolved(w io.Writer, c *project.Config) error {
+	secrets, err := results.NewRedactor(SecretVars...)
+	if err != nil {
+		return err
+	}
+	vars, err := c.Resolved()
+	var values results.Redactor
+	for k, v := range vars {
+		if redact(k, v, secrets) != v {
+			values = append(values, regexp.MustCompile(regexp.QuoteMeta(v)))
+		}
+	}
+	for k, v := range vars {
+		vars[k] = values.Redact(v)
+	}
+	for _, kv := range env.Env(vars).Slice() {
+		fmt.Fprintln(w, kv)
+	}
+	return err
+}
+
 func printValues(c *project.Config, keys []string) error {
 	for _, key := range keys {
 		s, err := get(c, key)
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/nokia/ntt/internal/env"
+	"github.com/nokia/ntt/project"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintResolved(t *testing.T) {
+	c := &project.Config{}
+	c.Variables = env.Env{"DB_PASSWORD": "hunter2", "DSN": "db://${DB_PASSWORD}@host", "USER": "me"}
+
+	var b bytes.Buffer
+	assert.Nil(t, printResolved(&b, c))
+	assert.Equal(t, "DB_PASSWORD=***\nDSN=db://***@host\nUSER=me\n", b.String())
+
+	SecretVars = []string{"("}
+	defer func() { SecretVars = secretPatterns }()
+	assert.NotNil(t, printResolved(&b, c))
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/nokia/ntt/internal/results"
+	"github.com/spf13/cobra"
+)
+
+var (
+	TimeSeriesCommand = &cobra.Command{
+		Use:   "timeseries",
+		Short: "Export a metric of historical test runs for plotting",
+		Long: `Export a metric of historical test runs for plotting.
+
+The timeseries command loads all test results files (` + results.Filename + `)
+found in the directories given as arguments. Every session of these files
+becomes one data point, ordered by the start time of the session's first
+test. Sessions are evaluated independently, hence the set of tests may vary
+from point to point. Sessions without any test runs are ignored.
+
+Available metrics (--metric):
+
+    passrate   percentage of passed tests (control parts are not counted)
+    duration   seconds between the start of the first and the end of the last test
+    tests      number of test runs
+    failures   number of runs with verdict fail, error or unknown
+
+The output is CSV with the columns time (RFC 3339), file, session, commit and
+the metric. With --json all metrics are written as a JSON array instead:
+
+	ntt report timeseries --metric=passrate results/ > passrate.csv
+	ntt report timeseries --json results/
+`,
+		RunE: timeSeries,
+	}
+
+	timeSeriesMetric string
+)
+
+func init() {
+	TimeSeriesCommand.Flags().StringVar(&timeSeriesMetric, "metric", "passrate", "metric to export: passrate, duration, tests or failures")
+	ReportCommand.AddCommand(TimeSeriesCommand)
+}
+
+func timeSeries(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+
+	// Validate the metric before loading possibly many files.
+	if _, err := (results.Point{}).Metric(timeSeriesMetric); err != nil {
+		return err
+	}
+
+	dbs, err := results.LoadHistory(args...)
+	if err != nil {
+		return err
+	}
+
+	points := results.TimeSeries(dbs)
+	if useJSON {
+		if points == nil {
+			points = []results.Point{}
+		}
+		b, err := json.MarshalIndent(points, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+		return nil
+	}
+
+	w := csv.NewWriter(os.Stdout)
+	w.Write([]string{"time", "file", "session", "commit", timeSeriesMetric})
+	for _, p := range points {
+		v, _ := p.Metric(timeSeriesMetric)
+		w.Write([]string{
+			p.Time.Format(time.RFC3339),
+			p.File,
+			p.Session,
+			p.Commit,
+			strconv.FormatFloat(v, 'f', -1, 64),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
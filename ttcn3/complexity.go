@@ -0,0 +1,38 @@
+package ttcn3
+
+import "github.com/nokia/ntt/ttcn3/syntax"
+
+// Complexity returns the cyclomatic complexity of a function, testcase,
+// altstep or control part. It is one plus the number of decision points in
+// the body of def. Following constructs are decision points:
+//
+//   - each if statement, including every "else if"
+//   - each for, for-range, while and do-while loop
+//   - each case of a select statement, except "case else"
+//   - each guarded alternative of an alt statement, interleave statement
+//     or altstep body, except "[else]"
+//
+// Boolean operators (and, or) do not increment the complexity. Definitions
+// without body, like external functions, have complexity 1.
+func Complexity(def *Definition) int {
+	c := 1
+	if def == nil || syntax.IsNil(def.Node) {
+		return c
+	}
+	syntax.Inspect(def.Node, func(n syntax.Node) bool {
+		switch n := n.(type) {
+		case *syntax.IfStmt, *syntax.ForStmt, *syntax.ForRangeStmt, *syntax.WhileStmt, *syntax.DoWhileStmt:
+			c++
+		case *syntax.CaseClause:
+			if n.Case != nil {
+				c++
+			}
+		case *syntax.CommClause:
+			if n.Else == nil {
+				c++
+			}
+		}
+		return true
+	})
+	return c
+}
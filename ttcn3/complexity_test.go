@@ -0,0 +1,60 @@
+package ttcn3_test
+
+import (
+	"testing"
+
+	"github.com/nokia/ntt/ttcn3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComplexity(t *testing.T) {
+	tree := ttcn3.Parse(`
+		module M {
+			external function ext();
+			function f1() {}
+			function f2(integer x) {
+				if (x > 0 and x < 10) {
+				} else if (x > 10) {
+				} else {
+				}
+			}
+			function f3() {
+				for (var integer i := 0; i < 10; i := i + 1) {}
+				while (true) {}
+				do {} while (false);
+			}
+			function f4(integer x) {
+				select (x) {
+				case (1) {}
+				case (2) {}
+				case else {}
+				}
+			}
+			testcase tc() runs on C {
+				alt {
+				[] p.receive { repeat }
+				[x > 0] p.receive {}
+				[else] {}
+				}
+			}
+			altstep as() runs on C {
+			[] p.receive {}
+			[] t.timeout {}
+			}
+		}`)
+
+	actual := make(map[string]int)
+	for _, f := range tree.Funcs() {
+		actual[f.Ident.String()] = ttcn3.Complexity(f)
+	}
+	assert.Equal(t, map[string]int{
+		"ext": 1,
+		"f1":  1,
+		"f2":  3,
+		"f3":  4,
+		"f4":  3,
+		"tc":  3,
+		"as":  3,
+	}, actual)
+	assert.Equal(t, 1, ttcn3.Complexity(nil))
+}
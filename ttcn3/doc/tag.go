@@ -7,7 +7,7 @@ import (
 )
 
 var (
-	tagRegex = regexp.MustCompile(`^[/*\s]*(@[A-Za-z0-9_]+)\s*:?\s*(.*?)[/*\r\n\s]*$`)
+	tagRegex = regexp.MustCompile(`^[/*\s]*(@[A-Za-z0-9_][A-Za-z0-9_-]*)\s*:?\s*(.*?)[/*\r\n\s]*$`)
 )
 
 // Finds first tag in s. A Return value of nil indicates not match.
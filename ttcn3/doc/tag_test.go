@@ -22,6 +22,7 @@ func TestTags(t *testing.T) {
 	testTag(t, `// @foo		@bar	`, []string{`@foo`, `@bar`})
 	testTag(t, `// *** @foo	@bar ***`, []string{`@foo`, `@bar`})
 	testTag(t, `// *** @wip23	***`, []string{`@wip23`, ``})
+	testTag(t, `// @skip-if: OS==windows`, []string{`@skip-if`, `OS==windows`})
 	testTag(t, `** @verdict  @foo:bar`, []string{`@verdict`, `@foo:bar`})
 	testTag(t, `** @verdict:@foo:bar`, []string{`@verdict`, `@foo:bar`})
 }
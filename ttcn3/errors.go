@@ -0,0 +1,62 @@
+package ttcn3
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/nokia/ntt/ttcn3/syntax"
+)
+
+// FormatError renders err like a compiler diagnostic: the position in the form
+// file:line:col followed by the message, the offending source line and a caret
+// pointing at the column. Multiple errors are rendered one after another.
+// Errors without position information are rendered as is.
+func FormatError(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var merr *multierror.Error
+	if errors.As(err, &merr) {
+		var s []string
+		for _, e := range merr.Errors {
+			s = append(s, FormatError(e))
+		}
+		return strings.Join(s, "\n")
+	}
+
+	var serr syntax.Error
+	if !errors.As(err, &serr) || syntax.IsNil(serr.Node) {
+		return err.Error()
+	}
+
+	spn := syntax.SpanOf(serr.Node)
+	if !spn.Begin.IsValid() {
+		return err.Error()
+	}
+
+	var b strings.Builder
+	if spn.Filename != "" {
+		fmt.Fprintf(&b, "%s:", spn.Filename)
+	}
+	fmt.Fprintf(&b, "%d:%d: %s", spn.Begin.Line, spn.Begin.Column, serr.Msg)
+
+	if line := serr.Source(); line != "" {
+		b.WriteString("\n\t")
+		b.WriteString(line)
+		b.WriteString("\n\t")
+
+		// Keep tabs to align the caret with the column.
+		for i := 0; i < spn.Begin.Column-1 && i < len(line); i++ {
+			if line[i] == '\t' {
+				b.WriteByte('\t')
+			} else {
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteByte('^')
+	}
+	return b.String()
+}
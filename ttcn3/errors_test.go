@@ -0,0 +1,44 @@
+package ttcn3_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nokia/ntt/ttcn3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatError(t *testing.T) {
+	assert.Equal(t, "", ttcn3.FormatError(nil))
+
+	tree := ttcn3.Parse("module M {\n\tfunction f() { x := }\n}")
+	assert.NotNil(t, tree.Err)
+	assert.Equal(t, "2:22: expected operand, found }\n"+
+		"\t\tfunction f() { x := }\n"+
+		"\t\t                    ^", ttcn3.FormatError(tree.Err))
+}
+
+func TestFormatErrorEncoding(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"bom", "\xEF\xBB\xBFmodule M {\n\tfunction f() { x := }\n}", "2:22: expected operand, found }"},
+		{"bom_first_line", "\xEF\xBB\xBFmodule M { x }", "1:12: expected module definition, found x"},
+		{"crlf", "module M {\r\n\tfunction f() { x := }\r\n}\r\n", "2:22: expected operand, found }"},
+		{"crlf_comment", "// comment\r\nmodule M {\r\n\tfunction f() { x := }\r\n}", "3:22: expected operand, found }"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "a.ttcn3")
+			if err := os.WriteFile(file, []byte(tt.input), 0644); err != nil {
+				t.Fatal(err)
+			}
+			tree := ttcn3.ParseFile(file)
+			assert.NotNil(t, tree.Err)
+			assert.Contains(t, ttcn3.FormatError(tree.Err), file+":"+tt.want+"\n")
+		})
+	}
+}
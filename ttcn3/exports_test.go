@@ -8,3 +8,16 @@ import (
 func (t *Tree) SliceAt(pos int) []syntax.Node {
 	return t.sliceAt(pos)
 }
+
+// BlockParser occupies all parser slots until the returned function is
+// called.
+func BlockParser() func() {
+	for i := 0; i < cap(parseLimit); i++ {
+		parseLimit <- struct{}{}
+	}
+	return func() {
+		for i := 0; i < cap(parseLimit); i++ {
+			<-parseLimit
+		}
+	}
+}
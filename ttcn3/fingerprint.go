@@ -0,0 +1,161 @@
+package ttcn3
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/nokia/ntt/ttcn3/syntax"
+)
+
+// Fingerprint returns a content hash of definition d and of the definitions
+// it references. References to other files are not resolved. For handling
+// imports, use FingerprintWithDB.
+func (d *Definition) Fingerprint() string {
+	return d.FingerprintWithDB(&DB{})
+}
+
+// FingerprintWithDB returns a content hash of definition d and of the
+// definitions it references, using the database for import resolution.
+//
+// The hash covers the tokens of the definition, hence changes of white space
+// and comments, including the documentation comment, do not change the
+// fingerprint. Identifiers referring to module-level definitions add the
+// fingerprints of those definitions, which are computed the same way. Hence
+// the closure includes all module-level definitions d depends on, directly
+// or transitively: functions called, types and templates used, the component
+// of a runs on clause, ... Everything else marks the boundary of the closure:
+//
+//   - references to definitions inside of d (parameters, local variables, ...)
+//     are covered by the tokens of d already
+//   - references to a definition inside another module-level definition (like
+//     a record field) add the fingerprint of the enclosing definition
+//   - references to modules, unresolved references and builtins only
+//     contribute their name
+//   - recursive references contribute the qualified name of the definition
+//     already being fingerprinted
+//
+// Fingerprints are only comparable if the references are resolved with the
+// same set of files.
+func (d *Definition) FingerprintWithDB(db *DB) string {
+	s, _ := newFingerprinter(db).fingerprint(d.Node, d.Tree)
+	return s
+}
+
+// Fingerprints returns the fingerprints of the given definitions, like
+// FingerprintWithDB. Definitions referenced by several of them are hashed only
+// once, unless they are part of a reference cycle: The hash of a definition in
+// a cycle depends on where the cycle is entered, hence it is computed anew for
+// every definition referencing it.
+func Fingerprints(db *DB, defs ...*Definition) []string {
+	fp := newFingerprinter(db)
+	ret := make([]string, len(defs))
+	for i, d := range defs {
+		ret[i], _ = fp.fingerprint(d.Node, d.Tree)
+	}
+	return ret
+}
+
+func newFingerprinter(db *DB) *fingerprinter {
+	return &fingerprinter{
+		finder:   newFinder(db),
+		done:     make(map[syntax.Node]string),
+		visiting: make(map[syntax.Node]int),
+	}
+}
+
+type fingerprinter struct {
+	*finder
+
+	// done caches the fingerprints of definitions, which are not part of
+	// a reference cycle.
+	done map[syntax.Node]string
+
+	// visiting maps the definitions being fingerprinted to their depth
+	// in the stack of definitions.
+	visiting map[syntax.Node]int
+}
+
+// fingerprint returns the fingerprint of n and the lowest stack depth of the
+// definitions being fingerprinted, which n refers to directly or transitively.
+// The depth is math.MaxInt if there is no such reference, that is if n is not
+// part of a reference cycle.
+func (fp *fingerprinter) fingerprint(n syntax.Node, tree *Tree) (string, int) {
+	if s, ok := fp.done[n]; ok {
+		return s, math.MaxInt
+	}
+	if depth, ok := fp.visiting[n]; ok {
+		return "cycle " + tree.QualifiedName(n), depth
+	}
+	depth := len(fp.visiting)
+	fp.visiting[n] = depth
+	defer delete(fp.visiting, n)
+	low := math.MaxInt
+
+	h := sha256.New()
+	writeTokens(h, n)
+
+	visited := make(map[syntax.Node]bool)
+	var visit func(x syntax.Node) bool
+	visit = func(x syntax.Node) bool {
+		var expr syntax.Expr
+		switch x := x.(type) {
+		case *syntax.Ident:
+			expr = x
+		case *syntax.SelectorExpr:
+			// The selector is resolved together with the left-hand
+			// side, which is visited on its own.
+			expr = x
+			syntax.Inspect(x.X, visit)
+		default:
+			return true
+		}
+		for _, def := range fp.lookup(expr, tree) {
+			d, t := topLevel(def.Node, def.Tree)
+			if d == nil || visited[d] || (t == tree && n.Pos() <= d.Pos() && d.End() <= n.End()) {
+				continue
+			}
+			visited[d] = true
+			s, l := fp.fingerprint(d, t)
+			if l < low {
+				low = l
+			}
+			fmt.Fprintf(h, "> %s\n", s)
+		}
+		return false
+	}
+	n.Inspect(visit)
+
+	s := hex.EncodeToString(h.Sum(nil))
+	if low > depth {
+		fp.done[n] = s
+	}
+	return s, low
+}
+
+// writeTokens writes the tokens of n to w, one per line. Comments are
+// skipped, hence the output does not depend on formatting.
+func writeTokens(w io.Writer, n syntax.Node) {
+	first, last := n.FirstTok(), n.LastTok()
+	if first == nil || last == nil {
+		return
+	}
+	for tok := first; tok != nil && tok.Pos() <= last.Pos(); tok = tok.NextTok() {
+		if tok.Kind() != syntax.COMMENT {
+			fmt.Fprintf(w, "%s\n", tok.String())
+		}
+	}
+}
+
+// topLevel returns the module-level definition enclosing n, or nil if n is
+// not part of a module-level definition.
+func topLevel(n syntax.Node, tree *Tree) (syntax.Node, *Tree) {
+	for p := tree.ParentOf(n); p != nil; n, p = p, tree.ParentOf(p) {
+		if _, ok := p.(*syntax.ModuleDef); ok {
+			return n, tree
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,84 @@
+package ttcn3_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/nokia/ntt/internal/fs"
+	"github.com/nokia/ntt/ttcn3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFingerprint(t *testing.T) {
+	const test = `module Test {
+		import from Lib all;
+		type component C {}
+		// Documentation is not covered.
+		testcase TC() runs on C {
+			var integer x := f(1);
+			setverdict(pass);
+		}
+		function unrelated() {}
+	}`
+	const lib = `module Lib {
+		type record R { integer a }
+		function f(integer p) return integer { var R r := { a := p }; return g(r.a) }
+		function g(integer p) return integer { return f(p) }
+		function h() {}
+	}`
+
+	n := 0
+	fingerprint := func(test, lib string) string {
+		n++
+		testFile, libFile := fmt.Sprintf("%s%d_test.ttcn3", t.Name(), n), fmt.Sprintf("%s%d_lib.ttcn3", t.Name(), n)
+		fs.SetContent(testFile, []byte(test))
+		fs.SetContent(libFile, []byte(lib))
+		db := &ttcn3.DB{}
+		db.Index(testFile, libFile)
+		tree := ttcn3.ParseFile(testFile)
+		return tree.Tests()[0].FingerprintWithDB(db)
+	}
+	replace := func(s, old, new string) string {
+		r := strings.Replace(s, old, new, 1)
+		if r == s {
+			t.Fatalf("%q not found", old)
+		}
+		return r
+	}
+
+	want := fingerprint(test, lib)
+	assert.Equal(t, 64, len(want))
+	assert.Equal(t, want, fingerprint(test, lib), "fingerprint is not stable")
+
+	// Formatting, comments and unrelated definitions do not matter.
+	assert.Equal(t, want, fingerprint(replace(test, "var integer x := f(1);", "var integer x:=f(1); // comment"), lib))
+	assert.Equal(t, want, fingerprint(replace(test, "Documentation", "Docs"), lib))
+	assert.Equal(t, want, fingerprint(replace(test, "function unrelated() {}", ""), lib))
+	assert.Equal(t, want, fingerprint(test, replace(lib, "function h() {}", "function h() { log(1) }")))
+
+	// Changes of the test and of referenced definitions do matter.
+	assert.NotEqual(t, want, fingerprint(replace(test, "f(1)", "f(2)"), lib))
+	assert.NotEqual(t, want, fingerprint(replace(test, "type component C {}", "type component C { var integer y }"), lib))
+	assert.NotEqual(t, want, fingerprint(test, replace(lib, "return g(r.a)", "return g(r.a + 1)")))
+	assert.NotEqual(t, want, fingerprint(test, replace(lib, "return f(p)", "return f(p - 1)")), "transitive change not detected")
+	assert.NotEqual(t, want, fingerprint(test, replace(lib, "integer a", "integer a optional")), "change of record type not detected")
+
+	// Without database imports are not resolved.
+	tree := ttcn3.Parse(test)
+	assert.Equal(t, 64, len(tree.Tests()[0].Fingerprint()))
+
+	// Fingerprints of several definitions are the same as individual ones.
+	tree = ttcn3.Parse(`module M { function f() { g() } function g() {} }`)
+	funcs := tree.Funcs()
+	db := &ttcn3.DB{}
+	assert.Equal(t, []string{funcs[0].FingerprintWithDB(db), funcs[1].FingerprintWithDB(db)}, ttcn3.Fingerprints(db, funcs...))
+
+	// Also for mutually recursive definitions, independent of the order.
+	tree = ttcn3.Parse(`module M { function f() { g() } function g() { f() } function h() { f() } }`)
+	funcs = tree.Funcs()
+	single := []string{funcs[0].FingerprintWithDB(db), funcs[1].FingerprintWithDB(db), funcs[2].FingerprintWithDB(db)}
+	assert.NotEqual(t, single[0], single[1])
+	assert.Equal(t, single, ttcn3.Fingerprints(db, funcs...))
+	assert.Equal(t, []string{single[2], single[1], single[0]}, ttcn3.Fingerprints(db, funcs[2], funcs[1], funcs[0]))
+}
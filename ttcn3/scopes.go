@@ -20,6 +20,10 @@ type Node struct {
 	Next *Node
 }
 
+// A Definition is a named definition, like a function or a type, together with
+// its syntax tree, as found by Definitions or Tree.Funcs.
+type Definition = Node
+
 func Definitions(id string, n syntax.Node, t *Tree) []*Node {
 	return NewScope(n, t).Lookup(id)
 }
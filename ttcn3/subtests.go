@@ -0,0 +1,76 @@
+package ttcn3
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A Subtest is a single instance of a data-driven testcase. Data-driven
+// testcases describe the data set they iterate over with a @subtests tag,
+// naming a module parameter and the values it takes:
+//
+//	// @subtests: PX_RATE = 10, 20, 30
+//	testcase TC_throughput() runs on C { ... }
+//
+// Each value yields one subtest, identified as test.TC_throughput[PX_RATE=10].
+// Values are separated by commas and must not contain commas themselves.
+// Unqualified parameter names refer to the module of the testcase.
+type Subtest struct {
+	// Name is the fully qualified name of the testcase.
+	Name string
+
+	// Param is the module parameter as written in the @subtests tag.
+	Param string
+
+	// Value is the value of the module parameter.
+	Value string
+}
+
+// ID returns the identifier of the subtest, for example test.A[PX_RATE=10].
+func (s Subtest) ID() string {
+	return fmt.Sprintf("%s[%s=%s]", s.Name, s.Param, s.Value)
+}
+
+// ModulePar returns the qualified name of the module parameter of the subtest.
+func (s Subtest) ModulePar() string {
+	if strings.Contains(s.Param, ".") {
+		return s.Param
+	}
+	return JoinNames(ModuleName(s.Name), s.Param)
+}
+
+// Subtests returns the subtests of testcase name described by the @subtests
+// tags. Multiple @subtests tags are concatenated.
+func Subtests(name string, tags [][]string) ([]Subtest, error) {
+	var ret []Subtest
+	for _, tag := range tags {
+		if tag[0] != "@subtests" {
+			continue
+		}
+		param, values, ok := strings.Cut(tag[1], "=")
+		param = strings.TrimSpace(param)
+		if !ok || param == "" || strings.ContainsAny(param, " \t") {
+			return nil, fmt.Errorf("%s: invalid @subtests tag %q: expected \"PARAM = VALUE, ...\"", name, tag[1])
+		}
+		for _, v := range strings.Split(values, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				ret = append(ret, Subtest{Name: name, Param: param, Value: v})
+			}
+		}
+	}
+	return ret, nil
+}
+
+// ParseSubtest parses a subtest identifier like test.A[PX_RATE=10]. ok is
+// false if id does not identify a subtest.
+func ParseSubtest(id string) (s Subtest, ok bool) {
+	i := strings.Index(id, "[")
+	if i < 0 || !strings.HasSuffix(id, "]") {
+		return Subtest{}, false
+	}
+	param, value, ok := strings.Cut(id[i+1:len(id)-1], "=")
+	if !ok || param == "" {
+		return Subtest{}, false
+	}
+	return Subtest{Name: id[:i], Param: param, Value: value}, true
+}
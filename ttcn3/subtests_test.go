@@ -0,0 +1,57 @@
+package ttcn3_test
+
+import (
+	"testing"
+
+	"github.com/nokia/ntt/ttcn3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubtests(t *testing.T) {
+	tags := [][]string{
+		{"@tag", "foo"},
+		{"@subtests", "PX_RATE = 10, 20 ,,30"},
+		{"@subtests", "other.PX_MODE=\"a\""},
+	}
+	subtests, err := ttcn3.Subtests("m.tc", tags)
+	assert.Nil(t, err)
+
+	var ids, pars []string
+	for _, s := range subtests {
+		ids = append(ids, s.ID())
+		pars = append(pars, s.ModulePar())
+	}
+	assert.Equal(t, []string{
+		"m.tc[PX_RATE=10]",
+		"m.tc[PX_RATE=20]",
+		"m.tc[PX_RATE=30]",
+		`m.tc[other.PX_MODE="a"]`,
+	}, ids)
+	assert.Equal(t, []string{"m.PX_RATE", "m.PX_RATE", "m.PX_RATE", "other.PX_MODE"}, pars)
+
+	_, err = ttcn3.Subtests("m.tc", [][]string{{"@subtests", "10, 20"}})
+	assert.NotNil(t, err)
+
+	subtests, err = ttcn3.Subtests("m.tc", [][]string{{"@tag", ""}})
+	assert.Nil(t, err)
+	assert.Nil(t, subtests)
+}
+
+func TestParseSubtest(t *testing.T) {
+	tests := []struct {
+		id   string
+		want ttcn3.Subtest
+		ok   bool
+	}{
+		{id: "m.tc[PX_RATE=10]", want: ttcn3.Subtest{Name: "m.tc", Param: "PX_RATE", Value: "10"}, ok: true},
+		{id: "m.tc[m.PX=a=b]", want: ttcn3.Subtest{Name: "m.tc", Param: "m.PX", Value: "a=b"}, ok: true},
+		{id: "m.tc"},
+		{id: "m.tc[10]"},
+		{id: "m.tc[PX=1"},
+	}
+	for _, tt := range tests {
+		got, ok := ttcn3.ParseSubtest(tt.id)
+		assert.Equal(t, tt.ok, ok, tt.id)
+		assert.Equal(t, tt.want, got, tt.id)
+	}
+}
@@ -0,0 +1,95 @@
+package syntax
+
+import "fmt"
+
+// CommentAssoc describes how a comment is associated with the surrounding
+// code.
+type CommentAssoc int
+
+const (
+	// Standalone comments are separated from the following code by an
+	// empty line or are not followed by code at all.
+	Standalone CommentAssoc = iota
+
+	// Leading comments precede the following code without an empty line
+	// in between, like documentation comments.
+	Leading
+
+	// Trailing comments start on the line the preceding code ends.
+	Trailing
+)
+
+func (a CommentAssoc) String() string {
+	switch a {
+	case Standalone:
+		return "standalone"
+	case Leading:
+		return "leading"
+	case Trailing:
+		return "trailing"
+	default:
+		return fmt.Sprintf("CommentAssoc(%d)", int(a))
+	}
+}
+
+// A Comment is a block or line comment token together with its association.
+// Use SpanOf to get its position.
+type Comment struct {
+	Token
+	Assoc CommentAssoc
+}
+
+// Comments returns all comments of the source in document order, including
+// comments in sections skipped by the preprocessor.
+//
+// A comment is trailing if it starts on the line the preceding code token
+// ends. Otherwise it is leading if the next token is on the same or the
+// following line and is either code or a leading comment itself. All other
+// comments are standalone.
+func (n *Root) Comments() []Comment {
+	if n == nil {
+		return nil
+	}
+	line := func(offset int) int { return n.Position(offset).Line }
+
+	var comments []Comment
+	prev := -1 // index of the last code token
+	for i, tok := range n.tokens {
+		if tok.Kind != COMMENT {
+			if tok.Kind != EOF {
+				prev = i
+			}
+			continue
+		}
+		c := Comment{Token: &tokenNode{Root: n, idx: i}}
+		if prev >= 0 && line(n.tokens[prev].End) == line(tok.Begin) {
+			c.Assoc = Trailing
+		}
+		comments = append(comments, c)
+	}
+
+	// Leading comments are determined backwards, because a comment
+	// followed by a leading comment is leading, too.
+	next := -1 // index of the following code token or leading comment
+	k := len(comments) - 1
+	for i := len(n.tokens) - 1; i >= 0; i-- {
+		tok := n.tokens[i]
+		if tok.Kind != COMMENT {
+			next = -1
+			if tok.Kind != EOF {
+				next = i
+			}
+			continue
+		}
+		c := &comments[k]
+		k--
+		if c.Assoc == Standalone && next >= 0 && line(n.tokens[next].Begin)-line(tok.End) <= 1 {
+			c.Assoc = Leading
+		}
+		next = -1
+		if c.Assoc == Leading {
+			next = i
+		}
+	}
+	return comments
+}
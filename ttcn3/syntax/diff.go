@@ -0,0 +1,154 @@
+package syntax
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sort"
+)
+
+// ChangeKind describes how a definition changed.
+type ChangeKind int
+
+const (
+	Added ChangeKind = iota + 1
+	Removed
+	Modified
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Modified:
+		return "modified"
+	default:
+		return fmt.Sprintf("ChangeKind(%d)", int(k))
+	}
+}
+
+// A Change describes a module or module-level definition, which differs
+// between two syntax trees.
+type Change struct {
+	Kind ChangeKind
+
+	// Name is the qualified name of the definition, like test.A.
+	Name string
+
+	// Old is the span of the definition in the first tree, New the span
+	// in the second. Old is zero for added, New for removed definitions.
+	Old, New Span
+}
+
+// Diff returns the modules and module-level definitions, which were added,
+// removed or modified from tree a to tree b, sorted by name. Trees may be nil.
+// The trees of package ttcn3 are diffed by their Root.
+//
+// Definitions are compared by their tokens. Changes of white space and
+// comments, including documentation comments, are no modifications.
+// Definitions are identified by their qualified names. Imports and friend
+// declarations are named like "test.import from Other"; when a module imports
+// the same module multiple times, the imports are numbered in order, like
+// "test.import from Other#2". Variable, constant and template declarations
+// with multiple declarators are compared as a whole: modifying one declarator
+// modifies all of them. Modules themselves are only added or removed. Their
+// modifications are the changes of their definitions.
+func Diff(a, b *Root) []Change {
+	before, after := diffDefs(a), diffDefs(b)
+
+	var changes []Change
+	for name, d := range before {
+		n, ok := after[name]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Kind: Removed, Name: name, Old: d.span})
+		case d.hash != n.hash:
+			changes = append(changes, Change{Kind: Modified, Name: name, Old: d.span, New: n.span})
+		}
+	}
+	for name, n := range after {
+		if _, ok := before[name]; !ok {
+			changes = append(changes, Change{Kind: Added, Name: name, New: n.span})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes
+}
+
+type diffDef struct {
+	span Span
+	hash [sha256.Size]byte
+}
+
+// diffDefs returns the modules and module-level definitions of tree t,
+// indexed by qualified name.
+func diffDefs(root *Root) map[string]diffDef {
+	defs := make(map[string]diffDef)
+	if root == nil {
+		return defs
+	}
+
+	add := func(name string, n Node) {
+		// Comments are skipped, hence the hash does not depend on
+		// formatting.
+		h := sha256.New()
+		if first, last := n.FirstTok(), n.LastTok(); first != nil && last != nil {
+			for tok := first; tok != nil && tok.Pos() <= last.Pos(); tok = tok.NextTok() {
+				if tok.Kind() != COMMENT {
+					fmt.Fprintf(h, "%s\n", tok.String())
+				}
+			}
+		}
+		d := diffDef{span: SpanOf(n)}
+		h.Sum(d.hash[:0])
+		key := name
+		for i := 2; ; i++ {
+			if _, ok := defs[key]; !ok {
+				break
+			}
+			key = fmt.Sprintf("%s#%d", name, i)
+		}
+		defs[key] = d
+	}
+
+	var modules []*Module
+	root.Inspect(func(n Node) bool {
+		if m, ok := n.(*Module); ok {
+			modules = append(modules, m)
+			return false
+		}
+		return true
+	})
+	for _, m := range modules {
+		name := Name(m.Name)
+
+		// Modules compare equal, otherwise every modification would
+		// modify the module, too.
+		defs[name] = diffDef{span: SpanOf(m)}
+
+		var visit func(mdefs []*ModuleDef)
+		visit = func(mdefs []*ModuleDef) {
+			for _, d := range mdefs {
+				switch n := d.Def.(type) {
+				case *GroupDecl:
+					visit(n.Defs)
+				case *ValueDecl:
+					for _, decl := range n.Decls {
+						add(name+"."+Name(decl), d)
+					}
+				case *ImportDecl:
+					add(name+".import from "+Name(n.Module), d)
+				case *FriendDecl:
+					add(name+".friend module "+Name(n.Module), d)
+				default:
+					if s := Name(n); s != "" {
+						add(name+"."+s, d)
+					}
+				}
+			}
+		}
+		visit(m.Defs)
+	}
+	return defs
+}
@@ -0,0 +1,81 @@
+package syntax_test
+
+import (
+	"testing"
+
+	"github.com/nokia/ntt/ttcn3/syntax"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	parse := func(s string) *syntax.Root {
+		root, _, _ := syntax.Parse([]byte(s))
+		return root
+	}
+	changes := func(a, b string) []string {
+		var ret []string
+		for _, c := range syntax.Diff(parse(a), parse(b)) {
+			ret = append(ret, c.Kind.String()+" "+c.Name)
+		}
+		return ret
+	}
+
+	src := `module test {
+		import from other all;
+		const integer x := 1, y := 2;
+		group g { type integer T; }
+		function f() { log("hello"); }
+	}`
+
+	assert.Nil(t, changes(src, src))
+
+	// Formatting and comments are no semantic change.
+	assert.Nil(t, changes(src, `module test {
+		import   from other all;
+		const integer x := 1,
+		              y := 2;
+		group g {
+			// The T.
+			type integer T;
+		}
+		/* Say hello. */
+		function f()
+		{
+			log( "hello" );
+		}
+	}`))
+
+	assert.Equal(t, []string{"modified test.f"}, changes(src, `module test {
+		import from other all;
+		const integer x := 1, y := 2;
+		group g { type integer T; }
+		function f() { log("bye"); }
+	}`))
+
+	assert.Equal(t, []string{
+		"removed test.T",
+		"modified test.x",
+		"modified test.y",
+		"added test.z",
+	}, changes(src, `module test {
+		import from other all;
+		const integer x := 3, y := 2;
+		function f() { log("hello"); }
+		function z() {}
+	}`))
+
+	assert.Equal(t, []string{
+		"added test2",
+		"added test2.g",
+	}, changes(src, src+`module test2 { function g() {} }`))
+
+	assert.Equal(t, []string{"removed test.import from other#2"}, changes(
+		`module test { import from other all; import from other { type T } }`,
+		`module test { import from other all; }`))
+
+	c := syntax.Diff(nil, parse("module M { function f() {} }"))
+	assert.Equal(t, 2, len(c))
+	assert.Equal(t, "M.f", c[1].Name)
+	assert.Equal(t, 12, c[1].New.Begin.Column)
+	assert.False(t, c[1].Old.Begin.IsValid())
+}
@@ -2,6 +2,7 @@ package syntax
 
 import (
 	"fmt"
+	"strings"
 )
 
 // Error represents a syntax error.
@@ -11,9 +12,38 @@ type Error struct {
 }
 
 func (e Error) Error() string {
+	if e.Node == nil {
+		return e.Msg
+	}
 	if spn := SpanOf(e.Node); spn.Begin.IsValid() {
 		return fmt.Sprintf("%s: %s", spn, e.Msg)
 	}
 
 	return e.Msg
 }
+
+// Source returns the source line containing the beginning of the error
+// without line terminator. Source returns an empty string if no source is
+// available.
+func (e Error) Source() string {
+	if IsNil(e.Node) {
+		return ""
+	}
+	tok := e.FirstTok()
+	if tok == nil {
+		return ""
+	}
+	root := tok.(*tokenNode).Root
+	if root.Scanner == nil {
+		return ""
+	}
+	l := root.searchLines(tok.Pos())
+	if l < 0 {
+		return ""
+	}
+	end := len(root.src)
+	if l+1 < len(root.lines) {
+		end = root.lines[l+1]
+	}
+	return strings.TrimRight(string(root.src[root.lines[l]:end]), "\r\n")
+}
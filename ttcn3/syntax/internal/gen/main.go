@@ -15,13 +15,49 @@ import (
 )
 
 var skipTypes = map[string]bool{
-	"token": true,
+	"token":         true,
+	"RecoveryStats": true,
 }
 
 var tmpl = `// Code generated by go generate; DO NOT EDIT.
 
 package syntax
 
+// NodeKind describes the concrete type of a syntax node.
+type NodeKind int
+
+const (
+	InvalidKind NodeKind = iota
+	{{ range $name, $type := . }}{{ if $type.Exported }}{{ $name }}Kind
+	{{ end }}{{ end }}
+)
+
+// KindOf returns the kind of the given node. KindOf returns InvalidKind for
+// nil nodes and tokens.
+func KindOf(n Node) NodeKind {
+	if IsNil(n) {
+		return InvalidKind
+	}
+	switch n.(type) {
+	{{ range $name, $type := . }}{{ if $type.Exported }}case *{{ $name }}:
+		return {{ $name }}Kind
+	{{ end }}{{ end }}
+	default:
+		return InvalidKind
+	}
+}
+
+// String returns the name of the node type.
+func (k NodeKind) String() string {
+	switch k {
+	{{ range $name, $type := . }}{{ if $type.Exported }}case {{ $name }}Kind:
+		return "{{ $name }}"
+	{{ end }}{{ end }}
+	default:
+		return "Invalid"
+	}
+}
+
 {{ range $name, $type := . }}
 
 {{ if ($type.NotImplemented "FirstTok" ) }}
@@ -124,6 +160,12 @@ type Type struct {
 	Methods []string
 }
 
+// Exported reports whether the type is exported. Only exported types have a
+// NodeKind.
+func (f Type) Exported() bool {
+	return token.IsExported(f.Name)
+}
+
 func (f Type) NotImplemented(name string) bool {
 	for _, m := range f.Methods {
 		if m == name {
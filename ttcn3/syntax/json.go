@@ -0,0 +1,69 @@
+package syntax
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// jsonNode is the JSON representation of a node. The field order defines the
+// order of the JSON output.
+type jsonNode struct {
+	Type     string      `json:"type"`
+	Kind     string      `json:"kind,omitempty"`
+	Text     string      `json:"text,omitempty"`
+	Pos      int         `json:"pos"`
+	End      int         `json:"end"`
+	Children []*jsonNode `json:"children,omitempty"`
+}
+
+// MarshalJSON returns the syntax tree of n as JSON. Each node is an object
+// with its type name, its offsets pos and end and its children in source
+// order. Tokens have type Token and also provide their kind and literal
+// text. An error is returned if the tree contains a cycle.
+func MarshalJSON(n Node) ([]byte, error) {
+	if IsNil(n) {
+		return []byte("null"), nil
+	}
+	v, err := newJSONNode(n, make(map[Node]bool))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// newJSONNode converts n and its children. path contains the ancestors of n.
+func newJSONNode(n Node, path map[Node]bool) (*jsonNode, error) {
+	if path[n] {
+		return nil, fmt.Errorf("syntax: cycle at %s node", typeName(n))
+	}
+	v := &jsonNode{Type: typeName(n), Pos: n.Pos(), End: n.End()}
+	if tok, ok := n.(Token); ok {
+		v.Type = "Token"
+		v.Kind = tok.Kind().String()
+		v.Text = tok.String()
+		return v, nil
+	}
+
+	path[n] = true
+	defer delete(path, n)
+	for _, c := range n.Children() {
+		if IsNil(c) {
+			continue
+		}
+		cv, err := newJSONNode(c, path)
+		if err != nil {
+			return nil, err
+		}
+		v.Children = append(v.Children, cv)
+	}
+	return v, nil
+}
+
+func typeName(n Node) string {
+	t := reflect.TypeOf(n)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
@@ -0,0 +1,37 @@
+package syntax_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/nokia/ntt/ttcn3/syntax"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	root, _, _ := syntax.Parse([]byte(`module M {}`))
+	b, err := syntax.MarshalJSON(root)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"type":"Root","pos":0,"end":11,"children":[`+
+		`{"type":"Module","pos":0,"end":11,"children":[`+
+		`{"type":"Token","kind":"module","text":"module","pos":0,"end":6},`+
+		`{"type":"Ident","pos":7,"end":8,"children":[{"type":"Token","kind":"IDENT","text":"M","pos":7,"end":8}]},`+
+		`{"type":"Token","kind":"{","text":"{","pos":9,"end":10},`+
+		`{"type":"Token","kind":"}","text":"}","pos":10,"end":11}]}]}`, string(b))
+
+	// Output is stable.
+	b2, _ := syntax.MarshalJSON(root)
+	assert.Equal(t, b, b2)
+	assert.True(t, json.Valid(b))
+
+	b, err = syntax.MarshalJSON(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "null", string(b))
+
+	// Positions of a cycle are taken from the braces.
+	mod := root.Nodes[0].(*syntax.Module)
+	cycle := &syntax.BlockStmt{LBrace: mod.LBrace, RBrace: mod.RBrace}
+	cycle.Stmts = []syntax.Stmt{cycle}
+	_, err = syntax.MarshalJSON(cycle)
+	assert.NotNil(t, err)
+}
@@ -48,12 +48,32 @@ type Root struct {
 	Filename string
 	tokens   []token
 	errs     []error
+
+	// RecoveryStats describes how much error recovery was needed to parse
+	// the source. It is zero for sources without syntax errors.
+	RecoveryStats RecoveryStats
+}
+
+// RecoveryStats counts the error recovery of the parser.
+type RecoveryStats struct {
+	// Points is the number of syntax errors the parser recovered from.
+	Points int `json:"points"`
+
+	// SkippedTokens is the number of tokens skipped during recovery,
+	// either unexpected tokens or tokens skipped to resynchronize.
+	SkippedTokens int `json:"skipped_tokens"`
 }
 
 func (n *Root) Err() error {
 	return multierror.Append(nil, n.errs...).ErrorOrNil()
 }
 
+// Errors returns all errors found while parsing, in order of detection.
+// Syntax errors are of type Error.
+func (n *Root) Errors() []error {
+	return append([]error(nil), n.errs...)
+}
+
 func (n *Root) Position(offset int) Position {
 	if offset < 0 {
 		return Position{}
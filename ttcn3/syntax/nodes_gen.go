@@ -2,6 +2,430 @@
 
 package syntax
 
+// NodeKind describes the concrete type of a syntax node.
+type NodeKind int
+
+const (
+	InvalidKind NodeKind = iota
+	AltStmtKind
+	BehaviourSpecKind
+	BehaviourTypeDeclKind
+	BinaryExprKind
+	BlockStmtKind
+	BranchStmtKind
+	CallExprKind
+	CallStmtKind
+	CaseClauseKind
+	CommClauseKind
+	ComponentTypeDeclKind
+	CompositeLiteralKind
+	ControlPartKind
+	DeclStmtKind
+	DeclaratorKind
+	DecmatchExprKind
+	DecodedExprKind
+	DefKindExprKind
+	DoWhileStmtKind
+	EnumSpecKind
+	EnumTypeDeclKind
+	ErrorNodeKind
+	ExceptExprKind
+	ExprStmtKind
+	FieldKind
+	ForRangeStmtKind
+	ForStmtKind
+	FormalParKind
+	FormalParsKind
+	FriendDeclKind
+	FromExprKind
+	FuncDeclKind
+	GroupDeclKind
+	IdentKind
+	IfStmtKind
+	ImportDeclKind
+	IndexExprKind
+	LanguageSpecKind
+	LengthExprKind
+	ListSpecKind
+	MapSpecKind
+	MapTypeDeclKind
+	ModifiesExprKind
+	ModuleKind
+	ModuleDefKind
+	ModuleParameterGroupKind
+	MtcSpecKind
+	NodeListKind
+	ParamExprKind
+	ParametrizedIdentKind
+	ParenExprKind
+	PatternExprKind
+	PortAttributeKind
+	PortMapAttributeKind
+	PortTypeDeclKind
+	PostExprKind
+	RedirectExprKind
+	RefSpecKind
+	RegexpExprKind
+	RestrictionSpecKind
+	ReturnSpecKind
+	ReturnStmtKind
+	RootKind
+	RunsOnSpecKind
+	SelectStmtKind
+	SelectorExprKind
+	SignatureDeclKind
+	StructSpecKind
+	StructTypeDeclKind
+	SubTypeDeclKind
+	SystemSpecKind
+	TemplateDeclKind
+	UnaryExprKind
+	ValueDeclKind
+	ValueExprKind
+	ValueLiteralKind
+	WhileStmtKind
+	WithSpecKind
+	WithStmtKind
+)
+
+// KindOf returns the kind of the given node. KindOf returns InvalidKind for
+// nil nodes and tokens.
+func KindOf(n Node) NodeKind {
+	if IsNil(n) {
+		return InvalidKind
+	}
+	switch n.(type) {
+	case *AltStmt:
+		return AltStmtKind
+	case *BehaviourSpec:
+		return BehaviourSpecKind
+	case *BehaviourTypeDecl:
+		return BehaviourTypeDeclKind
+	case *BinaryExpr:
+		return BinaryExprKind
+	case *BlockStmt:
+		return BlockStmtKind
+	case *BranchStmt:
+		return BranchStmtKind
+	case *CallExpr:
+		return CallExprKind
+	case *CallStmt:
+		return CallStmtKind
+	case *CaseClause:
+		return CaseClauseKind
+	case *CommClause:
+		return CommClauseKind
+	case *ComponentTypeDecl:
+		return ComponentTypeDeclKind
+	case *CompositeLiteral:
+		return CompositeLiteralKind
+	case *ControlPart:
+		return ControlPartKind
+	case *DeclStmt:
+		return DeclStmtKind
+	case *Declarator:
+		return DeclaratorKind
+	case *DecmatchExpr:
+		return DecmatchExprKind
+	case *DecodedExpr:
+		return DecodedExprKind
+	case *DefKindExpr:
+		return DefKindExprKind
+	case *DoWhileStmt:
+		return DoWhileStmtKind
+	case *EnumSpec:
+		return EnumSpecKind
+	case *EnumTypeDecl:
+		return EnumTypeDeclKind
+	case *ErrorNode:
+		return ErrorNodeKind
+	case *ExceptExpr:
+		return ExceptExprKind
+	case *ExprStmt:
+		return ExprStmtKind
+	case *Field:
+		return FieldKind
+	case *ForRangeStmt:
+		return ForRangeStmtKind
+	case *ForStmt:
+		return ForStmtKind
+	case *FormalPar:
+		return FormalParKind
+	case *FormalPars:
+		return FormalParsKind
+	case *FriendDecl:
+		return FriendDeclKind
+	case *FromExpr:
+		return FromExprKind
+	case *FuncDecl:
+		return FuncDeclKind
+	case *GroupDecl:
+		return GroupDeclKind
+	case *Ident:
+		return IdentKind
+	case *IfStmt:
+		return IfStmtKind
+	case *ImportDecl:
+		return ImportDeclKind
+	case *IndexExpr:
+		return IndexExprKind
+	case *LanguageSpec:
+		return LanguageSpecKind
+	case *LengthExpr:
+		return LengthExprKind
+	case *ListSpec:
+		return ListSpecKind
+	case *MapSpec:
+		return MapSpecKind
+	case *MapTypeDecl:
+		return MapTypeDeclKind
+	case *ModifiesExpr:
+		return ModifiesExprKind
+	case *Module:
+		return ModuleKind
+	case *ModuleDef:
+		return ModuleDefKind
+	case *ModuleParameterGroup:
+		return ModuleParameterGroupKind
+	case *MtcSpec:
+		return MtcSpecKind
+	case *NodeList:
+		return NodeListKind
+	case *ParamExpr:
+		return ParamExprKind
+	case *ParametrizedIdent:
+		return ParametrizedIdentKind
+	case *ParenExpr:
+		return ParenExprKind
+	case *PatternExpr:
+		return PatternExprKind
+	case *PortAttribute:
+		return PortAttributeKind
+	case *PortMapAttribute:
+		return PortMapAttributeKind
+	case *PortTypeDecl:
+		return PortTypeDeclKind
+	case *PostExpr:
+		return PostExprKind
+	case *RedirectExpr:
+		return RedirectExprKind
+	case *RefSpec:
+		return RefSpecKind
+	case *RegexpExpr:
+		return RegexpExprKind
+	case *RestrictionSpec:
+		return RestrictionSpecKind
+	case *ReturnSpec:
+		return ReturnSpecKind
+	case *ReturnStmt:
+		return ReturnStmtKind
+	case *Root:
+		return RootKind
+	case *RunsOnSpec:
+		return RunsOnSpecKind
+	case *SelectStmt:
+		return SelectStmtKind
+	case *SelectorExpr:
+		return SelectorExprKind
+	case *SignatureDecl:
+		return SignatureDeclKind
+	case *StructSpec:
+		return StructSpecKind
+	case *StructTypeDecl:
+		return StructTypeDeclKind
+	case *SubTypeDecl:
+		return SubTypeDeclKind
+	case *SystemSpec:
+		return SystemSpecKind
+	case *TemplateDecl:
+		return TemplateDeclKind
+	case *UnaryExpr:
+		return UnaryExprKind
+	case *ValueDecl:
+		return ValueDeclKind
+	case *ValueExpr:
+		return ValueExprKind
+	case *ValueLiteral:
+		return ValueLiteralKind
+	case *WhileStmt:
+		return WhileStmtKind
+	case *WithSpec:
+		return WithSpecKind
+	case *WithStmt:
+		return WithStmtKind
+
+	default:
+		return InvalidKind
+	}
+}
+
+// String returns the name of the node type.
+func (k NodeKind) String() string {
+	switch k {
+	case AltStmtKind:
+		return "AltStmt"
+	case BehaviourSpecKind:
+		return "BehaviourSpec"
+	case BehaviourTypeDeclKind:
+		return "BehaviourTypeDecl"
+	case BinaryExprKind:
+		return "BinaryExpr"
+	case BlockStmtKind:
+		return "BlockStmt"
+	case BranchStmtKind:
+		return "BranchStmt"
+	case CallExprKind:
+		return "CallExpr"
+	case CallStmtKind:
+		return "CallStmt"
+	case CaseClauseKind:
+		return "CaseClause"
+	case CommClauseKind:
+		return "CommClause"
+	case ComponentTypeDeclKind:
+		return "ComponentTypeDecl"
+	case CompositeLiteralKind:
+		return "CompositeLiteral"
+	case ControlPartKind:
+		return "ControlPart"
+	case DeclStmtKind:
+		return "DeclStmt"
+	case DeclaratorKind:
+		return "Declarator"
+	case DecmatchExprKind:
+		return "DecmatchExpr"
+	case DecodedExprKind:
+		return "DecodedExpr"
+	case DefKindExprKind:
+		return "DefKindExpr"
+	case DoWhileStmtKind:
+		return "DoWhileStmt"
+	case EnumSpecKind:
+		return "EnumSpec"
+	case EnumTypeDeclKind:
+		return "EnumTypeDecl"
+	case ErrorNodeKind:
+		return "ErrorNode"
+	case ExceptExprKind:
+		return "ExceptExpr"
+	case ExprStmtKind:
+		return "ExprStmt"
+	case FieldKind:
+		return "Field"
+	case ForRangeStmtKind:
+		return "ForRangeStmt"
+	case ForStmtKind:
+		return "ForStmt"
+	case FormalParKind:
+		return "FormalPar"
+	case FormalParsKind:
+		return "FormalPars"
+	case FriendDeclKind:
+		return "FriendDecl"
+	case FromExprKind:
+		return "FromExpr"
+	case FuncDeclKind:
+		return "FuncDecl"
+	case GroupDeclKind:
+		return "GroupDecl"
+	case IdentKind:
+		return "Ident"
+	case IfStmtKind:
+		return "IfStmt"
+	case ImportDeclKind:
+		return "ImportDecl"
+	case IndexExprKind:
+		return "IndexExpr"
+	case LanguageSpecKind:
+		return "LanguageSpec"
+	case LengthExprKind:
+		return "LengthExpr"
+	case ListSpecKind:
+		return "ListSpec"
+	case MapSpecKind:
+		return "MapSpec"
+	case MapTypeDeclKind:
+		return "MapTypeDecl"
+	case ModifiesExprKind:
+		return "ModifiesExpr"
+	case ModuleKind:
+		return "Module"
+	case ModuleDefKind:
+		return "ModuleDef"
+	case ModuleParameterGroupKind:
+		return "ModuleParameterGroup"
+	case MtcSpecKind:
+		return "MtcSpec"
+	case NodeListKind:
+		return "NodeList"
+	case ParamExprKind:
+		return "ParamExpr"
+	case ParametrizedIdentKind:
+		return "ParametrizedIdent"
+	case ParenExprKind:
+		return "ParenExpr"
+	case PatternExprKind:
+		return "PatternExpr"
+	case PortAttributeKind:
+		return "PortAttribute"
+	case PortMapAttributeKind:
+		return "PortMapAttribute"
+	case PortTypeDeclKind:
+		return "PortTypeDecl"
+	case PostExprKind:
+		return "PostExpr"
+	case RedirectExprKind:
+		return "RedirectExpr"
+	case RefSpecKind:
+		return "RefSpec"
+	case RegexpExprKind:
+		return "RegexpExpr"
+	case RestrictionSpecKind:
+		return "RestrictionSpec"
+	case ReturnSpecKind:
+		return "ReturnSpec"
+	case ReturnStmtKind:
+		return "ReturnStmt"
+	case RootKind:
+		return "Root"
+	case RunsOnSpecKind:
+		return "RunsOnSpec"
+	case SelectStmtKind:
+		return "SelectStmt"
+	case SelectorExprKind:
+		return "SelectorExpr"
+	case SignatureDeclKind:
+		return "SignatureDecl"
+	case StructSpecKind:
+		return "StructSpec"
+	case StructTypeDeclKind:
+		return "StructTypeDecl"
+	case SubTypeDeclKind:
+		return "SubTypeDecl"
+	case SystemSpecKind:
+		return "SystemSpec"
+	case TemplateDeclKind:
+		return "TemplateDecl"
+	case UnaryExprKind:
+		return "UnaryExpr"
+	case ValueDeclKind:
+		return "ValueDecl"
+	case ValueExprKind:
+		return "ValueExpr"
+	case ValueLiteralKind:
+		return "ValueLiteral"
+	case WhileStmtKind:
+		return "WhileStmt"
+	case WithSpecKind:
+		return "WithSpec"
+	case WithStmtKind:
+		return "WithStmt"
+
+	default:
+		return "Invalid"
+	}
+}
+
 func (n *AltStmt) FirstTok() Token {
 	switch {
 
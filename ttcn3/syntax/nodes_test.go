@@ -43,3 +43,21 @@ func printNode(n syntax.Node) string {
 		return syntax.Name(n)
 	}
 }
+
+func TestKindOf(t *testing.T) {
+	root, _, _ := syntax.Parse([]byte("module M {}"))
+	if len(root.Nodes) != 1 {
+		t.Fatalf("expected one module, got %d nodes", len(root.Nodes))
+	}
+	if k := syntax.KindOf(root.Nodes[0]); k != syntax.ModuleKind || k.String() != "Module" {
+		t.Errorf("KindOf(module) = %v, want Module", k)
+	}
+
+	// Tokens have no kind of their own.
+	if k := syntax.KindOf(root.FirstTok()); k != syntax.InvalidKind || k.String() != "Invalid" {
+		t.Errorf("KindOf(token) = %v, want Invalid", k)
+	}
+	if k := syntax.KindOf(nil); k != syntax.InvalidKind {
+		t.Errorf("KindOf(nil) = %v, want Invalid", k)
+	}
+}
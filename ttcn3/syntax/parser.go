@@ -346,6 +346,9 @@ type bailout struct{}
 func (p *parser) error(n Node, msg string, args ...interface{}) {
 	err := Error{Node: n, Msg: fmt.Sprintf(msg, args...)}
 	p.errs = append(p.errs, err)
+	if !p.speculating() {
+		p.RecoveryStats.Points++
+	}
 }
 
 func (p *parser) errorExpected(what string) {
@@ -356,6 +359,9 @@ func (p *parser) errorExpected(what string) {
 func (p *parser) expect(k Kind) Token {
 	if p.tok != k {
 		p.errorExpected("'" + k.String() + "'")
+		if p.tok != EOF && !p.speculating() {
+			p.RecoveryStats.SkippedTokens++
+		}
 	}
 	return p.consume() // make progress
 }
@@ -403,6 +409,9 @@ func (p *parser) advance(to map[Kind]bool) {
 			// previous error is present, and thus is preferred
 			// over a non-terminating parse.
 		}
+		if !p.speculating() {
+			p.RecoveryStats.SkippedTokens++
+		}
 	}
 }
 
@@ -359,21 +359,43 @@ func TestTypeParametrization(t *testing.T) {
 	testParse(t, tests, func(p *parser) { p.parseModuleDef() })
 }
 
+func TestRecoveryStats(t *testing.T) {
+	tests := []struct {
+		input string
+		want  RecoveryStats
+	}{
+		{`module m { function f() { if (x) { y := 1 } } }`, RecoveryStats{}},
+		{`module m { function f() { x := ; y := 1 } }`, RecoveryStats{Points: 1, SkippedTokens: 0}},
+		{`module m { 1 2 3; function f() {} }`, RecoveryStats{Points: 1, SkippedTokens: 3}},
+		{`module m { function f() { if (x { } }`, RecoveryStats{Points: 4, SkippedTokens: 2}},
+		{`module m {} }`, RecoveryStats{Points: 1}},
+	}
+	for _, tt := range tests {
+		root, _, _ := Parse([]byte(tt.input))
+		if root.RecoveryStats != tt.want {
+			t.Errorf("Parse(%#q): got %+v, want %+v (%v)", tt.input, root.RecoveryStats, tt.want, root.Err())
+		}
+	}
+}
+
 func testParse(t *testing.T, tests []Test, f func(p *parser)) {
 	for _, tt := range tests {
-		err := anyParse(tt.input, f)
+		stats, err := anyParse(tt.input, f)
 		if tt.expect == pass && err != nil {
 			t.Errorf("Parse(%#q):\n\t%v\n\n", tt.input, err)
 		}
+		if tt.expect == pass && stats != (RecoveryStats{}) {
+			t.Errorf("Parse(%#q): unexpected recovery %+v", tt.input, stats)
+		}
 		if tt.expect == fail && err == nil {
 			t.Errorf("breakage vanished: Parse(%#q)", tt.input)
 		}
 	}
 }
 
-func anyParse(input string, f func(p *parser)) error {
+func anyParse(input string, f func(p *parser)) (RecoveryStats, error) {
 	p := NewParser([]byte(input))
 	f(p)
 	// TODO(5nord) temporary hack until we have proper error handling
-	return p.Err()
+	return p.RecoveryStats, p.Err()
 }
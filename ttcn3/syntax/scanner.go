@@ -1,5 +1,7 @@
 package syntax
 
+import "bytes"
+
 // Tokenize given source code and return a root node with all the tokens.
 func Tokenize(src []byte) *Root {
 	root := newRoot(src)
@@ -13,12 +15,23 @@ func Tokenize(src []byte) *Root {
 	return root
 }
 
+// bom is the UTF-8 encoded byte order mark.
+var bom = []byte{0xEF, 0xBB, 0xBF}
+
 // NewScanner returns a new TTCN-3 scanner for src.
+//
+// A leading byte order mark is skipped. Token offsets still refer to src, but
+// columns of the first line are counted from the end of the byte order mark.
 func NewScanner(src []byte) *Scanner {
-	return &Scanner{
+	s := &Scanner{
 		src:   src,
 		lines: []int{0},
 	}
+	if bytes.HasPrefix(src, bom) {
+		s.pos = len(bom)
+		s.lines[0] = len(bom)
+	}
+	return s
 }
 
 // Scanner scans a TTCN-3 source.
@@ -33,6 +46,11 @@ func (s *Scanner) Lines() []int {
 	return s.lines
 }
 
+// Size returns the length of the source in bytes.
+func (s *Scanner) Size() int {
+	return len(s.src)
+}
+
 // Scan returns the next token and its range.
 func (s *Scanner) Scan() (Kind, int, int) {
 	s.scanWhitespace()
@@ -214,8 +232,13 @@ func (s *Scanner) scanWhitespace() {
 	}
 }
 
+// scanLine scans to the end of the line. The carriage return of a CRLF line
+// ending is not part of the line.
 func (s *Scanner) scanLine() {
 	for s.pos < len(s.src) && s.src[s.pos] != '\n' {
+		if s.src[s.pos] == '\r' && s.pos+1 < len(s.src) && s.src[s.pos+1] == '\n' {
+			return
+		}
 		s.pos++
 	}
 }
@@ -23,6 +23,15 @@ func TestTokenize(t *testing.T) {
 			`COMMENT "//"`,
 			`EOF`,
 		}},
+		{"\xEF\xBB\xBFfoo", []string{
+			`IDENT "foo"`,
+			`EOF`,
+		}},
+		{"// foo\r\nbar\r\n", []string{
+			`COMMENT "// foo"`,
+			`IDENT "bar"`,
+			`EOF`,
+		}},
 	}
 	for _, test := range tests {
 		root := Tokenize([]byte(test.input))
@@ -30,7 +30,16 @@ func FindChildOf(n Node, pos int) Node {
 	if IsNil(n) || pos < 0 {
 		return nil
 	}
-	for _, c := range n.Children() {
+	return ChildAt(n.Children(), pos)
+}
+
+// ChildAt returns the node of children containing position pos, like
+// FindChildOf does. ChildAt is useful when the children of a node are cached.
+func ChildAt(children []Node, pos int) Node {
+	if pos < 0 {
+		return nil
+	}
+	for _, c := range children {
 		if IsNil(c) {
 			continue
 		}
@@ -178,6 +187,24 @@ L:
 	return ret
 }
 
+// Text returns the source text of the given node, from the beginning of its
+// first token to the end of its last token. Comments and white space between
+// tokens are preserved.
+func Text(n Node) string {
+	if IsNil(n) {
+		return ""
+	}
+	first, last := n.FirstTok(), n.LastTok()
+	if first == nil || last == nil {
+		return ""
+	}
+	root := first.(*tokenNode).Root
+	if root.Scanner == nil || first.Pos() < 0 || last.End() > len(root.src) {
+		return ""
+	}
+	return string(root.src[first.Pos():last.End()])
+}
+
 // Inspect traverse the syntax tree in depth-first order.
 func Inspect(n Node, f func(Node) bool) {
 	if n != nil {
@@ -187,3 +214,47 @@ func Inspect(n Node, f func(Node) bool) {
 		f(nil)
 	}
 }
+
+// Walk traverses the syntax tree in depth-first order like Inspect, but
+// passes the parent of each node to f, nil for n itself. If f returns false,
+// the children of the node are skipped. Unlike Inspect, f is not called with
+// nil nodes.
+func Walk(n Node, f func(n, parent Node) bool) {
+	stack := []Node{nil}
+	Inspect(n, func(c Node) bool {
+		// Inspect calls f(nil) when the children of c are done.
+		if c == nil {
+			stack = stack[:len(stack)-1]
+			return false
+		}
+		parent := stack[len(stack)-1]
+		stack = append(stack, c)
+		return !IsNil(c) && f(c, parent)
+	})
+}
+
+// Parent returns the parent of node n in the syntax tree of root or nil, if
+// n is root or not part of the tree. Parent walks the tree; for repeated
+// lookups use ttcn3.Tree.ParentOf, which caches.
+func Parent(root, n Node) Node {
+	if IsNil(n) {
+		return nil
+	}
+	var (
+		ret   Node
+		found bool
+		pos   = n.Pos()
+	)
+	Walk(root, func(c, parent Node) bool {
+		if found {
+			return false
+		}
+		if c == n {
+			ret, found = parent, true
+			return false
+		}
+		// Only nodes enclosing n may be its ancestors.
+		return pos < 0 || c.Pos() < 0 || c.Pos() <= pos && pos <= c.End()
+	})
+	return ret
+}
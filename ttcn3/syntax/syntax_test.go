@@ -71,3 +71,51 @@ func TestDoc(t *testing.T) {
 		assert.Equal(t, want, testDoc(t, input))
 	})
 }
+
+func TestWalk(t *testing.T) {
+	root, _, _ := syntax.Parse([]byte(`module M { function f() { var integer x := y; } }`))
+
+	// Every node but root has a parent, which was visited before.
+	visited := map[syntax.Node]bool{}
+	var fn *syntax.FuncDecl
+	var y syntax.Node
+	syntax.Walk(root, func(n, parent syntax.Node) bool {
+		if n == root {
+			assert.Nil(t, parent)
+		} else {
+			assert.True(t, visited[parent], "%T has unvisited parent %T", n, parent)
+		}
+		visited[n] = true
+		if x, ok := n.(*syntax.FuncDecl); ok {
+			fn = x
+		}
+		if id, ok := n.(*syntax.Ident); ok && id.String() == "y" {
+			y = id
+		}
+		return true
+	})
+	assert.NotNil(t, fn)
+	assert.NotNil(t, y)
+
+	// Enclosing function of y.
+	var enclosing syntax.Node
+	for p := syntax.Parent(root, y); p != nil; p = syntax.Parent(root, p) {
+		if _, ok := p.(*syntax.FuncDecl); ok {
+			enclosing = p
+			break
+		}
+	}
+	assert.Equal(t, fn, enclosing)
+
+	assert.IsType(t, &syntax.Module{}, syntax.Parent(root, root.Nodes[0].(*syntax.Module).Defs[0]))
+	assert.Nil(t, syntax.Parent(root, root))
+	assert.Nil(t, syntax.Parent(root, nil))
+
+	// Returning false skips the children.
+	n := 0
+	syntax.Walk(root, func(syntax.Node, syntax.Node) bool {
+		n++
+		return false
+	})
+	assert.Equal(t, 1, n)
+}
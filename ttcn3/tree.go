@@ -1,14 +1,21 @@
 package ttcn3
 
 import (
+	"context"
 	"fmt"
 	"sync"
 
 	"github.com/hashicorp/go-multierror"
+	"github.com/nokia/ntt/internal/loc"
 	"github.com/nokia/ntt/internal/log"
+	"github.com/nokia/ntt/internal/memoize"
+	"github.com/nokia/ntt/ttcn3/doc"
 	"github.com/nokia/ntt/ttcn3/syntax"
 )
 
+// Tree is a syntax.Node, like the syntax.Root it embeds.
+var _ syntax.Node = (*Tree)(nil)
+
 // Tree represents the TTCN-3 syntax tree, usually of a file.
 type Tree struct {
 	*syntax.Root
@@ -21,6 +28,107 @@ type Tree struct {
 	parentsMu sync.Mutex
 	scopes    map[syntax.Node]*Scope
 	scopesMu  sync.Mutex
+
+	// children caches the children of nodes visited by ParentOf.
+	children   map[syntax.Node][]syntax.Node
+	childrenMu sync.Mutex
+
+	// docTags keeps cached documentation tags alive as long as the tree.
+	docTags   map[string]*memoize.Handle
+	docTagsMu sync.Mutex
+
+	// file maps the offsets of the tree to loc.Pos values (see PosFor).
+	file     *loc.File
+	fileOnce sync.Once
+}
+
+// Diagnostics returns all errors found while parsing the tree, not only the
+// first one. The offsets of the diagnostic nodes map to loc.Pos values of a
+// FileSet using loc.File.Pos (see ParseFilesWithFileSet). Errors not
+// belonging to a node, like a file not found, have a nil node.
+func (t *Tree) Diagnostics() []Diagnostic {
+	if t == nil {
+		return nil
+	}
+	if t.Root == nil {
+		if t.Err == nil {
+			return nil
+		}
+		return []Diagnostic{{Msg: t.Err.Error()}}
+	}
+	var diags []Diagnostic
+	for _, err := range t.Root.Errors() {
+		d, ok := err.(syntax.Error)
+		if !ok {
+			d = Diagnostic{Msg: err.Error()}
+		}
+		diags = append(diags, d)
+	}
+	return diags
+}
+
+// A Comment is a comment of a syntax tree together with its association with
+// the surrounding code.
+type Comment = syntax.Comment
+
+// Comments returns all comments of the tree in document order. See
+// syntax.Root.Comments for how comments are associated with code.
+func (t *Tree) Comments() []Comment {
+	if t == nil || t.Root == nil {
+		return nil
+	}
+	return t.Root.Comments()
+}
+
+// locFile returns the file of the tree, added to a file set of its own. It
+// returns nil for trees without syntax tree.
+func (t *Tree) locFile() *loc.File {
+	if t == nil || t.Root == nil {
+		return nil
+	}
+	t.fileOnce.Do(func() {
+		t.file = addFile(loc.NewFileSet(), t.filename, t)
+	})
+	return t.file
+}
+
+// PosFor returns the loc.Pos of the byte offset in the file set of the
+// tree's own file. Invalid offsets and trees without syntax tree result in
+// loc.NoPos. Other file sets are mapped with ParseFilesWithFileSet.
+//
+// PosFor shadows syntax.Root.PosFor, which takes line and column. Use
+// t.Root.PosFor for those. Pos cannot be shadowed likewise, because Tree
+// is a syntax.Node.
+func (t *Tree) PosFor(offset int) loc.Pos {
+	f := t.locFile()
+	if f == nil || offset < 0 || offset > f.Size() {
+		return loc.NoPos
+	}
+	return f.Pos(offset)
+}
+
+// OffsetFor returns the byte offset of p, as returned by PosFor. It returns
+// -1 for positions outside the tree's file.
+func (t *Tree) OffsetFor(p loc.Pos) int {
+	f := t.locFile()
+	if f == nil || int(p) < f.Base() || int(p) > f.Base()+f.Size() {
+		return -1
+	}
+	return f.Offset(p)
+}
+
+// Position returns the 1-based line and column and the filename of p, as
+// returned by PosFor. Columns count bytes. Positions outside the tree's
+// file, like loc.NoPos, result in zero values.
+//
+// Position shadows syntax.Root.Position, which takes a byte offset. Use
+// t.Root.Position for offsets.
+func (t *Tree) Position(p loc.Pos) (line, col int, file string) {
+	if t.OffsetFor(p) < 0 {
+		return 0, 0, ""
+	}
+	pos := t.locFile().Position(p)
+	return pos.Line, pos.Column, pos.Filename
 }
 
 // Filename returns the filename of the file that was parsed.
@@ -38,7 +146,7 @@ func (t *Tree) ParentOf(n syntax.Node) syntax.Node {
 	if p, ok := t.parents[n]; ok {
 		return p
 	}
-	parents := parentsSlow(n, t.Root)
+	parents := t.parentsSlow(n)
 	if len(parents) == 0 {
 		t.parents[n] = nil
 		return nil
@@ -53,7 +161,27 @@ func (t *Tree) ParentOf(n syntax.Node) syntax.Node {
 	return parents[0]
 }
 
-func parentsSlow(tgt, root syntax.Node) []syntax.Node {
+// ChildrenOf returns the children of node n, like n.Children does. Unlike
+// n.Children, the result is computed only once per node and shared by all
+// callers, which saves allocations when a tree is traversed many times.
+//
+// Trees are assumed to be immutable once parsed, like for all other caches of
+// Tree: Neither the returned slice nor the syntax tree must be modified.
+func (t *Tree) ChildrenOf(n syntax.Node) []syntax.Node {
+	t.childrenMu.Lock()
+	defer t.childrenMu.Unlock()
+	if t.children == nil {
+		t.children = make(map[syntax.Node][]syntax.Node)
+	}
+	children, ok := t.children[n]
+	if !ok {
+		children = n.Children()
+		t.children[n] = children
+	}
+	return children
+}
+
+func (t *Tree) parentsSlow(tgt syntax.Node) []syntax.Node {
 	var (
 		path  []syntax.Node
 		visit func(n syntax.Node)
@@ -70,13 +198,13 @@ func parentsSlow(tgt, root syntax.Node) []syntax.Node {
 				return
 			}
 			path = append(path, n)
-			if child := syntax.FindChildOf(n, pos); !syntax.IsNil(child) {
+			if child := syntax.ChildAt(t.ChildrenOf(n), pos); !syntax.IsNil(child) {
 				visit(child)
 			}
 		}
 
 	}
-	visit(root)
+	visit(t.Root)
 
 	// Reverse path so leaf is first element.
 	for i := 0; i < len(path)/2; i++ {
@@ -107,6 +235,26 @@ func (t *Tree) ModuleOf(n syntax.Node) *syntax.Module {
 	return nil
 }
 
+// FindAll returns all nodes of the given kinds in document order.
+func (t *Tree) FindAll(kinds ...syntax.NodeKind) []syntax.Node {
+	want := make(map[syntax.NodeKind]bool, len(kinds))
+	for _, k := range kinds {
+		want[k] = true
+	}
+
+	var nodes []syntax.Node
+	t.Inspect(func(n syntax.Node) bool {
+		if n == nil {
+			return false
+		}
+		if want[syntax.KindOf(n)] {
+			nodes = append(nodes, n)
+		}
+		return true
+	})
+	return nodes
+}
+
 func (t *Tree) Modules() []*Node {
 	var defs []*Node
 	t.Inspect(func(n syntax.Node) bool {
@@ -191,31 +339,86 @@ func (t *Tree) Controls() []*Node {
 	return defs
 }
 
-func (t *Tree) ModulePars() []*Node {
-	var defs []*Node
+// ModulePar describes a single module parameter declaration.
+type ModulePar struct {
+	Name string
+
+	// Type is the source text of the parameter type.
+	Type string
+
+	// Default is the source text of the default value expression or empty
+	// if the parameter has no default value.
+	Default string
+
+	Node *syntax.Declarator
+}
+
+// ModulePars returns all module parameters declared in the tree, including
+// those in grouped declarations, like `modulepar integer a := 1, b` or
+// `modulepar { integer a := 1; charstring b }`.
+func (t *Tree) ModulePars() []ModulePar {
+	var pars []ModulePar
 	t.Inspect(func(n syntax.Node) bool {
 		switch n := n.(type) {
 		case *syntax.Module, *syntax.ModuleDef, *syntax.GroupDecl, *syntax.ModuleParameterGroup:
 			return true
 
 		case *syntax.ValueDecl:
-			if n.Kind.Kind() != syntax.MODULEPAR && n.Kind.Kind() != syntax.ILLEGAL {
+			if n.Kind != nil && n.Kind.Kind() != syntax.MODULEPAR && n.Kind.Kind() != syntax.ILLEGAL {
 				return false
 			}
-			return true
-
-		case *syntax.Declarator:
-			defs = append(defs, &Node{Node: n, Tree: t})
+			for _, d := range n.Decls {
+				p := ModulePar{
+					Name:    d.Name.String(),
+					Type:    syntax.Text(n.Type),
+					Default: syntax.Text(d.Value),
+					Node:    d,
+				}
+				for _, a := range d.ArrayDef {
+					p.Type += syntax.Text(a)
+				}
+				pars = append(pars, p)
+			}
 		}
 		return false
 	})
-	return defs
+	return pars
+}
+
+// docTagsKey is the cache key of the tags of a documentation comment.
+type docTagsKey string
+
+// DocTags returns the tags of the documentation comment of n, like
+// doc.FindAllTags does. Tags are cached by comment text, which makes repeated
+// enumerations of the same tree cheap. The returned slice must not be
+// modified.
+func (t *Tree) DocTags(n syntax.Node) [][]string {
+	s := syntax.Doc(n)
+	if s == "" {
+		return nil
+	}
+
+	t.docTagsMu.Lock()
+	h, ok := t.docTags[s]
+	if !ok {
+		h = cache.Bind(docTagsKey(s), func(context.Context) interface{} {
+			tags := doc.FindAllTags(s)
+			return &tags
+		})
+		if t.docTags == nil {
+			t.docTags = make(map[string]*memoize.Handle)
+		}
+		t.docTags[s] = h
+	}
+	t.docTagsMu.Unlock()
+
+	return *h.Get(context.TODO()).(*[][]string)
 }
 
 // IdentifierAt returns the primary expression enclosing the identifer at the
 // given position.
 func (t *Tree) IdentifierAt(line, col int) syntax.Expr {
-	pos := t.PosFor(line, col)
+	pos := t.Root.PosFor(line, col)
 	s := t.sliceAt(pos)
 	if len(s) == 0 {
 		log.Debugf("%d:%d: no expression at cursor position.\n", line, col)
@@ -244,6 +447,33 @@ func (t *Tree) ExprAt(pos int) syntax.Expr {
 	return nil
 }
 
+// NodeAt returns the innermost node, excluding tokens, covering position pos,
+// as returned by PosFor. NodeAt returns nil, if pos is outside the tree or if
+// the tree has errors.
+func (t *Tree) NodeAt(pos loc.Pos) syntax.Node {
+	if path := t.Path(pos); len(path) > 0 {
+		return path[len(path)-1]
+	}
+	return nil
+}
+
+// Path returns the nodes covering position pos, starting with the root and
+// ending with the innermost node, as returned by NodeAt.
+func (t *Tree) Path(pos loc.Pos) []syntax.Node {
+	if t == nil || t.Err != nil {
+		return nil
+	}
+	offset := t.OffsetFor(pos)
+	if offset < 0 || offset < t.Root.Pos() || offset >= t.Root.End() {
+		return nil
+	}
+	path := t.sliceAt(offset)
+	for i := 0; i < len(path)/2; i++ {
+		path[i], path[len(path)-1-i] = path[len(path)-1-i], path[i]
+	}
+	return path
+}
+
 // sliceAt returns the slice of nodes at the given position.
 func (t *Tree) sliceAt(pos int) []syntax.Node {
 	var (
@@ -1,10 +1,16 @@
 package ttcn3_test
 
 import (
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
 
+	"github.com/nokia/ntt/internal/loc"
 	"github.com/nokia/ntt/internal/ntttest"
 	"github.com/nokia/ntt/ttcn3"
+	"github.com/nokia/ntt/ttcn3/doc"
+	"github.com/nokia/ntt/ttcn3/syntax"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -56,6 +62,172 @@ func TestSliceAt(t *testing.T) {
 	}
 }
 
+func TestFindAll(t *testing.T) {
+	tree := ttcn3.Parse(`
+		module M {
+			testcase tc1() { f() }
+			function f() {}
+			control { execute(tc1()) }
+		}`)
+
+	var actual []string
+	for _, n := range tree.FindAll(syntax.FuncDeclKind, syntax.ControlPartKind) {
+		actual = append(actual, nodeDesc(n))
+	}
+	assert.Equal(t, []string{
+		"*syntax.FuncDecl(tc1)",
+		"*syntax.FuncDecl(f)",
+		"*syntax.ControlPart(control)",
+	}, actual)
+	assert.Nil(t, tree.FindAll())
+}
+
+func TestChildrenOf(t *testing.T) {
+	tree := ttcn3.Parse("module M { function f() { x := 1 } }")
+	f := tree.Funcs()[0].Node
+
+	children := tree.ChildrenOf(f)
+	assert.Equal(t, f.Children(), children)
+	assert.Equal(t, fmt.Sprintf("%p", children), fmt.Sprintf("%p", tree.ChildrenOf(f)), "children are not cached")
+	assert.Equal(t, tree.Root.Children(), tree.ChildrenOf(tree.Root))
+}
+
+func TestDiagnostics(t *testing.T) {
+	tree := ttcn3.ParseString("test.ttcn3", "module M {\n  const integer x := ;\n  const integer y := ;\n}")
+	diags := tree.Diagnostics()
+	assert.Len(t, diags, 2)
+	var lines []int
+	for _, d := range diags {
+		line, _, _ := tree.Position(tree.PosFor(d.Node.Pos()))
+		lines = append(lines, line)
+	}
+	assert.Equal(t, []int{2, 3}, lines)
+
+	assert.Nil(t, ttcn3.ParseString("test.ttcn3", "module M {}").Diagnostics())
+	assert.Nil(t, (*ttcn3.Tree)(nil).Diagnostics())
+
+	diags = (&ttcn3.Tree{Err: errors.New("file not found")}).Diagnostics()
+	assert.Equal(t, []string{"file not found"}, []string{diags[0].Error()})
+	assert.Nil(t, diags[0].Node)
+}
+
+func TestComments(t *testing.T) {
+	src := `// header
+
+/* doc of x
+   continued */
+// more doc
+module M {
+	const integer x := 1; // trailing x
+	/* inline */ const integer y := 2; /* trailing y */
+
+	// standalone
+
+	// doc of z
+	const integer z := 3;
+} // end
+`
+	tree := ttcn3.ParseString("comments.ttcn3", src)
+	var got []string
+	for _, c := range tree.Comments() {
+		span := syntax.SpanOf(c)
+		got = append(got, fmt.Sprintf("%d:%d-%d:%d %s %s",
+			span.Begin.Line, span.Begin.Column, span.End.Line, span.End.Column, c.Assoc, c.String()))
+	}
+	assert.Equal(t, []string{
+		"1:1-1:10 standalone // header",
+		"3:1-4:16 leading /* doc of x\n   continued */",
+		"5:1-5:12 leading // more doc",
+		"7:24-7:37 trailing // trailing x",
+		"8:2-8:14 leading /* inline */",
+		"8:37-8:53 trailing /* trailing y */",
+		"10:2-10:15 standalone // standalone",
+		"12:2-12:13 leading // doc of z",
+		"14:3-14:9 trailing // end",
+	}, got)
+
+	assert.Nil(t, ttcn3.ParseString("test.ttcn3", "module M {}").Comments())
+	assert.Nil(t, (&ttcn3.Tree{Err: errors.New("file not found")}).Comments())
+}
+
+func TestPosFor(t *testing.T) {
+	src := "module M {\n  const charstring s := \"äöü\"; const integer x := 1;\n}\n"
+	tree := ttcn3.ParseString("utf8.ttcn3", src)
+	x := strings.Index(src, "x :=")
+
+	p := tree.PosFor(x)
+	assert.True(t, p.IsValid())
+	assert.Equal(t, x, tree.OffsetFor(p))
+	line, col, file := tree.Position(p)
+	assert.Equal(t, "utf8.ttcn3", file)
+	assert.Equal(t, 2, line)
+	assert.Equal(t, x-strings.Index(src, "\n"), col, "columns count bytes")
+	assert.Equal(t, tree.PosFor(0), tree.PosFor(0), "positions are stable")
+
+	assert.Equal(t, loc.NoPos, tree.PosFor(-1))
+	assert.Equal(t, loc.NoPos, tree.PosFor(len(src)+1))
+	assert.Equal(t, -1, tree.OffsetFor(loc.NoPos))
+
+	broken := &ttcn3.Tree{Err: errors.New("file not found")}
+	assert.Equal(t, loc.NoPos, broken.PosFor(0))
+	assert.Equal(t, -1, broken.OffsetFor(1))
+	line, col, file = broken.Position(1)
+	assert.Equal(t, []interface{}{0, 0, ""}, []interface{}{line, col, file})
+}
+
+func TestPosition(t *testing.T) {
+	tree := parseFile(t, t.Name(), "module M {\n\ttype integer x\n}")
+	file := tree.Filename()
+
+	tests := []struct {
+		pos       loc.Pos
+		line, col int
+		file      string
+	}{
+		{pos: loc.NoPos},
+		{pos: tree.PosFor(0), line: 1, col: 1, file: file},
+		{pos: tree.PosFor(10), line: 1, col: 11, file: file},
+		{pos: tree.PosFor(11), line: 2, col: 1, file: file},
+		{pos: tree.PosFor(18), line: 2, col: 8, file: file},
+	}
+	for _, tt := range tests {
+		line, col, file := tree.Position(tt.pos)
+		assert.Equal(t, []interface{}{tt.line, tt.col, tt.file}, []interface{}{line, col, file}, "pos %d", tt.pos)
+	}
+
+	var empty *ttcn3.Tree
+	line, col, file := empty.Position(empty.PosFor(0))
+	assert.Equal(t, []interface{}{0, 0, ""}, []interface{}{line, col, file})
+}
+
+func TestNodeAt(t *testing.T) {
+	src := "module M {\n  function f() { var integer x := y + 1; }\n}\n"
+	tree := ttcn3.ParseString("nodeat.ttcn3", src)
+	pos := tree.PosFor(strings.Index(src, "y +"))
+
+	n := tree.NodeAt(pos)
+	assert.IsType(t, &syntax.Ident{}, n)
+	assert.Equal(t, "y", n.(*syntax.Ident).String())
+
+	var kinds []string
+	for _, n := range tree.Path(pos) {
+		kinds = append(kinds, fmt.Sprintf("%T", n))
+	}
+	assert.Equal(t, "*syntax.Root", kinds[0])
+	assert.Equal(t, "*syntax.Module", kinds[1])
+	assert.Contains(t, kinds, "*syntax.FuncDecl")
+	assert.Contains(t, kinds, "*syntax.BinaryExpr")
+	assert.Equal(t, "*syntax.Ident", kinds[len(kinds)-1])
+
+	assert.Nil(t, tree.NodeAt(loc.NoPos))
+	assert.Nil(t, tree.NodeAt(tree.PosFor(len(src))))
+	assert.Nil(t, tree.Path(loc.NoPos))
+	assert.Nil(t, (&ttcn3.Tree{}).NodeAt(1))
+
+	broken := ttcn3.ParseString("broken.ttcn3", "module M { const integer x := ; }")
+	assert.Nil(t, broken.NodeAt(broken.PosFor(12)))
+}
+
 func TestExprAt(t *testing.T) {
 	tests := []struct {
 		input string
@@ -83,7 +255,7 @@ func TestExprAt(t *testing.T) {
 		t.Run(tt.input, func(t *testing.T) {
 			source, cursor := ntttest.CutCursor(tt.input)
 			tree := parseFile(t, t.Name(), source)
-			pos := tree.Position(cursor)
+			pos := tree.Root.Position(cursor)
 			actual := nodeDesc(tree.IdentifierAt(pos.Line, pos.Column))
 			assert.Equal(t, tt.want, actual)
 		})
@@ -308,7 +480,7 @@ func TestLookup(t *testing.T) {
 			tt.input, cursor = ntttest.CutCursor(tt.input)
 
 			tree := parseFile(t, t.Name(), tt.input)
-			pos := tree.Position(cursor)
+			pos := tree.Root.Position(cursor)
 			ids := enumerateIDs(tree.Root)
 
 			db := &ttcn3.DB{}
@@ -326,3 +498,123 @@ func TestLookup(t *testing.T) {
 		})
 	}
 }
+
+func TestModulePars(t *testing.T) {
+	tree := ttcn3.Parse(`
+		module M {
+			modulepar integer PX_A := 1, PX_B;
+			modulepar charstring PX_C := "foo" & "bar";
+			modulepar { float PX_D := 1.0; boolean PX_E }
+			group G { modulepar R.f PX_F := { x := 1 } }
+			modulepar integer PX_G[2] := { 1, 2 };
+			const integer X := 1;
+			function f() { var integer PX_H := 1 }
+		}`)
+
+	var actual []string
+	for _, p := range tree.ModulePars() {
+		actual = append(actual, p.Name+"|"+p.Type+"|"+p.Default)
+	}
+	assert.Equal(t, []string{
+		"PX_A|integer|1",
+		"PX_B|integer|",
+		`PX_C|charstring|"foo" & "bar"`,
+		"PX_D|float|1.0",
+		"PX_E|boolean|",
+		"PX_F|R.f|{ x := 1 }",
+		"PX_G|integer[2]|{ 1, 2 }",
+	}, actual)
+}
+
+func TestDocTags(t *testing.T) {
+	tree := ttcn3.Parse(`
+		module M {
+			// @tag A
+			// @after M.B, M.C
+			testcase A() runs on C {}
+
+			testcase B() runs on C {}
+		}`)
+
+	var tcs []syntax.Node
+	tree.Inspect(func(n syntax.Node) bool {
+		if n, ok := n.(*syntax.FuncDecl); ok {
+			tcs = append(tcs, n)
+		}
+		return true
+	})
+	assert.Equal(t, [][]string{{"@tag", "A"}, {"@after", "M.B, M.C"}}, tree.DocTags(tcs[0]))
+	assert.Nil(t, tree.DocTags(tcs[1]))
+
+	// Cached results are identical.
+	assert.Equal(t, tree.DocTags(tcs[0]), tree.DocTags(tcs[0]))
+}
+
+// BenchmarkDocTags compares tag extraction of a tag-heavy module with and
+// without caching.
+func BenchmarkDocTags(b *testing.B) {
+	var sb strings.Builder
+	sb.WriteString("module M {\n")
+	for i := 0; i < 500; i++ {
+		for j := 0; j < 10; j++ {
+			fmt.Fprintf(&sb, "// @tag%d value %d %d\n", j, i, j)
+		}
+		fmt.Fprintf(&sb, "testcase TC%d() runs on C {}\n", i)
+	}
+	sb.WriteString("}\n")
+	tree := ttcn3.Parse(sb.String())
+
+	var tcs []syntax.Node
+	tree.Inspect(func(n syntax.Node) bool {
+		if n, ok := n.(*syntax.FuncDecl); ok {
+			tcs = append(tcs, n)
+		}
+		return true
+	})
+
+	b.Run("uncached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, n := range tcs {
+				doc.FindAllTags(syntax.Doc(n))
+			}
+		}
+	})
+	b.Run("cached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, n := range tcs {
+				tree.DocTags(n)
+			}
+		}
+	})
+}
+
+// BenchmarkParentOf simulates a lint pass with several rules, each looking up
+// the module of every identifier of a large file.
+func BenchmarkParentOf(b *testing.B) {
+	var sb strings.Builder
+	sb.WriteString("module M {\n")
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&sb, "function f%d(integer p) return integer {\n", i)
+		for j := 0; j < 20; j++ {
+			fmt.Fprintf(&sb, "\tvar integer x%d := p + %d * f%d(p);\n", j, j, i)
+		}
+		sb.WriteString("\treturn p;\n}\n")
+	}
+	sb.WriteString("}\n")
+	src := sb.String()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		tree := ttcn3.Parse(src)
+		b.StartTimer()
+		for rule := 0; rule < 5; rule++ {
+			tree.Inspect(func(n syntax.Node) bool {
+				if id, ok := n.(*syntax.Ident); ok {
+					tree.ModuleOf(id)
+				}
+				return true
+			})
+		}
+	}
+}
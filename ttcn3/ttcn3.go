@@ -7,8 +7,10 @@ import (
 	"context"
 	"runtime"
 	"strings"
+	"sync"
 
 	"github.com/nokia/ntt/internal/fs"
+	"github.com/nokia/ntt/internal/loc"
 	"github.com/nokia/ntt/internal/memoize"
 	"github.com/nokia/ntt/ttcn3/syntax"
 )
@@ -44,22 +46,140 @@ func ModuleName(s string) string {
 
 // Parse parses a string and returns a syntax tree.
 func Parse(src string) *Tree {
-	return parse("", []byte(src))
+	return parse(context.Background(), "", []byte(src))
+}
+
+// ParseBytes parses src as content of file name and returns a syntax tree.
+// Unlike ParseFile, the file system and the cache are not used, hence
+// repeated calls with the same name but different content do not collide.
+// Positions are resolved by the line table of the returned tree, like for
+// every tree.
+func ParseBytes(name string, src []byte) *Tree {
+	// parse reads the file, if input is nil.
+	if src == nil {
+		src = []byte{}
+	}
+	return parse(context.Background(), name, src)
+}
+
+// ParseString is like ParseBytes, but parses a string.
+func ParseString(name, src string) *Tree {
+	return ParseBytes(name, []byte(src))
 }
 
 // ParseFile parses a file and returns a syntax tree.
 func ParseFile(path string) *Tree {
+	return ParseFileContext(context.Background(), path)
+}
+
+// ParseFileContext is like ParseFile, but aborts parsing when ctx is done.
+// The tree of an aborted parse has the error of the context and is not
+// cached.
+func ParseFileContext(ctx context.Context, path string) *Tree {
 	f := fs.Open(path)
 	f.Handle = cache.Bind(f.ID(), func(ctx context.Context) interface{} {
-		return parse(path, nil)
+		return parse(ctx, path, nil)
 	})
 
-	return f.Handle.Get(context.TODO()).(*Tree)
+	for {
+		if tree, ok := f.Handle.Get(ctx).(*Tree); ok {
+			return tree
+		}
+		// Get also returns nil, when a parse shared with other callers
+		// was cancelled by them. Then we parse again.
+		if err := ctx.Err(); err != nil {
+			return &Tree{Err: err, filename: path}
+		}
+	}
+}
+
+// ParseFiles parses the given files concurrently and returns their syntax
+// trees in the same order. Errors are attached to the trees. Files not parsed
+// before ctx is done get a tree with the error of the context.
+func ParseFiles(ctx context.Context, files ...string) []*Tree {
+	trees := make([]*Tree, len(files))
+	var wg sync.WaitGroup
+	wg.Add(len(files))
+	for i, file := range files {
+		go func(i int, file string) {
+			defer wg.Done()
+			if err := ctx.Err(); err != nil {
+				trees[i] = &Tree{Err: err, filename: file}
+				return
+			}
+			trees[i] = ParseFileContext(ctx, file)
+		}(i, file)
+	}
+	wg.Wait()
+	return trees
+}
+
+// ParseFilesWithFileSet parses the given files like ParseFiles and adds them,
+// in the order given, to the shared file set fset. The returned files are in
+// the same order as the trees and map the offsets of a tree to positions of
+// fset. Thus positions of all trees of a batch are comparable and resolved by
+// a single fset.Position.
+//
+// The trees are still cached and shared per file, like with ParseFile. They
+// do not reference fset; a tree parsed in several batches is added to each
+// file set separately.
+//
+// A FileSet is safe for concurrent use: trees are parsed concurrently, but
+// files are added only after all parsing is done, so the bases do not depend
+// on scheduling. Other goroutines may add files to fset or resolve positions
+// at the same time.
+func ParseFilesWithFileSet(ctx context.Context, fset *loc.FileSet, files ...string) ([]*Tree, []*loc.File) {
+	trees := ParseFiles(ctx, files...)
+	lfs := make([]*loc.File, len(trees))
+	for i, tree := range trees {
+		lfs[i] = addFile(fset, files[i], tree)
+	}
+	return trees, lfs
+}
+
+// addFile adds the file of tree with the given name and its line table to
+// fset.
+func addFile(fset *loc.FileSet, name string, tree *Tree) *loc.File {
+	if tree.Root == nil {
+		return fset.AddFile(name, -1, 0)
+	}
+	size := tree.Root.Size()
+	lines := tree.Root.Lines()
+	for len(lines) > 0 && lines[len(lines)-1] >= size {
+		lines = lines[:len(lines)-1]
+	}
+	f := fset.AddFile(name, -1, size)
+	f.SetLines(append([]int(nil), lines...))
+	return f
+}
+
+// ParseDir parses the TTCN-3 files of directory dir, as listed by
+// fs.TTCN3Files, concurrently. Subdirectories are not parsed. The returned map
+// is indexed by file name. Syntax errors do not fail ParseDir, but are
+// attached to the trees. An error is returned if dir could not be read. If ctx
+// is done, ParseDir returns the trees along with the error of the context.
+//
+// Like with ParseFile, trees are cached per file and at most one file per CPU
+// is parsed at a time.
+func ParseDir(ctx context.Context, dir string) (map[string]*Tree, error) {
+	files, err := fs.TTCN3Files(dir)
+	if err != nil {
+		return nil, err
+	}
+	ret := make(map[string]*Tree, len(files))
+	for i, tree := range ParseFiles(ctx, files...) {
+		ret[files[i]] = tree
+	}
+	return ret, ctx.Err()
 }
 
-func parse(path string, input []byte) *Tree {
+func parse(ctx context.Context, path string, input []byte) *Tree {
 	// Without parseLimit we may end up with too many open files.
-	parseLimit <- struct{}{}
+	select {
+	case parseLimit <- struct{}{}:
+	case <-ctx.Done():
+		return &Tree{Err: ctx.Err(), filename: path}
+	}
 	defer func() { <-parseLimit }()
 
 	if input == nil {
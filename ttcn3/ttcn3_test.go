@@ -0,0 +1,120 @@
+package ttcn3_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nokia/ntt/internal/loc"
+	"github.com/nokia/ntt/ttcn3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBytes(t *testing.T) {
+	a := ttcn3.ParseString("test.ttcn3", "module A {}")
+	b := ttcn3.ParseString("test.ttcn3", "module B {\n  const integer x := ;\n}")
+	assert.Nil(t, a.Err)
+	assert.Equal(t, "test.ttcn3", a.Filename())
+	assert.Equal(t, "A", a.Modules()[0].Ident.String())
+
+	// Same name, but different content does not collide.
+	assert.NotNil(t, b.Err)
+	assert.Equal(t, "B", b.Modules()[0].Ident.String())
+	line, col, file := b.Position(b.PosFor(13))
+	assert.Equal(t, []interface{}{2, 3, "test.ttcn3"}, []interface{}{line, col, file})
+
+	// Nil content is an empty file, which is not read from disk.
+	c := ttcn3.ParseBytes("does-not-exist.ttcn3", nil)
+	assert.Nil(t, c.Err)
+	assert.Len(t, c.Modules(), 0)
+}
+
+func TestParseDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.ttcn3":     "module A {}",
+		"b.ttcn":      "module B { syntax error }",
+		"c.txt":       "not TTCN-3",
+		"sub/d.ttcn3": "module D {}",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	trees, err := ttcn3.ParseDir(context.Background(), dir)
+	assert.Nil(t, err)
+	assert.Len(t, trees, 2)
+
+	a := trees[filepath.Join(dir, "a.ttcn3")]
+	assert.NotNil(t, a)
+	assert.Nil(t, a.Err)
+	assert.Len(t, a.Modules(), 1)
+
+	b := trees[filepath.Join(dir, "b.ttcn")]
+	assert.NotNil(t, b)
+	assert.NotNil(t, b.Err)
+
+	_, err = ttcn3.ParseDir(context.Background(), filepath.Join(dir, "missing"))
+	assert.NotNil(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	trees, err = ttcn3.ParseDir(ctx, dir)
+	assert.Equal(t, context.Canceled, err)
+	for _, tree := range trees {
+		assert.Equal(t, context.Canceled, tree.Err)
+	}
+}
+
+func TestParseFilesWithFileSet(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.ttcn3")
+	b := filepath.Join(dir, "b.ttcn3")
+	os.WriteFile(a, []byte("module A {}\n"), 0644)
+	os.WriteFile(b, []byte("module B {\n  const integer x := 1;\n}\n"), 0644)
+
+	fset := loc.NewFileSet()
+	trees, files := ttcn3.ParseFilesWithFileSet(context.Background(), fset, a, b)
+	assert.Len(t, trees, 2)
+	assert.Len(t, files, 2)
+
+	// Positions of different files are distinct and resolved by the
+	// shared file set.
+	x := trees[1].Root.Lines()[1] + 2
+	pos := fset.Position(files[1].Pos(x))
+	assert.Equal(t, b, pos.Filename)
+	assert.Equal(t, 2, pos.Line)
+	assert.Equal(t, 3, pos.Column)
+	assert.Equal(t, a, fset.Position(files[0].Pos(0)).Filename)
+	assert.Less(t, files[0].Base()+files[0].Size(), files[1].Base())
+}
+
+func TestParseFileContext(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.ttcn3")
+	os.WriteFile(path, []byte("module A {}\n"), 0644)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	tree := ttcn3.ParseFileContext(ctx, path)
+	assert.Equal(t, context.Canceled, tree.Err)
+	assert.Equal(t, path, tree.Filename())
+
+	// Waiting for a free parser slot is cancelled, too.
+	unblock := ttcn3.BlockParser()
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	tree = ttcn3.ParseFileContext(ctx, path)
+	unblock()
+	assert.Equal(t, context.DeadlineExceeded, tree.Err)
+
+	// Aborted parses are not cached.
+	tree = ttcn3.ParseFile(path)
+	assert.Nil(t, tree.Err)
+	assert.Len(t, tree.Modules(), 1)
+}
@@ -0,0 +1,171 @@
+package ttcn3
+
+import "github.com/nokia/ntt/ttcn3/syntax"
+
+// A Diagnostic is a problem found by analysing the syntax tree. Its node
+// provides the position of the problem.
+type Diagnostic = syntax.Error
+
+// UnreachableCode returns a diagnostic for every statement which can never be
+// executed, because it follows a statement that unconditionally terminates
+// the enclosing block. def is a function, testcase, altstep, control part or
+// block statement. Following statements are terminators:
+//
+//   - return
+//   - stop, self.stop, mtc.stop, self.kill, mtc.kill and testcase.stop
+//   - repeat, break, continue and goto
+//   - if-else statements, whose branches all terminate
+//   - select statements with case-else, whose cases all terminate
+//   - alt and interleave statements, whose alternatives all terminate
+//     without break
+//
+// Loops are never considered terminators. Only the first unreachable
+// statement of a sequence is reported. A label makes the statements following
+// it reachable again.
+func UnreachableCode(def *Definition) []Diagnostic {
+	if def == nil {
+		return nil
+	}
+	var body *syntax.BlockStmt
+	switch n := def.Node.(type) {
+	case *syntax.FuncDecl:
+		body = n.Body
+	case *syntax.ControlPart:
+		body = n.Body
+	case *syntax.BlockStmt:
+		body = n
+	}
+	var a unreachableAnalysis
+	a.block(body)
+	return a.diags
+}
+
+type unreachableAnalysis struct {
+	diags []Diagnostic
+}
+
+// block analyses the statements of b. term is true if b unconditionally
+// terminates. brk is true if b may be left via break.
+func (a *unreachableAnalysis) block(b *syntax.BlockStmt) (term bool, brk bool) {
+	if b == nil {
+		return false, false
+	}
+	reported := false
+	for _, s := range b.Stmts {
+		if isLabel(s) {
+			term, reported = false, false
+		}
+		if term {
+			if !reported {
+				a.diags = append(a.diags, Diagnostic{Node: s, Msg: "unreachable code"})
+				reported = true
+			}
+			continue
+		}
+		t, br := a.stmt(s)
+		term = t
+		brk = brk || br
+	}
+	return term, brk
+}
+
+// stmt analyses statement s. Results are like those of block.
+func (a *unreachableAnalysis) stmt(s syntax.Stmt) (term bool, brk bool) {
+	switch s := s.(type) {
+	case *syntax.ReturnStmt:
+		return true, false
+	case *syntax.BranchStmt:
+		switch s.Tok.Kind() {
+		case syntax.BREAK:
+			return true, true
+		case syntax.REPEAT, syntax.CONTINUE, syntax.GOTO:
+			return true, false
+		}
+	case *syntax.ExprStmt:
+		return isStop(s.Expr), false
+	case *syntax.BlockStmt:
+		return a.block(s)
+	case *syntax.IfStmt:
+		t1, b1 := a.block(s.Then)
+		if s.Else == nil {
+			return false, b1
+		}
+		t2, b2 := a.stmt(s.Else)
+		return t1 && t2, b1 || b2
+	case *syntax.SelectStmt:
+		term = false
+		for _, c := range s.Body {
+			if c.Case == nil {
+				term = true
+			}
+		}
+		for _, c := range s.Body {
+			t, b := a.block(c.Body)
+			term = term && t
+			brk = brk || b
+		}
+		return term, brk
+	case *syntax.AltStmt:
+		return a.alternatives(s.Body), false
+	case *syntax.CallStmt:
+		a.alternatives(s.Body)
+	case *syntax.CommClause:
+		a.block(s.Body)
+	case *syntax.ForStmt:
+		a.block(s.Body)
+	case *syntax.ForRangeStmt:
+		a.block(s.Body)
+	case *syntax.WhileStmt:
+		a.block(s.Body)
+	case *syntax.DoWhileStmt:
+		a.block(s.Body)
+	}
+	return false, false
+}
+
+// alternatives analyses the alternatives of an alt, interleave or call
+// statement and returns true if all of them terminate. Break leaves the
+// alternatives and does not terminate.
+func (a *unreachableAnalysis) alternatives(b *syntax.BlockStmt) bool {
+	if b == nil {
+		return false
+	}
+	term := len(b.Stmts) > 0
+	for _, s := range b.Stmts {
+		c, ok := s.(*syntax.CommClause)
+		if !ok {
+			a.stmt(s)
+			term = false
+			continue
+		}
+		t, brk := a.block(c.Body)
+		term = term && t && !brk
+	}
+	return term
+}
+
+func isLabel(s syntax.Stmt) bool {
+	b, ok := s.(*syntax.BranchStmt)
+	return ok && b.Tok.Kind() == syntax.LABEL
+}
+
+// isStop returns true if x stops or kills the running component or test
+// case.
+func isStop(x syntax.Expr) bool {
+	if c, ok := x.(*syntax.CallExpr); ok {
+		x = c.Fun
+	}
+	switch x := x.(type) {
+	case *syntax.Ident:
+		return x.String() == "stop"
+	case *syntax.SelectorExpr:
+		obj, op := syntax.Name(x.X), syntax.Name(x.Sel)
+		switch {
+		case (obj == "self" || obj == "mtc") && (op == "stop" || op == "kill"):
+			return true
+		case obj == "testcase" && op == "stop":
+			return true
+		}
+	}
+	return false
+}
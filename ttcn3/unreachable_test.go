@@ -0,0 +1,53 @@
+package ttcn3_test
+
+import (
+	"testing"
+
+	"github.com/nokia/ntt/ttcn3"
+	"github.com/nokia/ntt/ttcn3/syntax"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnreachableCode(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{input: `{ f(); g() }`, want: nil},
+		{input: `{ return; f(); g() }`, want: []string{"f()"}},
+		{input: `{ stop; f() }`, want: []string{"f()"}},
+		{input: `{ self.stop; f() }`, want: []string{"f()"}},
+		{input: `{ mtc.kill; f() }`, want: []string{"f()"}},
+		{input: `{ testcase.stop("reason"); f() }`, want: []string{"f()"}},
+		{input: `{ p.stop; t.stop; f() }`, want: nil},
+		{input: `{ return; label L; f() }`, want: nil},
+		{input: `{ { return } f() }`, want: []string{"f()"}},
+		{input: `{ if (x) { return } f() }`, want: nil},
+		{input: `{ if (x) { return } else { stop } f() }`, want: []string{"f()"}},
+		{input: `{ if (x) { return } else if (y) { stop } f() }`, want: nil},
+		{input: `{ if (x) { return; g() } }`, want: []string{"g()"}},
+		{input: `{ while (x) { break; g() } f() }`, want: []string{"g()"}},
+		{input: `{ for (var integer i := 0; i < 1; i := i + 1) { return } f() }`, want: nil},
+		{input: `{ select (x) { case (1) { return } case else { stop } } f() }`, want: []string{"f()"}},
+		{input: `{ select (x) { case (1) { return } } f() }`, want: nil},
+		{input: `{ alt { [] p.receive { repeat } [] t.timeout { stop } } f() }`, want: []string{"f()"}},
+		{input: `{ alt { [] p.receive { repeat } [] t.timeout {} } f() }`, want: nil},
+		{input: `{ alt { [] p.receive { break } [] t.timeout { stop } } f() }`, want: nil},
+		{input: `{ alt { [] p.receive { return; g() } } }`, want: []string{"g()"}},
+	}
+
+	for _, tt := range tests {
+		tree := ttcn3.Parse("module M { function f() " + tt.input + " }")
+		if tree.Err != nil {
+			t.Fatalf("%s: %s", tt.input, tree.Err)
+		}
+		var got []string
+		for _, f := range tree.Funcs() {
+			for _, d := range ttcn3.UnreachableCode(f) {
+				got = append(got, syntax.Text(d.Node))
+			}
+		}
+		assert.Equal(t, tt.want, got, tt.input)
+	}
+	assert.Nil(t, ttcn3.UnreachableCode(nil))
+}
@@ -3,6 +3,7 @@ package printer_test
 import (
 	"os"
 
+	"github.com/nokia/ntt/ttcn3/syntax"
 	"github.com/nokia/ntt/ttcn3/v2/printer"
 )
 
@@ -31,3 +32,29 @@ func ExampleCanonicalPrinter() {
 	//     y := 234, // Comment 2
 	//   }
 }
+
+func ExampleFormat() {
+	b, err := printer.Format([]byte("module M {\nfunction f() {\nvar integer x:=1; // Comment\n}\n}\n"))
+	if err != nil {
+		panic(err)
+	}
+	os.Stdout.Write(b)
+	// Output:
+	// module M {
+	// function f() {
+	// 	var integer x := 1; // Comment
+	// }
+	// }
+}
+
+func ExampleFprint_node() {
+	root, _, _ := syntax.Parse([]byte("module M {\nfunction f() {\nx:=1; // Comment\n}\n}"))
+	def := root.Nodes[0].(*syntax.Module).Defs[0]
+	if err := printer.Fprint(os.Stdout, def); err != nil {
+		panic(err)
+	}
+	// Output:
+	// function f() {
+	// 	x := 1; // Comment
+	// }
+}
@@ -2,11 +2,13 @@
 package printer
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"strings"
 	"text/tabwriter"
 
+	ttcn3syntax "github.com/nokia/ntt/ttcn3/syntax"
 	"github.com/nokia/ntt/ttcn3/v2/syntax"
 )
 
@@ -23,11 +25,25 @@ const (
 
 // Fprint formats src in canonical TTCN-3 style and writes the result to w or
 // returns an (I/O or syntax) error. src is expected to be syntactically
-// correct TTCN-3 source text.
+// correct TTCN-3 source text, given as []byte, string, io.Reader or as node
+// of package ttcn3/syntax, whose source text including comments is
+// formatted.
 func Fprint(w io.Writer, src interface{}) error {
 	return NewCanonicalPrinter(w).Fprint(src)
 }
 
+// Format formats the TTCN-3 source file src in canonical style, like ntt
+// format does, and returns the result. Module definitions are not indented.
+func Format(src []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	p := NewCanonicalPrinter(&buf)
+	p.Indent = -1
+	if err := p.Fprint(src); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
 // CanonicalPrinter is a simple formatter that only fixes indentation and
 // various whitespace issues.
 type CanonicalPrinter struct {
@@ -255,6 +271,8 @@ func toBytes(v interface{}) ([]byte, error) {
 		return []byte(v), nil
 	case io.Reader:
 		return io.ReadAll(v)
+	case ttcn3syntax.Node:
+		return []byte(ttcn3syntax.Text(v)), nil
 	default:
 		return nil, fmt.Errorf("printer: unsupported type %T", v)
 	}